@@ -21,6 +21,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"regexp"
+	"sync"
 
 	"github.com/minio/minio/pkg/probe"
 )
@@ -47,7 +48,20 @@ const (
 var isValidSecretKey = regexp.MustCompile(`^.{8,40}$`)
 
 // isValidAccessKey - validate access key.
-var isValidAccessKey = regexp.MustCompile(`^[a-zA-Z0-9\\-\\.\\_\\~]{5,20}$`)
+var isValidAccessKey = regexp.MustCompile(`^[a-zA-Z0-9\-\.\_\~]{5,20}$`)
+
+// Validate - verifies the keys meet S3 constraints, access keys of 5 to
+// 20 letters, digits, '-', '.', '_' or '~' and secret keys of 8 to 40
+// characters.
+func (a credential) Validate() error {
+	if !isValidAccessKey.MatchString(a.AccessKeyID) {
+		return errInvalidAccessKey
+	}
+	if !isValidSecretKey.MatchString(a.SecretAccessKey) {
+		return errInvalidSecretKey
+	}
+	return nil
+}
 
 // mustGenAccessKeys - must generate access credentials.
 func mustGenAccessKeys() (creds credential) {
@@ -70,6 +84,9 @@ func genAccessKeys() (credential, *probe.Error) {
 		AccessKeyID:     string(accessKeyID),
 		SecretAccessKey: string(secretAccessKey),
 	}
+	if e := creds.Validate(); e != nil {
+		return credential{}, probe.NewError(e)
+	}
 	return creds, nil
 }
 
@@ -94,3 +111,32 @@ func genSecretAccessKey() ([]byte, *probe.Error) {
 	}
 	return []byte(base64.StdEncoding.EncodeToString(rb))[:minioSecretID], nil
 }
+
+// rotateMutex - serializes rotations of the server credential.
+var rotateMutex = &sync.Mutex{}
+
+// rotateCredentials - replaces the credential of the running server
+// and saves it to the config file, the previous credential is restored
+// if saving fails. Requests are authenticated against the credential
+// current when their signature is verified, there is no grace period:
+// requests signed with the previous credential are rejected once the
+// credential rotated, including uploads whose payload is still being
+// verified, and browser sessions have to login again.
+func rotateCredentials(newAccess, newSecret string) *probe.Error {
+	rotateMutex.Lock()
+	defer rotateMutex.Unlock()
+
+	oldCreds := serverConfig.GetCredential()
+	err := serverConfig.SetCredential(credential{
+		AccessKeyID:     newAccess,
+		SecretAccessKey: newSecret,
+	})
+	if err != nil {
+		return err.Trace(newAccess)
+	}
+	if err = serverConfig.Save(); err != nil {
+		serverConfig.SetCredential(oldCreds)
+		return err.Trace(newAccess)
+	}
+	return nil
+}
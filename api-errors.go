@@ -78,15 +78,23 @@ const (
 	ErrSignatureDoesNotMatch
 	ErrMethodNotAllowed
 	ErrInvalidPart
+	ErrInvalidPartNumber
 	ErrInvalidPartOrder
+	ErrInvalidStorageClass
+	ErrInvalidHeaderValue
+	ErrMetadataTooLarge
 	ErrAuthorizationHeaderMalformed
 	ErrMalformedPOSTRequest
 	ErrSignatureVersionNotSupported
 	ErrBucketNotEmpty
 	ErrRootPathFull
+	ErrRootPathNotFound
 	ErrObjectExistsAsPrefix
+	ErrPrefixExistsAsObject
 	ErrAllAccessDisabled
 	ErrMalformedPolicy
+	ErrPolicyTooLarge
+	ErrQuotaExceeded
 	ErrMissingFields
 	ErrMissingCredTag
 	ErrCredMalformed
@@ -270,11 +278,31 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "One or more of the specified parts could not be found.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidPartNumber: {
+		Code:           "InvalidArgument",
+		Description:    "Part number must be an integer between 1 and 10000, inclusive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidPartOrder: {
 		Code:           "InvalidPartOrder",
 		Description:    "The list of parts was not in ascending order. The parts list must be specified in order by part number.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidStorageClass: {
+		Code:           "InvalidStorageClass",
+		Description:    "The storage class you specified is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidHeaderValue: {
+		Code:           "InvalidArgument",
+		Description:    "Header values must not contain line breaks.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMetadataTooLarge: {
+		Code:           "MetadataTooLarge",
+		Description:    "Your metadata headers exceed the maximum allowed metadata size.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrAuthorizationHeaderMalformed: {
 		Code:           "AuthorizationHeaderMalformed",
 		Description:    "The authorization header is malformed; the region is wrong; expecting 'us-east-1'.",
@@ -300,11 +328,21 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Root path has reached its minimum free disk threshold. Please delete few objects to proceed.",
 		HTTPStatusCode: http.StatusInternalServerError,
 	},
+	ErrRootPathNotFound: {
+		Code:           "RootPathNotFound",
+		Description:    "Root path is missing or not a directory. Please verify the server data path.",
+		HTTPStatusCode: http.StatusInternalServerError,
+	},
 	ErrObjectExistsAsPrefix: {
 		Code:           "ObjectExistsAsPrefix",
 		Description:    "An object already exists as your prefix, choose a different prefix to proceed.",
 		HTTPStatusCode: http.StatusConflict,
 	},
+	ErrPrefixExistsAsObject: {
+		Code:           "PrefixExistsAsObject",
+		Description:    "A prefix already exists as your object name, choose a different object name to proceed.",
+		HTTPStatusCode: http.StatusConflict,
+	},
 	ErrAllAccessDisabled: {
 		Code:           "AllAccessDisabled",
 		Description:    "All access to this bucket has been disabled.",
@@ -315,6 +353,16 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Policy has invalid resource.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrQuotaExceeded: {
+		Code:           "QuotaExceeded",
+		Description:    "The write would exceed the quota of the bucket.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrPolicyTooLarge: {
+		Code:           "MalformedPolicy",
+		Description:    "Policy has too many statements, or statements with too many actions or resources.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrMissingFields: {
 		Code:           "MissingFields",
 		Description:    "Missing fields in request.",
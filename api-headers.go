@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"runtime"
 	"strconv"
+	"strings"
 )
 
 //// helpers
@@ -57,6 +58,26 @@ func encodeResponse(response interface{}) []byte {
 	return bytesBuffer.Bytes()
 }
 
+// setContentHeadersMetadata - saves the content headers of a request
+// in the metadata of an object.
+func setContentHeadersMetadata(metadata map[string]string, header http.Header) {
+	metadata["contentEncoding"] = header.Get("Content-Encoding")
+	metadata["cacheControl"] = header.Get("Cache-Control")
+	metadata["contentDisposition"] = header.Get("Content-Disposition")
+	metadata["contentLanguage"] = header.Get("Content-Language")
+	metadata["expires"] = header.Get("Expires")
+}
+
+// setUserMetadata - saves the "x-amz-meta-" headers of a request in
+// the metadata of an object.
+func setUserMetadata(metadata map[string]string, header http.Header) {
+	for name := range header {
+		if strings.HasPrefix(strings.ToLower(name), userMetadataPrefix) {
+			metadata[name] = header.Get(name)
+		}
+	}
+}
+
 // Write object header
 func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, contentRange *httpRange) {
 	// set common headers
@@ -67,9 +88,26 @@ func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, contentRange *h
 	w.Header().Set("Last-Modified", lastModified)
 
 	w.Header().Set("Content-Type", objInfo.ContentType)
+	for header, value := range map[string]string{
+		"Content-Encoding":    objInfo.ContentEncoding,
+		"Cache-Control":       objInfo.CacheControl,
+		"Content-Disposition": objInfo.ContentDisposition,
+		"Content-Language":    objInfo.ContentLanguage,
+		"Expires":             objInfo.Expires,
+	} {
+		if value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+	for name, value := range objInfo.UserDefined {
+		w.Header().Set(name, value)
+	}
 	if objInfo.MD5Sum != "" {
 		w.Header().Set("ETag", "\""+objInfo.MD5Sum+"\"")
 	}
+	if objInfo.StorageClass != "" {
+		w.Header().Set("X-Amz-Storage-Class", objInfo.StorageClass)
+	}
 
 	w.Header().Set("Content-Length", strconv.FormatInt(objInfo.Size, 10))
 
@@ -20,6 +20,8 @@ import (
 	"encoding/xml"
 	"net/http"
 	"time"
+
+	"github.com/minio/minio/pkg/probe"
 )
 
 const (
@@ -175,6 +177,15 @@ type Owner struct {
 	DisplayName string
 }
 
+// newOwner - returns the owner for the given access key, "minio" when
+// the owner is unknown.
+func newOwner(accessKey string) Owner {
+	if accessKey == "" {
+		accessKey = "minio"
+	}
+	return Owner{ID: accessKey, DisplayName: accessKey}
+}
+
 // InitiateMultipartUploadResponse container for InitiateMultiPartUpload response, provides uploadID to start MultiPart upload
 type InitiateMultipartUploadResponse struct {
 	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult" json:"-"`
@@ -226,10 +237,7 @@ func getLocation(r *http.Request) string {
 func generateListBucketsResponse(buckets []BucketInfo) ListBucketsResponse {
 	var listbuckets []Bucket
 	var data = ListBucketsResponse{}
-	var owner = Owner{}
-
-	owner.ID = "minio"
-	owner.DisplayName = "minio"
+	var owner = newOwner(getOwner())
 
 	for _, bucket := range buckets {
 		var listbucket = Bucket{}
@@ -248,12 +256,8 @@ func generateListBucketsResponse(buckets []BucketInfo) ListBucketsResponse {
 func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKeys int, resp ListObjectsInfo) ListObjectsResponse {
 	var contents []Object
 	var prefixes []CommonPrefix
-	var owner = Owner{}
 	var data = ListObjectsResponse{}
 
-	owner.ID = "minio"
-	owner.DisplayName = "minio"
-
 	for _, object := range resp.Objects {
 		var content = Object{}
 		if object.Name == "" {
@@ -265,8 +269,11 @@ func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKe
 			content.ETag = "\"" + object.MD5Sum + "\""
 		}
 		content.Size = object.Size
-		content.StorageClass = "STANDARD"
-		content.Owner = owner
+		content.StorageClass = object.StorageClass
+		if content.StorageClass == "" {
+			content.StorageClass = storageClassStandard
+		}
+		content.Owner = newOwner(object.Owner)
 		contents = append(contents, content)
 	}
 	// TODO - support EncodingType in xml decoding
@@ -386,6 +393,26 @@ func generateMultiDeleteResponse(quiet bool, deletedObjects []ObjectIdentifier,
 	return deleteResp
 }
 
+// generate delete error for an object which failed to be deleted.
+func generateDeleteError(object string, err *probe.Error) DeleteError {
+	var errCode APIErrorCode
+	switch err.ToGoError().(type) {
+	case BucketNameInvalid:
+		errCode = ErrInvalidBucketName
+	case BucketNotFound:
+		errCode = ErrNoSuchBucket
+	case ObjectNotFound, ObjectNameInvalid:
+		errCode = ErrNoSuchKey
+	default:
+		errCode = ErrInternalError
+	}
+	return DeleteError{
+		Code:    errorCodeResponse[errCode].Code,
+		Message: errorCodeResponse[errCode].Description,
+		Key:     object,
+	}
+}
+
 // writeSuccessResponse write success headers and response if any.
 func writeSuccessResponse(w http.ResponseWriter, response []byte) {
 	setCommonHeaders(w)
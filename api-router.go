@@ -20,7 +20,7 @@ import router "github.com/gorilla/mux"
 
 // objectStorageAPI container for S3 compatible API.
 type objectStorageAPI struct {
-	ObjectAPI ObjectAPI
+	ObjectAPI ObjectLayer
 }
 
 // registerAPIRouter - registers S3 compatible APIs.
@@ -24,6 +24,7 @@ import (
 	"io"
 	"io/ioutil"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -32,27 +33,84 @@ import (
 	"github.com/minio/minio/pkg/probe"
 )
 
-// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-func enforceBucketPolicy(action string, bucket string, reqURL *url.URL) (s3Error APIErrorCode) {
+// loadBucketPolicy - reads and parses the saved policy of a bucket.
+func loadBucketPolicy(bucket string) (BucketPolicy, APIErrorCode) {
 	// Read saved bucket policy.
 	policy, err := readBucketPolicy(bucket)
 	if err != nil {
 		errorIf(err.Trace(bucket), "GetBucketPolicy failed.", nil)
 		switch err.ToGoError().(type) {
 		case BucketNotFound:
-			return ErrNoSuchBucket
+			return BucketPolicy{}, ErrNoSuchBucket
 		case BucketNameInvalid:
-			return ErrInvalidBucketName
+			return BucketPolicy{}, ErrInvalidBucketName
 		default:
 			// For any other error just return AccessDenied.
-			return ErrAccessDenied
+			return BucketPolicy{}, ErrAccessDenied
 		}
 	}
 	// Parse the saved policy.
 	bucketPolicy, e := parseBucketPolicy(policy)
 	if e != nil {
 		errorIf(probe.NewError(e), "Parse policy failed.", nil)
-		return ErrAccessDenied
+		return BucketPolicy{}, ErrAccessDenied
+	}
+	return bucketPolicy, ErrNone
+}
+
+// bucketPolicyConditions - returns the values of a request compared
+// against policy conditions, its query parameters such as "prefix" and
+// "max-keys" and its source address as "ip".
+func bucketPolicyConditions(r *http.Request) map[string]string {
+	conditions := make(map[string]string)
+	for queryParam := range r.URL.Query() {
+		conditions[queryParam] = r.URL.Query().Get(queryParam)
+	}
+	if host, _, e := net.SplitHostPort(r.RemoteAddr); e == nil {
+		conditions["ip"] = host
+	}
+	return conditions
+}
+
+// bucketPolicyAllows - returns true if the policy of bucket allows an
+// anonymous request for action on resource, such as
+// "arn:aws:s3:::bucket/object". Buckets without a policy allow nothing.
+func bucketPolicyAllows(bucket, action, resource string, r *http.Request) bool {
+	return bucketPolicyAllowsConditions(bucket, action, resource, bucketPolicyConditions(r))
+}
+
+// bucketPolicyAllowsConditions - see bucketPolicyAllows, with the
+// condition values of the request.
+func bucketPolicyAllowsConditions(bucket, action, resource string, conditions map[string]string) bool {
+	bucketPolicy, s3Error := loadBucketPolicy(bucket)
+	if s3Error != ErrNone {
+		return false
+	}
+	return bucketPolicyEvalStatements(action, resource, conditions, bucketPolicy.Statements)
+}
+
+// addObjectTagConditions - adds the tags of an object to the condition
+// values of a request as "ExistingObjectTag/<key>", object layers
+// without tagging add none.
+func addObjectTagConditions(conditions map[string]string, objAPI ObjectLayer, bucket, object string) {
+	fs, ok := objAPI.(*Filesystem)
+	if !ok {
+		return
+	}
+	tags, err := fs.GetObjectTags(bucket, object)
+	if err != nil {
+		return
+	}
+	for key, value := range tags {
+		conditions["ExistingObjectTag/"+key] = value
+	}
+}
+
+// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+func enforceBucketPolicy(action string, bucket string, reqURL *url.URL) (s3Error APIErrorCode) {
+	bucketPolicy, s3Error := loadBucketPolicy(bucket)
+	if s3Error != ErrNone {
+		return s3Error
 	}
 
 	// Construct resource in 'arn:aws:s3:::examplebucket' format.
@@ -61,7 +119,7 @@ func enforceBucketPolicy(action string, bucket string, reqURL *url.URL) (s3Error
 	// Get conditions for policy verification.
 	conditions := make(map[string]string)
 	for queryParam := range reqURL.Query() {
-		conditions[queryParam] = reqURL.Query().Get("queryParam")
+		conditions[queryParam] = reqURL.Query().Get(queryParam)
 	}
 
 	// Validate action, resource and conditions with current policy statements.
@@ -293,6 +351,8 @@ func (api objectStorageAPI) ListObjectsHandler(w http.ResponseWriter, r *http.Re
 		writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
 	case ObjectNameInvalid:
 		writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
+	case RootPathNotFound, RootPathNotDir:
+		writeErrorResponse(w, r, ErrRootPathNotFound, r.URL.Path)
 	default:
 		errorIf(err.Trace(), "ListObjects failed.", nil)
 		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
@@ -419,38 +479,7 @@ func (api objectStorageAPI) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 			})
 		} else {
 			errorIf(err.Trace(object.ObjectName), "DeleteObject failed.", nil)
-			switch err.ToGoError().(type) {
-			case BucketNameInvalid:
-				deleteErrors = append(deleteErrors, DeleteError{
-					Code:    errorCodeResponse[ErrInvalidBucketName].Code,
-					Message: errorCodeResponse[ErrInvalidBucketName].Description,
-					Key:     object.ObjectName,
-				})
-			case BucketNotFound:
-				deleteErrors = append(deleteErrors, DeleteError{
-					Code:    errorCodeResponse[ErrNoSuchBucket].Code,
-					Message: errorCodeResponse[ErrNoSuchBucket].Description,
-					Key:     object.ObjectName,
-				})
-			case ObjectNotFound:
-				deleteErrors = append(deleteErrors, DeleteError{
-					Code:    errorCodeResponse[ErrNoSuchKey].Code,
-					Message: errorCodeResponse[ErrNoSuchKey].Description,
-					Key:     object.ObjectName,
-				})
-			case ObjectNameInvalid:
-				deleteErrors = append(deleteErrors, DeleteError{
-					Code:    errorCodeResponse[ErrNoSuchKey].Code,
-					Message: errorCodeResponse[ErrNoSuchKey].Description,
-					Key:     object.ObjectName,
-				})
-			default:
-				deleteErrors = append(deleteErrors, DeleteError{
-					Code:    errorCodeResponse[ErrInternalError].Code,
-					Message: errorCodeResponse[ErrInternalError].Description,
-					Key:     object.ObjectName,
-				})
-			}
+			deleteErrors = append(deleteErrors, generateDeleteError(object.ObjectName, err))
 		}
 	}
 	// Generate response
@@ -566,6 +595,8 @@ func (api objectStorageAPI) PostPolicyBucketHandler(w http.ResponseWriter, r *ht
 		switch err.ToGoError().(type) {
 		case RootPathFull:
 			writeErrorResponse(w, r, ErrRootPathFull, r.URL.Path)
+		case RootPathNotFound, RootPathNotDir:
+			writeErrorResponse(w, r, ErrRootPathNotFound, r.URL.Path)
 		case BucketNotFound:
 			writeErrorResponse(w, r, ErrNoSuchBucket, r.URL.Path)
 		case BucketNameInvalid:
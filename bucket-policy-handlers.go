@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -28,24 +29,51 @@ import (
 	"github.com/minio/minio/pkg/probe"
 )
 
+// Header of DELETE bucket policy requests which succeed when the bucket
+// has no policy, for tooling expecting deletes to be idempotent.
+const idempotentDeleteHeader = "X-Minio-Idempotent-Delete"
+
 // maximum supported access policy size.
 const maxAccessPolicySize = 20 * 1024 * 1024 // 20KiB.
 
 // Verify if a given action is valid for the url path based on the
 // existing bucket access policy.
 func bucketPolicyEvalStatements(action string, resource string, conditions map[string]string, statements []policyStatement) bool {
-	for _, statement := range statements {
-		if bucketPolicyMatchStatement(action, resource, conditions, statement) {
-			if statement.Effect == "Allow" {
-				return true
-			}
-			// Do not uncomment kept here for readability.
-			// else statement.Effect == "Deny"
-			return false
+	allowed, _, _ := SimulatePolicy(statements, action, resource, conditions)
+	return allowed
+}
+
+// SimulatePolicy - evaluates statements for action, resource and
+// conditions just like a request would be evaluated. Returns if the
+// request is allowed, the index of the statement deciding it, -1 if
+// no statement matched, and a human readable reason.
+func SimulatePolicy(statements []policyStatement, action, resource string, conditions map[string]string) (allowed bool, matchedStatementIndex int, reason string) {
+	var mismatches []string
+	for i, statement := range statements {
+		if !bucketPolicyActionMatch(action, statement) {
+			mismatches = append(mismatches, fmt.Sprintf("statement %d action does not match", i))
+			continue
+		}
+		if !bucketPolicyResourceMatch(resource, statement) {
+			mismatches = append(mismatches, fmt.Sprintf("statement %d resource does not match", i))
+			continue
+		}
+		if !bucketPolicyConditionMatch(conditions, statement) {
+			mismatches = append(mismatches, fmt.Sprintf("statement %d conditions do not match", i))
+			continue
+		}
+		// First matching statement decides.
+		if statement.Effect == "Allow" {
+			return true, i, fmt.Sprintf("statement %d (%s) allows %s on %s", i, statement.Sid, action, resource)
 		}
+		return false, i, fmt.Sprintf("statement %d (%s) explicitly denies %s on %s", i, statement.Sid, action, resource)
 	}
 	// None match so deny.
-	return false
+	reason = fmt.Sprintf("no statement matches %s on %s, denied by default", action, resource)
+	if len(mismatches) > 0 {
+		reason += ": " + strings.Join(mismatches, ", ")
+	}
+	return false, -1, reason
 }
 
 // Verify if action, resource and conditions match input policy statement.
@@ -66,10 +94,8 @@ func bucketPolicyMatchStatement(action string, resource string, conditions map[s
 // Verify if given action matches with policy statement.
 func bucketPolicyActionMatch(action string, statement policyStatement) bool {
 	for _, policyAction := range statement.Actions {
-		// Policy action can be a regex, validate the action with matching string.
-		matched, e := regexp.MatchString(policyAction, action)
-		fatalIf(probe.NewError(e), "Invalid pattern, please verify the pattern string.", nil)
-		if matched {
+		// Policy action can contain wildcards.
+		if policyActionMatch(policyAction, action) {
 			return true
 		}
 	}
@@ -94,27 +120,47 @@ func bucketPolicyConditionMatch(conditions map[string]string, statement policySt
 	// Supports following conditions.
 	// - StringEquals
 	// - StringNotEquals
+	// - StringLike
+	// - StringNotLike
 	//
 	// Supported applicable condition keys for each conditions.
 	// - s3:prefix
-	// - s3:max-keys
+	// - s3:max-keys (only StringEquals, StringNotEquals)
+	// - s3:ExistingObjectTag/<key>
 	var conditionMatches = true
 	for condition, conditionKeys := range statement.Conditions {
+		if !bucketPolicyObjectTagMatch(condition, conditions, conditionKeys) {
+			conditionMatches = false
+			break
+		}
+		// Only condition keys present in the statement are compared.
+		prefix, prefixOK := conditionKeys["s3:prefix"]
+		maxKeys, maxKeysOK := conditionKeys["s3:max-keys"]
 		if condition == "StringEquals" {
-			if conditionKeys["s3:prefix"] != conditions["prefix"] {
+			if prefixOK && prefix != conditions["prefix"] {
 				conditionMatches = false
 				break
 			}
-			if conditionKeys["s3:max-keys"] != conditions["max-keys"] {
+			if maxKeysOK && maxKeys != conditions["max-keys"] {
 				conditionMatches = false
 				break
 			}
 		} else if condition == "StringNotEquals" {
-			if conditionKeys["s3:prefix"] == conditions["prefix"] {
+			if prefixOK && prefix == conditions["prefix"] {
+				conditionMatches = false
+				break
+			}
+			if maxKeysOK && maxKeys == conditions["max-keys"] {
+				conditionMatches = false
+				break
+			}
+		} else if condition == "StringLike" {
+			if prefixOK && !bucketPolicyWildcardMatch(prefix, conditions["prefix"]) {
 				conditionMatches = false
 				break
 			}
-			if conditionKeys["s3:max-keys"] == conditions["max-keys"] {
+		} else if condition == "StringNotLike" {
+			if prefixOK && bucketPolicyWildcardMatch(prefix, conditions["prefix"]) {
 				conditionMatches = false
 				break
 			}
@@ -123,6 +169,65 @@ func bucketPolicyConditionMatch(conditions map[string]string, statement policySt
 	return conditionMatches
 }
 
+// Verify if tags of the requested object, passed in conditions as
+// "ExistingObjectTag/<key>", match the tag condition keys. Objects
+// without a tag only match negated conditions on it.
+func bucketPolicyObjectTagMatch(condition string, conditions map[string]string, conditionKeys map[string]string) bool {
+	for key, value := range conditionKeys {
+		if !strings.HasPrefix(key, existingObjectTagPrefix) {
+			continue
+		}
+		tag, ok := conditions[strings.TrimPrefix(key, "s3:")]
+		switch condition {
+		case "StringEquals":
+			if !ok || tag != value {
+				return false
+			}
+		case "StringNotEquals":
+			if ok && tag == value {
+				return false
+			}
+		case "StringLike":
+			if !ok || !bucketPolicyWildcardMatch(value, tag) {
+				return false
+			}
+		case "StringNotLike":
+			if ok && bucketPolicyWildcardMatch(value, tag) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Verify if text matches the pattern, where '*' matches any sequence
+// of characters including '/' and '?' matches any single character.
+func bucketPolicyWildcardMatch(pattern, text string) bool {
+	// Position in pattern and text to retry from on mismatch after a '*'.
+	starIdx, retryIdx := -1, 0
+	p, t := 0, 0
+	for t < len(text) {
+		if p < len(pattern) && (pattern[p] == '?' || pattern[p] == text[t]) {
+			p++
+			t++
+		} else if p < len(pattern) && pattern[p] == '*' {
+			starIdx, retryIdx = p, t
+			p++
+		} else if starIdx != -1 {
+			// Let the last '*' consume one more character.
+			retryIdx++
+			p, t = starIdx+1, retryIdx
+		} else {
+			return false
+		}
+	}
+	// Remaining pattern may only be '*'.
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
 // PutBucketPolicyHandler - PUT Bucket policy
 // -----------------
 // This implementation of the PUT operation uses the policy
@@ -200,7 +305,9 @@ func (api objectStorageAPI) PutBucketPolicyHandler(w http.ResponseWriter, r *htt
 // DeleteBucketPolicyHandler - DELETE Bucket policy
 // -----------------
 // This implementation of the DELETE operation uses the policy
-// subresource to add to remove a policy on a bucket.
+// subresource to add to remove a policy on a bucket. With the
+// X-Minio-Idempotent-Delete header set to "true" deleting a missing
+// policy succeeds as well.
 func (api objectStorageAPI) DeleteBucketPolicyHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
@@ -218,7 +325,11 @@ func (api objectStorageAPI) DeleteBucketPolicyHandler(w http.ResponseWriter, r *
 	}
 
 	// Delete bucket access policy.
-	err := removeBucketPolicy(bucket)
+	removePolicy := removeBucketPolicy
+	if strings.EqualFold(r.Header.Get(idempotentDeleteHeader), "true") {
+		removePolicy = removeBucketPolicyIfExists
+	}
+	err := removePolicy(bucket)
 	if err != nil {
 		errorIf(err.Trace(bucket), "DeleteBucketPolicy failed.", nil)
 		switch err.ToGoError().(type) {
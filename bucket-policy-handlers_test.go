@@ -0,0 +1,361 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// Testing SimulatePolicy().
+func TestSimulatePolicy(t *testing.T) {
+	statements := []policyStatement{
+		{
+			Sid:       "DenyPrivate",
+			Effect:    "Deny",
+			Actions:   []string{"s3:GetObject"},
+			Resources: []string{AWSResourcePrefix + "photos/private/*"},
+		},
+		{
+			Sid:       "AllowRead",
+			Effect:    "Allow",
+			Actions:   []string{"s3:GetObject"},
+			Resources: []string{AWSResourcePrefix + "photos/*"},
+		},
+		{
+			Sid:        "AllowListPublic",
+			Effect:     "Allow",
+			Actions:    []string{"s3:ListBucket"},
+			Resources:  []string{AWSResourcePrefix + "photos"},
+			Conditions: map[string]map[string]string{"StringEquals": {"s3:prefix": "public/"}},
+		},
+	}
+
+	testCases := []struct {
+		action     string
+		resource   string
+		conditions map[string]string
+		allowed    bool
+		index      int
+		reason     string
+	}{
+		// Allowed by a matching statement.
+		{"s3:GetObject", AWSResourcePrefix + "photos/2016/jan.jpg", nil, true, 1, "statement 1 (AllowRead) allows"},
+		// Explicitly denied by the first matching statement.
+		{"s3:GetObject", AWSResourcePrefix + "photos/private/me.jpg", nil, false, 0, "statement 0 (DenyPrivate) explicitly denies"},
+		// Implicitly denied, no statement allows this action.
+		{"s3:PutObject", AWSResourcePrefix + "photos/2016/jan.jpg", nil, false, -1, "statement 1 action does not match"},
+		// Implicitly denied, no statement covers this resource.
+		{"s3:GetObject", AWSResourcePrefix + "videos/2016/jan.mp4", nil, false, -1, "statement 1 resource does not match"},
+		// Implicitly denied, conditions do not match.
+		{"s3:ListBucket", AWSResourcePrefix + "photos", map[string]string{"prefix": "private/"}, false, -1, "statement 2 conditions do not match"},
+	}
+	for i, testCase := range testCases {
+		allowed, index, reason := SimulatePolicy(statements, testCase.action, testCase.resource, testCase.conditions)
+		if allowed != testCase.allowed {
+			t.Errorf("Test %d: Expected allowed to be %v, but instead found %v", i+1, testCase.allowed, allowed)
+		}
+		if index != testCase.index {
+			t.Errorf("Test %d: Expected matched statement %d, but instead found %d", i+1, testCase.index, index)
+		}
+		if !strings.Contains(reason, testCase.reason) {
+			t.Errorf("Test %d: Expected reason to contain \"%s\", but instead found \"%s\"", i+1, testCase.reason, reason)
+		}
+		// Simulation agrees with the evaluation of requests.
+		if bucketPolicyEvalStatements(testCase.action, testCase.resource, testCase.conditions, statements) != allowed {
+			t.Errorf("Test %d: Expected simulation to agree with policy evaluation", i+1)
+		}
+	}
+}
+
+// Testing bucketPolicyConditionMatch() with wildcard conditions.
+func TestBucketPolicyConditionMatch(t *testing.T) {
+	testCases := []struct {
+		condition string
+		s3Prefix  string
+		prefix    string
+		matches   bool
+	}{
+		{"StringLike", "photos/*", "photos/2016", true},
+		{"StringLike", "photos/*", "photos/2016/jan", true},
+		{"StringLike", "photos/*", "videos/", false},
+		{"StringLike", "photos/201?", "photos/2016", true},
+		{"StringLike", "photos/201?", "photos/20166", false},
+		{"StringNotLike", "photos/*", "photos/2016", false},
+		{"StringNotLike", "photos/*", "videos/", true},
+		// StringEquals remains exact.
+		{"StringEquals", "photos/*", "photos/2016", false},
+		{"StringEquals", "photos/*", "photos/*", true},
+	}
+	for i, testCase := range testCases {
+		statement := policyStatement{
+			Conditions: map[string]map[string]string{
+				testCase.condition: {"s3:prefix": testCase.s3Prefix},
+			},
+		}
+		matches := bucketPolicyConditionMatch(map[string]string{"prefix": testCase.prefix}, statement)
+		if matches != testCase.matches {
+			t.Errorf("Test %d: Expected %s \"%s\" on \"%s\" to be %v, but instead found %v", i+1,
+				testCase.condition, testCase.s3Prefix, testCase.prefix, testCase.matches, matches)
+		}
+	}
+
+	// Wildcard conditions are accepted in policies.
+	if e := isValidConditions(map[string]map[string]string{"StringLike": {"s3:prefix": "photos/*"}}); e != nil {
+		t.Fatal(e)
+	}
+	if e := isValidConditions(map[string]map[string]string{"StringLike": {"s3:max-keys": "10"}}); e == nil {
+		t.Fatal("Expected StringLike on s3:max-keys to be rejected")
+	}
+}
+
+// Testing canned bucket policies.
+func TestCannedBucketPolicies(t *testing.T) {
+	metaDir, e := ioutil.TempDir("", "minio-policy-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(metaDir)
+
+	// Keep bucket policies in a temporary directory.
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{MetaDir: metaDir},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	testCases := []struct {
+		setPolicy func(bucket string) *probe.Error
+		action    string
+		path      string
+		allowed   bool
+	}{
+		// Read-only policy.
+		{setBucketReadOnlyPolicy, "s3:GetObject", "/readonly/photos/jan.jpg", true},
+		{setBucketReadOnlyPolicy, "s3:ListBucket", "/readonly", true},
+		{setBucketReadOnlyPolicy, "s3:PutObject", "/readonly/photos/jan.jpg", false},
+		{setBucketReadOnlyPolicy, "s3:DeleteObject", "/readonly/photos/jan.jpg", false},
+		// Read-write policy.
+		{setBucketReadWritePolicy, "s3:GetObject", "/readwrite/photos/jan.jpg", true},
+		{setBucketReadWritePolicy, "s3:PutObject", "/readwrite/photos/jan.jpg", true},
+		{setBucketReadWritePolicy, "s3:DeleteObject", "/readwrite/photos/jan.jpg", true},
+		{setBucketReadWritePolicy, "s3:ListBucketMultipartUploads", "/readwrite", true},
+		// Public policy allows downloads without listing.
+		{setBucketPublicPolicy, "s3:GetObject", "/public/photos/jan.jpg", true},
+		{setBucketPublicPolicy, "s3:ListBucket", "/public", false},
+		{setBucketPublicPolicy, "s3:PutObject", "/public/photos/jan.jpg", false},
+	}
+	for i, testCase := range testCases {
+		bucket := strings.Split(strings.TrimPrefix(testCase.path, "/"), "/")[0]
+		if err := testCase.setPolicy(bucket); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		// Saved policy passes the same validation as uploaded policies.
+		policyBytes, err := readBucketPolicy(bucket)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		policy, e := parseBucketPolicy(policyBytes)
+		if e != nil {
+			t.Fatalf("Test %d: %s", i+1, e)
+		}
+		if s3Error := checkBucketPolicy(bucket, policy); s3Error != ErrNone {
+			t.Fatalf("Test %d: Expected policy to be valid, got %s", i+1, getAPIError(s3Error).Description)
+		}
+		s3Error := enforceBucketPolicy(testCase.action, bucket, &url.URL{Path: testCase.path})
+		if allowed := s3Error == ErrNone; allowed != testCase.allowed {
+			t.Errorf("Test %d: Expected anonymous %s on %s allowed to be %v, but instead found %v", i+1, testCase.action, testCase.path, testCase.allowed, allowed)
+		}
+	}
+
+	// Invalid bucket name.
+	if err := setBucketReadOnlyPolicy("Invalid_Bucket"); err == nil {
+		t.Fatal("Expected canned policy of an invalid bucket name to fail")
+	}
+}
+
+// Testing limits of checkBucketPolicy().
+func TestCheckBucketPolicyLimits(t *testing.T) {
+	// policyWith - returns a policy of count statements, each with the
+	// given number of actions and resources.
+	policyWith := func(count, actions, resources int) BucketPolicy {
+		statement := policyStatement{
+			Effect:    "Allow",
+			Principal: policyUser{AWS: []string{"*"}},
+		}
+		for i := 0; i < actions; i++ {
+			statement.Actions = append(statement.Actions, "s3:GetObject")
+		}
+		for i := 0; i < resources; i++ {
+			statement.Resources = append(statement.Resources, AWSResourcePrefix+"bucket/*")
+		}
+		policy := BucketPolicy{Version: "2012-10-17"}
+		for i := 0; i < count; i++ {
+			policy.Statements = append(policy.Statements, statement)
+		}
+		return policy
+	}
+
+	testCases := []struct {
+		policy  BucketPolicy
+		s3Error APIErrorCode
+	}{
+		{policyWith(50, 1, 1), ErrNone},
+		{policyWith(maxPolicyStatements, 1, 1), ErrNone},
+		{policyWith(200, 1, 1), ErrPolicyTooLarge},
+		{policyWith(1, maxPolicyStatementActions+1, 1), ErrPolicyTooLarge},
+		{policyWith(1, 1, maxPolicyStatementResources+1), ErrPolicyTooLarge},
+	}
+	for i, testCase := range testCases {
+		policyBytes, e := json.Marshal(testCase.policy)
+		if e != nil {
+			t.Fatal(e)
+		}
+		policy, e := parseBucketPolicy(policyBytes)
+		if e != nil {
+			t.Fatalf("Test %d: %s", i+1, e)
+		}
+		if s3Error := checkBucketPolicy("bucket", policy); s3Error != testCase.s3Error {
+			t.Errorf("Test %d: Expected %s, but instead found %s", i+1,
+				getAPIError(testCase.s3Error).Description, getAPIError(s3Error).Description)
+		}
+	}
+}
+
+// Testing validation and matching of policy actions.
+func TestBucketPolicyActions(t *testing.T) {
+	testCases := []struct {
+		action string
+		valid  bool
+		// Supported actions matched, all of them when nil.
+		matches []string
+	}{
+		// Literal action.
+		{"s3:GetObject", true, []string{"s3:GetObject"}},
+		// Literal actions compare case insensitively.
+		{"s3:getobject", true, []string{"s3:GetObject"}},
+		// Wildcards.
+		{"s3:*", true, nil},
+		{"s3:Get*", true, []string{"s3:GetObject", "s3:GetBucketLocation"}},
+		// Typos never match and are rejected.
+		{"s3:GetObjectz", false, nil},
+		{"s3:Fetch*", false, nil},
+	}
+	for i, testCase := range testCases {
+		statement := policyStatement{
+			Effect:    "Allow",
+			Principal: policyUser{AWS: []string{"*"}},
+			Actions:   []string{testCase.action},
+			Resources: []string{AWSResourcePrefix + "bucket/*"},
+		}
+		policyBytes, e := json.Marshal(BucketPolicy{Version: "2012-10-17", Statements: []policyStatement{statement}})
+		if e != nil {
+			t.Fatal(e)
+		}
+		policy, e := parseBucketPolicy(policyBytes)
+		if valid := e == nil; valid != testCase.valid {
+			t.Fatalf("Test %d: Expected %s valid to be %v, but instead found %v", i+1, testCase.action, testCase.valid, e)
+		}
+		if !testCase.valid {
+			continue
+		}
+		for action := range supportedActionMap {
+			expected := testCase.matches == nil
+			for _, match := range testCase.matches {
+				expected = expected || match == action
+			}
+			if bucketPolicyActionMatch(action, policy.Statements[0]) != expected {
+				t.Errorf("Test %d: Expected %s matching %s to be %v", i+1, testCase.action, action, expected)
+			}
+		}
+	}
+}
+
+// Testing s3:ExistingObjectTag conditions.
+func TestBucketPolicyExistingObjectTag(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-policy-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	for object, tags := range map[string]map[string]string{
+		"public":   {"public": "yes"},
+		"private":  {"public": "no"},
+		"untagged": nil,
+	} {
+		if _, err = fs.PutObject("bucket", object, int64(len("abcd")), bytes.NewBufferString("abcd"), nil); err != nil {
+			t.Fatal(err)
+		}
+		if tags != nil {
+			if err = fs.(*Filesystem).PutObjectTags("bucket", object, tags); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Tag conditions are accepted in policies.
+	policyBuf := `{"Version": "2012-10-17", "Statement": [{"Action": ["s3:GetObject"], "Effect": "Allow", "Principal": {"AWS": ["*"]}, "Resource": ["arn:aws:s3:::bucket/*"], "Condition": {"StringEquals": {"s3:ExistingObjectTag/public": "yes"}}}]}`
+	policy, e := parseBucketPolicy([]byte(policyBuf))
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	testCases := []struct {
+		object  string
+		allowed bool
+	}{
+		{"public", true},
+		{"private", false},
+		{"untagged", false},
+	}
+	for i, testCase := range testCases {
+		conditions := make(map[string]string)
+		addObjectTagConditions(conditions, fs, "bucket", testCase.object)
+		allowed := bucketPolicyEvalStatements("s3:GetObject", AWSResourcePrefix+"bucket/"+testCase.object, conditions, policy.Statements)
+		if allowed != testCase.allowed {
+			t.Errorf("Test %d: Expected GetObject of %s allowed to be %v, but instead found %v", i+1, testCase.object, testCase.allowed, allowed)
+		}
+	}
+
+	// Negated conditions match objects without the tag.
+	statement := policyStatement{
+		Conditions: map[string]map[string]string{"StringNotEquals": {"s3:ExistingObjectTag/public": "no"}},
+	}
+	if !bucketPolicyConditionMatch(map[string]string{}, statement) {
+		t.Error("Expected StringNotEquals to match an object without the tag")
+	}
+	if bucketPolicyConditionMatch(map[string]string{"ExistingObjectTag/public": "no"}, statement) {
+		t.Error("Expected StringNotEquals to not match an object with the tag")
+	}
+}
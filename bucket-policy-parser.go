@@ -32,7 +32,8 @@ const (
 	AWSResourcePrefix = "arn:aws:s3:::"
 )
 
-// supportedActionMap - lists all the actions supported by minio.
+// supportedActionMap - lists all the actions supported by minio, used
+// to validate policies and to match requests against them.
 var supportedActionMap = map[string]struct{}{
 	"s3:GetObject":                  {},
 	"s3:ListBucket":                 {},
@@ -79,7 +80,7 @@ func isValidActions(actions []string) (err error) {
 		return err
 	}
 	for _, action := range actions {
-		if _, ok := supportedActionMap[action]; !ok {
+		if !isSupportedActionPattern(action) {
 			err = errors.New("Unsupported action found: ‘" + action + "’, please validate your policy document.")
 			return err
 		}
@@ -87,6 +88,43 @@ func isValidActions(actions []string) (err error) {
 	return nil
 }
 
+// policyActionMatch - returns true if the policy action, which may
+// contain '*' and '?' wildcards, matches a supported action. Action
+// names compare case insensitively like in S3.
+func policyActionMatch(policyAction, action string) bool {
+	if _, ok := supportedActionMap[action]; !ok {
+		return false
+	}
+	return bucketPolicyWildcardMatch(strings.ToLower(policyAction), strings.ToLower(action))
+}
+
+// isSupportedActionPattern - returns true if the policy action matches
+// at least one supported action, so that typos are not silently
+// ignored.
+func isSupportedActionPattern(policyAction string) bool {
+	for action := range supportedActionMap {
+		if policyActionMatch(policyAction, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeActions - replaces literal actions with the names of the
+// supported actions they match, wildcards are left as is.
+func normalizeActions(actions []string) {
+	for i, policyAction := range actions {
+		if strings.ContainsAny(policyAction, "*?") {
+			continue
+		}
+		for action := range supportedActionMap {
+			if strings.EqualFold(policyAction, action) {
+				actions[i] = action
+			}
+		}
+	}
+}
+
 // isValidEffect - is effect valid.
 func isValidEffect(effect string) error {
 	// Statement effect cannot be empty.
@@ -146,14 +184,30 @@ func isValidPrincipals(principals []string) (err error) {
 	return nil
 }
 
+// Prefix of condition keys comparing tags of the requested object.
+const existingObjectTagPrefix = "s3:ExistingObjectTag/"
+
+// hasObjectTagKeys - returns true if condition keys compare tags of
+// the requested object.
+func hasObjectTagKeys(conditionKeys map[string]string) bool {
+	for key := range conditionKeys {
+		if strings.HasPrefix(key, existingObjectTagPrefix) && len(key) > len(existingObjectTagPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func isValidConditions(conditions map[string]map[string]string) (err error) {
 	// Verify conditions should be valid.
 	if len(conditions) > 0 {
-		// Validate if stringEquals, stringNotEquals are present
-		// if not throw an error.
+		// Validate if stringEquals, stringNotEquals, stringLike,
+		// stringNotLike are present if not throw an error.
 		_, stringEqualsOK := conditions["StringEquals"]
 		_, stringNotEqualsOK := conditions["StringNotEquals"]
-		if !stringEqualsOK && !stringNotEqualsOK {
+		_, stringLikeOK := conditions["StringLike"]
+		_, stringNotLikeOK := conditions["StringNotLike"]
+		if !stringEqualsOK && !stringNotEqualsOK && !stringLikeOK && !stringNotLikeOK {
 			err = fmt.Errorf("Unsupported condition type found: ‘%s’, please validate your policy document.", conditions)
 			return err
 		}
@@ -162,7 +216,7 @@ func isValidConditions(conditions map[string]map[string]string) (err error) {
 		if len(conditions["StringEquals"]) > 0 {
 			_, s3PrefixOK := conditions["StringEquals"]["s3:prefix"]
 			_, s3MaxKeysOK := conditions["StringEquals"]["s3:max-keys"]
-			if !s3PrefixOK && !s3MaxKeysOK {
+			if !s3PrefixOK && !s3MaxKeysOK && !hasObjectTagKeys(conditions["StringEquals"]) {
 				err = fmt.Errorf("Unsupported condition keys found: ‘%s’, please validate your policy document.",
 					conditions["StringEquals"])
 				return err
@@ -171,12 +225,23 @@ func isValidConditions(conditions map[string]map[string]string) (err error) {
 		if len(conditions["StringNotEquals"]) > 0 {
 			_, s3PrefixOK := conditions["StringNotEquals"]["s3:prefix"]
 			_, s3MaxKeysOK := conditions["StringNotEquals"]["s3:max-keys"]
-			if !s3PrefixOK && !s3MaxKeysOK {
+			if !s3PrefixOK && !s3MaxKeysOK && !hasObjectTagKeys(conditions["StringNotEquals"]) {
 				err = fmt.Errorf("Unsupported condition keys found: ‘%s’, please validate your policy document.",
 					conditions["StringNotEquals"])
 				return err
 			}
 		}
+		// Validate s3:prefix or object tags are present for
+		// wildcard matches if not throw an error.
+		for _, condition := range []string{"StringLike", "StringNotLike"} {
+			if len(conditions[condition]) > 0 {
+				if _, s3PrefixOK := conditions[condition]["s3:prefix"]; !s3PrefixOK && !hasObjectTagKeys(conditions[condition]) {
+					err = fmt.Errorf("Unsupported condition keys found: ‘%s’, please validate your policy document.",
+						conditions[condition])
+					return err
+				}
+			}
+		}
 	}
 	return nil
 }
@@ -189,8 +254,24 @@ var invalidPrefixActions = map[string]struct{}{
 	// Add actions which do not honor prefixes.
 }
 
+// Limits keeping the evaluation of policies cheap.
+const (
+	maxPolicyStatements         = 100
+	maxPolicyStatementActions   = 50
+	maxPolicyStatementResources = 50
+)
+
 // checkBucketPolicy validates unmarshalled bucket policy structure.
 func checkBucketPolicy(bucket string, bucketPolicy BucketPolicy) APIErrorCode {
+	if len(bucketPolicy.Statements) > maxPolicyStatements {
+		return ErrPolicyTooLarge
+	}
+	for _, statement := range bucketPolicy.Statements {
+		if len(statement.Actions) > maxPolicyStatementActions || len(statement.Resources) > maxPolicyStatementResources {
+			return ErrPolicyTooLarge
+		}
+	}
+
 	// Validate statements for special actions and collect resources
 	// for others to validate nesting.
 	var resourceMap = make(map[string]struct{})
@@ -276,6 +357,7 @@ func parseBucketPolicy(bucketPolicyBuf []byte) (policy BucketPolicy, err error)
 		if err := isValidActions(statement.Actions); err != nil {
 			return BucketPolicy{}, err
 		}
+		normalizeActions(statement.Actions)
 		// Statement resources should be valid.
 		if err := isValidResources(statement.Resources); err != nil {
 			return BucketPolicy{}, err
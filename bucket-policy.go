@@ -17,6 +17,8 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -24,8 +26,19 @@ import (
 	"github.com/minio/minio/pkg/probe"
 )
 
-// getBucketsConfigPath - get buckets path.
+// Directory under the storage metadata directory holding bucket
+// policies, not a valid bucket name since bucket metadata lives there
+// too.
+const metaBucketsConfigDir = "$buckets"
+
+// getBucketsConfigPath - get buckets path, kept under the storage
+// metadata directory when configured.
 func getBucketsConfigPath() (string, *probe.Error) {
+	if serverConfig != nil {
+		if metaDir := serverConfig.GetStorageConfig().MetaDir; metaDir != "" {
+			return filepath.Join(metaDir, metaBucketsConfigDir), nil
+		}
+	}
 	configPath, err := getConfigPath()
 	if err != nil {
 		return "", err.Trace()
@@ -114,6 +127,22 @@ func removeBucketPolicy(bucket string) *probe.Error {
 		}
 		return probe.NewError(e)
 	}
+	if e := os.Remove(bucketPolicyFile); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// removeBucketPolicyIfExists - remove bucket policy, succeeds when the
+// bucket has no policy.
+func removeBucketPolicyIfExists(bucket string) *probe.Error {
+	err := removeBucketPolicy(bucket)
+	if err != nil {
+		if _, ok := err.ToGoError().(BucketPolicyNotFound); ok {
+			return nil
+		}
+		return err.Trace(bucket)
+	}
 	return nil
 }
 
@@ -149,3 +178,68 @@ func writeBucketPolicy(bucket string, accessPolicyBytes []byte) *probe.Error {
 
 	return nil
 }
+
+// Policy language version of canned policies.
+const cannedPolicyVersion = "2012-10-17"
+
+// Actions of canned policies on the bucket and on its objects.
+var (
+	readOnlyBucketActions  = []string{"s3:GetBucketLocation", "s3:ListBucket"}
+	readOnlyObjectActions  = []string{"s3:GetObject"}
+	readWriteBucketActions = []string{"s3:GetBucketLocation", "s3:ListBucket", "s3:ListBucketMultipartUploads"}
+	readWriteObjectActions = []string{"s3:GetObject", "s3:PutObject", "s3:DeleteObject", "s3:AbortMultipartUpload", "s3:ListMultipartUploadParts"}
+)
+
+// newCannedPolicy - returns a policy allowing anonymous bucketActions
+// on the bucket and objectActions on all of its objects.
+func newCannedPolicy(bucket string, bucketActions, objectActions []string) BucketPolicy {
+	policy := BucketPolicy{Version: cannedPolicyVersion}
+	if len(bucketActions) > 0 {
+		policy.Statements = append(policy.Statements, policyStatement{
+			Effect:    "Allow",
+			Principal: policyUser{AWS: []string{"*"}},
+			Actions:   bucketActions,
+			Resources: []string{AWSResourcePrefix + bucket},
+		})
+	}
+	policy.Statements = append(policy.Statements, policyStatement{
+		Effect:    "Allow",
+		Principal: policyUser{AWS: []string{"*"}},
+		Actions:   objectActions,
+		Resources: []string{AWSResourcePrefix + bucket + "/*"},
+	})
+	return policy
+}
+
+// writeCannedPolicy - validate and save a canned bucket policy.
+func writeCannedPolicy(bucket string, policy BucketPolicy) *probe.Error {
+	if !IsValidBucketName(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if s3Error := checkBucketPolicy(bucket, policy); s3Error != ErrNone {
+		return probe.NewError(errors.New(getAPIError(s3Error).Description))
+	}
+	accessPolicyBytes, e := json.Marshal(policy)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	return writeBucketPolicy(bucket, accessPolicyBytes)
+}
+
+// setBucketReadOnlyPolicy - allow anonymous listing of the bucket and
+// downloads of its objects.
+func setBucketReadOnlyPolicy(bucket string) *probe.Error {
+	return writeCannedPolicy(bucket, newCannedPolicy(bucket, readOnlyBucketActions, readOnlyObjectActions))
+}
+
+// setBucketReadWritePolicy - allow anonymous listing of the bucket,
+// uploads, downloads and deletes of its objects.
+func setBucketReadWritePolicy(bucket string) *probe.Error {
+	return writeCannedPolicy(bucket, newCannedPolicy(bucket, readWriteBucketActions, readWriteObjectActions))
+}
+
+// setBucketPublicPolicy - allow anonymous downloads of objects whose
+// names are known, without listing the bucket.
+func setBucketPublicPolicy(bucket string) *probe.Error {
+	return writeCannedPolicy(bucket, newCannedPolicy(bucket, nil, readOnlyObjectActions))
+}
@@ -35,6 +35,12 @@ type serverConfigV4 struct {
 	// Additional error logging configuration.
 	Logger logger `json:"logger"`
 
+	// Filesystem backend configuration.
+	Storage storageConfig `json:"storage"`
+
+	// Event notification targets.
+	Notify notifyConfig `json:"notify"`
+
 	// Read Write mutex.
 	rwMutex *sync.RWMutex
 }
@@ -103,6 +109,133 @@ func (s serverConfigV4) GetVersion() string {
 	return s.Version
 }
 
+/// Storage related.
+
+// storageConfig filesystem backend configuration.
+type storageConfig struct {
+	// Checksum algorithm used for internal integrity verification of
+	// written data, either "md5" or "sha256". Defaults to "md5", ETag
+	// sent to clients is always an md5sum.
+	Checksum string `json:"checksum"`
+
+	// Fsync flushes object data to disk before it is renamed into
+	// place and the parent directory after, so that acknowledged
+	// writes survive a power failure. Every write then waits on the
+	// disk which considerably lowers write throughput, disabled by
+	// default.
+	Fsync bool `json:"fsync"`
+
+	// VerifyWrites confirms every written file is visible after it
+	// is renamed into place, failing the write otherwise. Directory
+	// entries may lag on some networked filesystems, disabled by
+	// default.
+	VerifyWrites bool `json:"verifyWrites"`
+
+	// MetaDir is the directory holding multipart uploads, object
+	// metadata and bucket policies, for example on a separate faster
+	// disk. Defaults to ".minio" under the data path, bucket policies
+	// then stay in the server config directory.
+	MetaDir string `json:"metaDir"`
+
+	// PlainSinglePartETag gives objects completed from a single part
+	// a plain md5sum ETag instead of the multipart "<md5sum>-1" form,
+	// for older clients which fail on the suffix. Disabled by default.
+	PlainSinglePartETag bool `json:"plainSinglePartETag"`
+
+	// MaxObjectSize is the largest object in bytes that can be
+	// written, by a single PUT or by completing a multipart upload.
+	// 0 is unlimited.
+	MaxObjectSize int64 `json:"maxObjectSize"`
+
+	// RetryAttempts is the number of attempts of atomic renames
+	// failing with transient errors such as EAGAIN or EBUSY, which
+	// networked filesystems may return. Defaults to 3.
+	RetryAttempts int `json:"retryAttempts"`
+
+	// ListCacheSize is the number of ListObjects results kept in a
+	// least recently used cache, invalidated per bucket on writes.
+	// 0 disables the cache.
+	ListCacheSize int `json:"listCacheSize"`
+
+	// ListWalkers is the number of top level directories recursive
+	// listings walk in parallel. 0 or 1 walks serially.
+	ListWalkers int `json:"listWalkers"`
+
+	// MaxPartWrites is the number of multipart parts written
+	// concurrently, further uploads of parts wait. 0 uses the
+	// default of 64.
+	MaxPartWrites int `json:"maxPartWrites"`
+
+	// MultipartCleanupInterval is how often multipart uploads
+	// initiated longer than MultipartExpiry ago are aborted, both
+	// durations such as "1h". Empty disables the cleanup, the expiry
+	// defaults to "168h".
+	MultipartCleanupInterval string `json:"multipartCleanupInterval"`
+	MultipartExpiry          string `json:"multipartExpiry"`
+
+	// DirMode and FileMode are the octal permissions, for example
+	// "0750", of directories and files created for buckets, objects
+	// and multipart uploads. Default to the built in modes of each,
+	// directories are subject to the process umask.
+	DirMode  string `json:"dirMode"`
+	FileMode string `json:"fileMode"`
+
+	// TempDir is the directory temporary files of object and part
+	// writes are created in before being renamed into place, for
+	// example on faster storage. It must be on the same filesystem as
+	// the data path, otherwise it is not used with a warning. Empty
+	// creates temporary files next to their target.
+	TempDir string `json:"tempDir"`
+
+	// Mirror is a directory holding copies of the buckets laid out
+	// like the data path, objects found corrupted on verified reads
+	// are repaired from their copy. Empty disables repairs.
+	Mirror string `json:"mirror"`
+
+	// Dedup stores identical objects once, as hard links to a blob
+	// under "blobs" in the metadata path, which must then be on the
+	// same filesystem as the data. Linked objects share their
+	// modification time, which every write of the content updates.
+	// Disabled by default.
+	Dedup bool `json:"dedup"`
+
+	// RateLimit is the combined rate in bytes per second of object
+	// data read by uploads and downloads, RequestRateLimit the rate of
+	// each single upload or download. 0 is unlimited.
+	RateLimit        int64 `json:"rateLimit"`
+	RequestRateLimit int64 `json:"requestRateLimit"`
+}
+
+// SetStorageConfig set new storage configuration.
+func (s *serverConfigV4) SetStorageConfig(storage storageConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Storage = storage
+}
+
+// GetStorageConfig get current storage configuration.
+func (s serverConfigV4) GetStorageConfig() storageConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Storage
+}
+
+/// Notify related.
+
+// SetNotifyConfig set new event notification targets.
+func (s *serverConfigV4) SetNotifyConfig(notify notifyConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.Notify = notify
+}
+
+// GetNotifyConfig get current event notification targets.
+func (s serverConfigV4) GetNotifyConfig() notifyConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.Notify
+}
+
 /// Logger related.
 
 // SetFileLogger set new file logger.
@@ -161,11 +294,15 @@ func (s serverConfigV4) GetRegion() string {
 	return s.Region
 }
 
-// SetCredentials set new credentials.
-func (s *serverConfigV4) SetCredential(creds credential) {
+// SetCredentials set new credentials, malformed keys are rejected.
+func (s *serverConfigV4) SetCredential(creds credential) *probe.Error {
+	if e := creds.Validate(); e != nil {
+		return probe.NewError(e)
+	}
 	s.rwMutex.Lock()
 	defer s.rwMutex.Unlock()
 	s.Credential = creds
+	return nil
 }
 
 // GetCredentials get current credentials.
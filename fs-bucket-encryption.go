@@ -0,0 +1,80 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// Name of the bucket encryption file kept under the metadata path.
+const bucketEncryptionFile = "encryption.json"
+
+// Supported default server side encryption algorithms.
+var supportedEncryptionAlgorithms = []string{"AES256", "aws:kms"}
+
+// isValidEncryptionAlgorithm - verify if the encryption algorithm is supported.
+func isValidEncryptionAlgorithm(algorithm string) bool {
+	for _, supported := range supportedEncryptionAlgorithms {
+		if algorithm == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// fsBucketEncryption - default server side encryption of a bucket.
+type fsBucketEncryption struct {
+	Algorithm string `json:"algorithm"`
+}
+
+// PutBucketEncryption - record the default server side encryption of
+// a bucket. Only the setting is stored, objects are not encrypted.
+func (fs Filesystem) PutBucketEncryption(bucket string, algorithm string) *probe.Error {
+	bucketDirName, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return probe.NewError(e).Trace(bucket)
+	}
+	bucket = bucketDirName
+	if !isValidEncryptionAlgorithm(algorithm) {
+		return probe.NewError(InvalidEncryptionAlgorithm{Algorithm: algorithm}).Trace(bucket)
+	}
+	encryption := fsBucketEncryption{Algorithm: algorithm}
+	if e = fs.writeMetaFile(fs.bucketMetaPath(bucket, bucketEncryptionFile), encryption); e != nil {
+		return probe.NewError(e).Trace(bucket)
+	}
+	return nil
+}
+
+// GetBucketEncryption - get the default server side encryption
+// algorithm of a bucket.
+func (fs Filesystem) GetBucketEncryption(bucket string) (string, *probe.Error) {
+	bucketDirName, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket)
+	}
+	bucket = bucketDirName
+	encryption := fsBucketEncryption{}
+	if e = readMetaFile(fs.bucketMetaPath(bucket, bucketEncryptionFile), &encryption); e != nil {
+		if os.IsNotExist(e) {
+			return "", probe.NewError(BucketEncryptionNotFound{Bucket: bucket}).Trace(bucket)
+		}
+		return "", probe.NewError(e).Trace(bucket)
+	}
+	return encryption.Algorithm, nil
+}
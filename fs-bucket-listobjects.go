@@ -17,10 +17,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/minio/minio/pkg/probe"
 )
@@ -77,40 +79,116 @@ func (fs *Filesystem) lookupTreeWalk(params listObjectParams) *treeWalker {
 // ListObjects - lists all objects for a given prefix, returns up to
 // maxKeys number of objects per call.
 func (fs Filesystem) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, *probe.Error) {
+	return fs.ListObjectsContext(context.Background(), bucket, prefix, marker, delimiter, maxKeys)
+}
+
+// ListObjectsContext - lists all objects for a given prefix, returns
+// up to maxKeys number of objects per call. Aborts the listing once
+// ctx is done.
+func (fs Filesystem) ListObjectsContext(ctx context.Context, bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, *probe.Error) {
+	// Serve repeated listings from the cache if enabled.
+	key := listCacheKey{bucket, prefix, marker, delimiter, maxKeys}
+	result, generation, ok := fs.listCache.get(key)
+	if ok {
+		fs.metrics.record(&fs.metrics.listObjects, nil)
+		return result, nil
+	}
+	result, err := fs.listObjects(ctx, bucket, prefix, marker, delimiter, maxKeys, time.Time{})
+	fs.metrics.record(&fs.metrics.listObjects, err)
+	if err == nil {
+		fs.listCache.put(key, generation, result)
+	}
+	return result, err
+}
+
+// ListObjectsFiltered - lists objects for a given prefix like
+// ListObjects, skipping objects modified before modifiedSince to the
+// second. Skipped objects do not count towards maxKeys, the next
+// marker is the last object returned. Common prefixes are listed
+// regardless of the objects below them.
+func (fs Filesystem) ListObjectsFiltered(bucket, prefix, marker, delimiter string, maxKeys int, modifiedSince time.Time) (ListObjectsInfo, *probe.Error) {
+	result, err := fs.listObjects(context.Background(), bucket, prefix, marker, delimiter, maxKeys, modifiedSince.Truncate(time.Second))
+	fs.metrics.record(&fs.metrics.listObjects, err)
+	return result, err
+}
+
+// ListObjectVersions - lists the versions of objects for a given
+// prefix, objects are not versioned and listed as their only, current
+// version with the version id "null".
+func (fs Filesystem) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (ListObjectVersionsInfo, *probe.Error) {
+	// The only version of an object is "null", continuing after it
+	// continues after the object.
+	if versionIDMarker != "" {
+		if keyMarker == "" {
+			return ListObjectVersionsInfo{}, probe.NewError(fmt.Errorf("Version id marker '%s' requires a key marker", versionIDMarker)).Trace(bucket, prefix)
+		}
+		if versionIDMarker != nullVersionID {
+			return ListObjectVersionsInfo{}, probe.NewError(fmt.Errorf("Invalid version id marker '%s'", versionIDMarker)).Trace(bucket, prefix)
+		}
+	}
+	result, err := fs.ListObjects(bucket, prefix, keyMarker, delimiter, maxKeys)
+	if err != nil {
+		return ListObjectVersionsInfo{}, err.Trace(bucket, prefix)
+	}
+	versions := ListObjectVersionsInfo{
+		IsTruncated:   result.IsTruncated,
+		NextKeyMarker: result.NextMarker,
+		Prefixes:      result.Prefixes,
+	}
+	for _, objInfo := range result.Objects {
+		versions.Versions = append(versions.Versions, ObjectVersionInfo{
+			ObjectInfo: objInfo,
+			VersionID:  nullVersionID,
+			IsLatest:   true,
+		})
+	}
+	// Truncated after an object, continue after its only version.
+	if n := len(versions.Versions); result.IsTruncated && n > 0 && versions.Versions[n-1].Name == result.NextMarker {
+		versions.NextVersionIDMarker = nullVersionID
+	}
+	return versions, nil
+}
+
+// listObjects - see ListObjectsContext and ListObjectsFiltered, a zero
+// modifiedSince lists all objects.
+func (fs Filesystem) listObjects(ctx context.Context, bucket, prefix, marker, delimiter string, maxKeys int, modifiedSince time.Time) (ListObjectsInfo, *probe.Error) {
 	result := ListObjectsInfo{}
 
 	// Input validation.
 	if !IsValidBucketName(bucket) {
-		return result, probe.NewError(BucketNameInvalid{Bucket: bucket})
+		return result, probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket, prefix)
 	}
 
+	if e := fs.checkRootPath(); e != nil {
+		return result, probe.NewError(e).Trace(bucket, prefix)
+	}
 	bucket = getActualBucketname(fs.path, bucket) // Get the right bucket name.
 	bucketDir := filepath.Join(fs.path, bucket)
 	// Verify if bucket exists.
 	if status, e := isDirExist(bucketDir); !status {
 		if e == nil {
 			// File exists, but its not a directory.
-			return result, probe.NewError(BucketNotFound{Bucket: bucket})
+			return result, probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket, prefix)
 		} else if os.IsNotExist(e) {
 			// File does not exist.
-			return result, probe.NewError(BucketNotFound{Bucket: bucket})
+			return result, probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket, prefix)
 		} else {
-			return result, probe.NewError(e)
+			return result, probe.NewError(e).Trace(bucket, prefix)
 		}
 	}
 	if !IsValidObjectPrefix(prefix) {
-		return result, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: prefix})
+		return result, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: prefix}).Trace(bucket, prefix)
 	}
 
 	// Verify if delimiter is anything other than '/', which we do not support.
 	if delimiter != "" && delimiter != "/" {
-		return result, probe.NewError(fmt.Errorf("delimiter '%s' is not supported. Only '/' is supported", delimiter))
+		return result, probe.NewError(fmt.Errorf("delimiter '%s' is not supported. Only '/' is supported", delimiter)).Trace(bucket, prefix)
 	}
 
 	// Verify if marker has prefix.
 	if marker != "" {
 		if !strings.HasPrefix(marker, prefix) {
-			return result, probe.NewError(fmt.Errorf("Invalid combination of marker '%s' and prefix '%s'", marker, prefix))
+			return result, probe.NewError(fmt.Errorf("Invalid combination of marker '%s' and prefix '%s'", marker, prefix)).Trace(bucket, prefix)
 		}
 	}
 
@@ -134,7 +212,7 @@ func (fs Filesystem) ListObjects(bucket, prefix, marker, delimiter string, maxKe
 			return result, nil
 		}
 		// Rest errors should be treated as failure.
-		return result, probe.NewError(e)
+		return result, probe.NewError(e).Trace(bucket, prefix)
 	}
 
 	recursive := true
@@ -149,11 +227,20 @@ func (fs Filesystem) ListObjects(bucket, prefix, marker, delimiter string, maxKe
 	// popTreeWalker returns the channel from which rest of the objects can be retrieved.
 	walker := fs.lookupTreeWalk(listObjectParams{bucket, delimiter, marker, prefix})
 	if walker == nil {
-		walker = startTreeWalk(fs.path, bucket, filepath.FromSlash(prefix), filepath.FromSlash(marker), recursive)
+		if recursive && fs.listWalkers > 1 {
+			walker = startParallelTreeWalk(fs.path, bucket, filepath.FromSlash(prefix), filepath.FromSlash(marker), fs.listWalkers, fs.closed)
+		} else {
+			walker = startTreeWalk(fs.path, bucket, filepath.FromSlash(prefix), filepath.FromSlash(marker), recursive, fs.closed)
+		}
 	}
 
+	owner := getOwner()
 	nextMarker := ""
 	for i := 0; i < maxKeys; {
+		// Request cancelled, the walker is not saved and times out.
+		if e := ctx.Err(); e != nil {
+			return ListObjectsInfo{}, probe.NewError(e).Trace(bucket, prefix)
+		}
 		walkResult, ok := <-walker.ch
 		if !ok {
 			// Closed channel.
@@ -161,13 +248,15 @@ func (fs Filesystem) ListObjects(bucket, prefix, marker, delimiter string, maxKe
 		}
 		// For any walk error return right away.
 		if walkResult.err != nil {
-			return ListObjectsInfo{}, probe.NewError(walkResult.err)
+			return ListObjectsInfo{}, probe.NewError(walkResult.err).Trace(bucket, prefix)
 		}
 		objInfo := walkResult.objectInfo
 		objInfo.Name = filepath.ToSlash(objInfo.Name)
+		objInfo.Owner = owner
 
-		// Skip temporary files.
-		if strings.Contains(objInfo.Name, "$multiparts") || strings.Contains(objInfo.Name, "$tmpobject") {
+		// Skip temporary and sidecar files, and objects modified
+		// before the filtered time.
+		if isInternalName(objInfo.Name) || (!objInfo.IsDir && objInfo.ModifiedTime.Before(modifiedSince)) {
 			continue
 		}
 
@@ -175,6 +264,9 @@ func (fs Filesystem) ListObjects(bucket, prefix, marker, delimiter string, maxKe
 		if objInfo.IsDir {
 			result.Prefixes = append(result.Prefixes, objInfo.Name)
 		} else {
+			if e := fs.readListedMetadata(bucket, &objInfo); e != nil {
+				return ListObjectsInfo{}, probe.NewError(e).Trace(bucket, prefix)
+			}
 			result.Objects = append(result.Objects, objInfo)
 		}
 
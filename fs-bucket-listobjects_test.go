@@ -18,29 +18,29 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/probe"
 )
 
 func TestListObjects(t *testing.T) {
-	// Make a temporary directory to use as the fs.
-	directory, e := ioutil.TempDir("", "minio-list-object-test")
-	if e != nil {
-		t.Fatal(e)
-	}
-	defer os.RemoveAll(directory)
+	execObjectLayerTest(t, testListObjects)
+}
 
-	// Create the fs.
-	fs, err := newFS(directory)
-	if err != nil {
-		t.Fatal(err)
-	}
+// testListObjects - runs the ListObjects() test table against an object layer.
+func testListObjects(t *testing.T, fs ObjectLayer) {
 	// This bucket is used for testing ListObject operations.
-	err = fs.MakeBucket("test-bucket-list-object")
+	err := fs.MakeBucket("test-bucket-list-object")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -371,11 +371,14 @@ func TestListObjects(t *testing.T) {
 
 		// ListObjectsResult-25.
 		// Prefix is set to "Asia" in the testCase, and delimiter is set (testCase 57).
+		// The prefix does not end at a delimiter, "Asia-maps" is listed as an
+		// object while the keys under "Asia/" collapse into a common prefix.
 		{
 			IsTruncated: false,
 			Objects: []ObjectInfo{
 				{Name: "Asia-maps"},
 			},
+			Prefixes: []string{"Asia/"},
 		},
 		// ListObjectsResult-26.
 		// prefix = "new" and delimiter is set in the testCase.(testCase 58).
@@ -385,6 +388,7 @@ func TestListObjects(t *testing.T) {
 				{Name: "newPrefix0"},
 				{Name: "newPrefix1"},
 			},
+			Prefixes: []string{"newzen/"},
 		},
 		// ListObjectsResult-27.
 		// Prefix is set to "Asia/India/" in the testCase, and delimiter is set to forward slash '/' (testCase 59).
@@ -393,6 +397,7 @@ func TestListObjects(t *testing.T) {
 			Objects: []ObjectInfo{
 				{Name: "Asia/India/India-summer-photos-1"},
 			},
+			Prefixes: []string{"Asia/India/Karnataka/"},
 		},
 		// ListObjectsResult-28.
 		// Marker is set to "Asia/India/India-summer-photos-1" and delimiter set in the testCase, (testCase 60).
@@ -405,9 +410,11 @@ func TestListObjects(t *testing.T) {
 				{Name: "obj1"},
 				{Name: "obj2"},
 			},
+			Prefixes: []string{"Asia/", "newzen/"},
 		},
 		// ListObjectsResult-29.
 		// Marker is set to "Asia/India/Karnataka/Bangalore/Koramangala/pics" in the testCase and delimeter set, (testCase 61).
+		// Nothing remains under "Asia/" after the marker.
 		{
 			IsTruncated: false,
 			Objects: []ObjectInfo{
@@ -417,6 +424,7 @@ func TestListObjects(t *testing.T) {
 				{Name: "obj1"},
 				{Name: "obj2"},
 			},
+			Prefixes: []string{"newzen/"},
 		},
 	}
 
@@ -555,16 +563,510 @@ func TestListObjects(t *testing.T) {
 					t.Errorf("Test %d: Expected object name to be \"%s\", but found \"%s\" instead", i+1, testCase.result.Objects[j].Name, result.Objects[j].Name)
 				}
 			}
+			// Common prefixes of delimited listings are returned apart from the objects.
+			if len(testCase.result.Prefixes) != len(result.Prefixes) {
+				t.Fatalf("Test %d: Expected common prefixes %v, but found %v instead", i+1, testCase.result.Prefixes, result.Prefixes)
+			}
+			for j := 0; j < len(testCase.result.Prefixes); j++ {
+				if testCase.result.Prefixes[j] != result.Prefixes[j] {
+					t.Errorf("Test %d: Expected common prefix to be \"%s\", but found \"%s\" instead", i+1, testCase.result.Prefixes[j], result.Prefixes[j])
+				}
+			}
 			if testCase.result.IsTruncated != result.IsTruncated {
 				t.Errorf("Test %d: Expected IsTruncated flag to be %v, but instead found it to be %v", i+1, testCase.result.IsTruncated, result.IsTruncated)
 			}
 
 		}
 	}
+}
+
+// Testing ListObjects() pagination with delimiter.
+func TestListObjectsDelimiterPagination(t *testing.T) {
+	// Make a temporary directory to use as the fs.
+	directory, e := ioutil.TempDir("", "minio-list-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-bucket-list-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := []string{
+		"Asia-maps",
+		"Asia/India/India-summer-photos-1",
+		"Asia/India/Karnataka/Bangalore/pics",
+		"Asia/China/pics",
+		"Asia/Japan",
+		"Europe/France/pics",
+		"obj0",
+		"zoo/pics",
+	}
+	for _, object := range objects {
+		_, err = fs.PutObject("test-bucket-list-object", object, int64(len(object)), bytes.NewBufferString(object), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		prefix  string
+		entries []string
+	}{
+		{"", []string{"Asia-maps", "Asia/", "Europe/", "obj0", "zoo/"}},
+		{"Asia/", []string{"Asia/China/", "Asia/India/", "Asia/Japan"}},
+		{"Asia/India/", []string{"Asia/India/India-summer-photos-1", "Asia/India/Karnataka/"}},
+	}
+	for i, testCase := range testCases {
+		// Resume from markers with a cached tree walk and with a new one.
+		for _, freshWalk := range []bool{false, true} {
+			var entries []string
+			marker := ""
+			for page := 0; page <= len(testCase.entries); page++ {
+				if freshWalk {
+					fs, err = newFS(directory)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				result, err := fs.ListObjects("test-bucket-list-object", testCase.prefix, marker, "/", 1)
+				if err != nil {
+					t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+				}
+				for _, prefix := range result.Prefixes {
+					entries = append(entries, prefix)
+				}
+				for _, object := range result.Objects {
+					entries = append(entries, object.Name)
+				}
+				if !result.IsTruncated {
+					break
+				}
+				marker = result.NextMarker
+			}
+			if strings.Join(entries, ",") != strings.Join(testCase.entries, ",") {
+				t.Errorf("Test %d: Expected entries %v, but instead found %v", i+1, testCase.entries, entries)
+			}
+		}
+	}
+}
+
+// Testing NextMarker of truncated ListObjects() results.
+func TestListObjectsNextMarker(t *testing.T) {
+	execObjectLayerTest(t, testListObjectsNextMarker)
+}
+
+func testListObjectsNextMarker(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("test-bucket-list-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"Asia-maps", "Asia/India/pics", "Asia/China/pics", "obj0"} {
+		_, err = fs.PutObject("test-bucket-list-object", object, int64(len(object)), bytes.NewBufferString(object), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		delimiter   string
+		maxKeys     int
+		isTruncated bool
+		nextMarker  string
+	}{
+		// Truncated flat listing ends with an object.
+		{"", 2, true, "Asia/China/pics"},
+		// Truncated delimiter listing ends with a common prefix.
+		{"/", 2, true, "Asia/"},
+		// Truncated delimiter listing ends with an object.
+		{"/", 1, true, "Asia-maps"},
+		// Complete listings have no next marker.
+		{"", 10, false, ""},
+		{"/", 10, false, ""},
+	}
+	for i, testCase := range testCases {
+		result, err := fs.ListObjects("test-bucket-list-object", "", "", testCase.delimiter, testCase.maxKeys)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		if result.IsTruncated != testCase.isTruncated {
+			t.Errorf("Test %d: Expected IsTruncated to be %v, but instead found %v", i+1, testCase.isTruncated, result.IsTruncated)
+		}
+		if result.NextMarker != testCase.nextMarker {
+			t.Errorf("Test %d: Expected NextMarker \"%s\", but instead found \"%s\"", i+1, testCase.nextMarker, result.NextMarker)
+		}
+	}
+}
+
+// Testing ListObjects() results cached and invalidated by writes.
+func TestListObjectsCache(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-list-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{ListCacheSize: 2},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("bucket", "a", int64(len("a")), bytes.NewBufferString("a"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listNames := func() string {
+		result, err := fs.ListObjects("bucket", "", "", "", 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, object := range result.Objects {
+			names = append(names, object.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	if names := listNames(); names != "a" {
+		t.Fatalf("Expected objects \"a\", but instead found \"%s\"", names)
+	}
+
+	// Objects written behind the back of the fs are not seen while
+	// the listing is cached.
+	if e = ioutil.WriteFile(filepath.Join(directory, "bucket", "b"), []byte("b"), 0644); e != nil {
+		t.Fatal(e)
+	}
+	if names := listNames(); names != "a" {
+		t.Fatalf("Expected cached objects \"a\", but instead found \"%s\"", names)
+	}
+
+	// A PUT invalidates the cached listings of its bucket.
+	_, err = fs.PutObject("bucket", "c", int64(len("c")), bytes.NewBufferString("c"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := listNames(); names != "a,b,c" {
+		t.Fatalf("Expected objects \"a,b,c\", but instead found \"%s\"", names)
+	}
+
+	// A DELETE invalidates the cached listings of its bucket.
+	if err = fs.DeleteObject("bucket", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if names := listNames(); names != "b,c" {
+		t.Fatalf("Expected objects \"b,c\", but instead found \"%s\"", names)
+	}
+
+	// The cache holds at most the configured number of listings.
+	for _, prefix := range []string{"a", "b", "c"} {
+		if _, err = fs.ListObjects("bucket", prefix, "", "", 1000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := fs.(*Filesystem).listCache.entries.Len(); n != 2 {
+		t.Fatalf("Expected 2 cached listings, but instead found %d", n)
+	}
+}
+
+// Testing ListObjectVersions() lists objects as their current version.
+func TestListObjectVersions(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-list-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	err = fs.MakeBucket("test-bucket-list-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"Asia-maps", "Asia/India/pics", "Asia/China/pics", "obj0", "obj1"} {
+		_, err = fs.PutObject("test-bucket-list-object", object, int64(len(object)), bytes.NewBufferString(object), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		prefix              string
+		keyMarker           string
+		versionIDMarker     string
+		delimiter           string
+		maxKeys             int
+		nextVersionIDMarker string
+	}{
+		{"", "", "", "", 1000, ""},
+		{"", "", "", "/", 1000, ""},
+		{"Asia/", "", "", "", 1000, ""},
+		// Truncated after an object.
+		{"", "", "", "", 2, nullVersionID},
+		// Truncated after a common prefix.
+		{"", "", "", "/", 2, ""},
+		{"", "Asia/China/pics", nullVersionID, "", 2, nullVersionID},
+		{"", "obj0", nullVersionID, "/", 1000, ""},
+	}
+	for i, testCase := range testCases {
+		expected, err := fs.ListObjects("test-bucket-list-object", testCase.prefix, testCase.keyMarker, testCase.delimiter, testCase.maxKeys)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		result, err := filesystem.ListObjectVersions("test-bucket-list-object", testCase.prefix, testCase.keyMarker, testCase.versionIDMarker, testCase.delimiter, testCase.maxKeys)
+		if err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if len(result.Versions) != len(expected.Objects) {
+			t.Fatalf("Test %d: Expected %d versions, but instead found %d", i+1, len(expected.Objects), len(result.Versions))
+		}
+		for j, version := range result.Versions {
+			if version.Name != expected.Objects[j].Name {
+				t.Errorf("Test %d: Expected version of \"%s\", but instead found \"%s\"", i+1, expected.Objects[j].Name, version.Name)
+			}
+			if version.VersionID != nullVersionID || !version.IsLatest {
+				t.Errorf("Test %d: Expected the latest version \"null\" of \"%s\", but instead found version \"%s\" latest %v", i+1, version.Name, version.VersionID, version.IsLatest)
+			}
+		}
+		if strings.Join(result.Prefixes, ",") != strings.Join(expected.Prefixes, ",") {
+			t.Errorf("Test %d: Expected common prefixes %v, but instead found %v", i+1, expected.Prefixes, result.Prefixes)
+		}
+		if result.IsTruncated != expected.IsTruncated || result.NextKeyMarker != expected.NextMarker {
+			t.Errorf("Test %d: Expected truncated %v with next key marker \"%s\", but instead found %v with \"%s\"", i+1, expected.IsTruncated, expected.NextMarker, result.IsTruncated, result.NextKeyMarker)
+		}
+		if result.NextVersionIDMarker != testCase.nextVersionIDMarker {
+			t.Errorf("Test %d: Expected next version id marker \"%s\", but instead found \"%s\"", i+1, testCase.nextVersionIDMarker, result.NextVersionIDMarker)
+		}
+	}
 
+	// Invalid version id markers.
+	for _, marker := range []struct{ keyMarker, versionIDMarker string }{{"", nullVersionID}, {"obj0", "3HL4kqtJlcpXroDTDmJ+rmSpXd3dIbrHY"}} {
+		if _, err = filesystem.ListObjectVersions("test-bucket-list-object", "", marker.keyMarker, marker.versionIDMarker, "", 1000); err == nil {
+			t.Errorf("Expected key marker \"%s\" with version id marker \"%s\" to fail, but passed instead", marker.keyMarker, marker.versionIDMarker)
+		}
+	}
+}
+
+// Testing ListObjects() with delimiter reads only the directory of the
+// prefix and gives the collapsed results of the recursive listing.
+func TestListObjectsDelimiterShallow(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-list-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-bucket-list-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := []string{
+		"Asia-maps",
+		"Asia/India/India-summer-photos-1",
+		"Asia/India/Karnataka/Bangalore/Koramangala/pics",
+		"newPrefix0",
+		"newPrefix1",
+		"newzen/zen/recurse/again/again/again/pics",
+		"obj0",
+		"obj1",
+		"obj2",
+	}
+	for _, object := range objects {
+		_, err = fs.PutObject("test-bucket-list-object", object, int64(len(object)), bytes.NewBufferString(object), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Prefixes and markers of the delimiter cases of TestListObjects (57-61).
+	testCases := []struct {
+		prefix string
+		marker string
+	}{
+		{"Asia", ""},
+		{"new", ""},
+		{"Asia/India/", ""},
+		{"", "Asia/India/India-summer-photos-1"},
+		{"", "Asia/India/Karnataka/Bangalore/Koramangala/pics"},
+	}
+	// listEntries - lists objects and common prefixes after collapsing
+	// recursively listed names at the delimiter.
+	listEntries := func(prefix, marker, delimiter string) (string, *probe.Error) {
+		result, err := fs.ListObjects("test-bucket-list-object", prefix, marker, delimiter, 1000)
+		if err != nil {
+			return "", err
+		}
+		var entries []string
+		for _, object := range result.Objects {
+			name := object.Name
+			if i := strings.Index(name[len(prefix):], "/"); i >= 0 {
+				name = name[:len(prefix)+i+1]
+			}
+			if len(entries) == 0 || entries[len(entries)-1] != name {
+				entries = append(entries, name)
+			}
+		}
+		// Merge the common prefixes into the sorted entries.
+		entries = append(entries, result.Prefixes...)
+		sort.Strings(entries)
+		return strings.Join(entries, ","), nil
+	}
+	for i, testCase := range testCases {
+		recursive, err := listEntries(testCase.prefix, testCase.marker, "")
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		shallow, err := listEntries(testCase.prefix, testCase.marker, "/")
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		if shallow != recursive {
+			t.Errorf("Test %d: Expected entries \"%s\", but instead found \"%s\"", i+1, recursive, shallow)
+		}
+	}
+
+	// Directories below the prefix are not read, an unreadable one
+	// fails the recursive listing only.
+	karnataka := filepath.Join(directory, "test-bucket-list-object", "Asia", "India", "Karnataka")
+	if e = os.Chmod(karnataka, 0); e != nil {
+		t.Fatal(e)
+	}
+	defer os.Chmod(karnataka, 0755)
+	if _, err = fs.ListObjects("test-bucket-list-object", "", "", "", 1000); err == nil {
+		t.Skip("Unreadable directories are readable, running as root")
+	}
+	entries, err := listEntries("Asia/India/", "", "/")
+	if err != nil {
+		t.Fatalf("Expected the delimiter listing to pass, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if entries != "Asia/India/India-summer-photos-1,Asia/India/Karnataka/" {
+		t.Fatalf("Expected entries \"Asia/India/India-summer-photos-1,Asia/India/Karnataka/\", but instead found \"%s\"", entries)
+	}
+}
+
+// Testing recursive ListObjects() walking top level directories in
+// parallel gives the results of the serial walk.
+func TestListObjectsParallelWalk(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-list-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		rwMutex:    &sync.RWMutex{},
+	}
+	serialFS, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig.Storage.ListWalkers = 4
+	parallelFS, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = serialFS.MakeBucket("test-bucket-list-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := []string{
+		"Asia-maps",
+		"Asia/",
+		"Asia/India/India-summer-photos-1",
+		"Asia/India/Karnataka/Bangalore/Koramangala/pics",
+		"newPrefix0",
+		"newPrefix1",
+		"newzen/zen/recurse/again/again/again/pics",
+		"obj0",
+		"obj1",
+		"obj2",
+	}
+	for _, object := range objects {
+		_, err = serialFS.PutObject("test-bucket-list-object", object, int64(len(object)), bytes.NewBufferString(object), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// listPages - lists all pages from marker.
+	listPages := func(fs ObjectLayer, prefix, marker string, maxKeys int) []string {
+		var pages []string
+		for {
+			result, err := fs.ListObjects("test-bucket-list-object", prefix, marker, "", maxKeys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, object := range result.Objects {
+				names = append(names, object.Name)
+			}
+			pages = append(pages, fmt.Sprintf("%v %v %s", names, result.IsTruncated, result.NextMarker))
+			if !result.IsTruncated {
+				return pages
+			}
+			marker = result.NextMarker
+		}
+	}
+
+	for _, prefix := range []string{"", "Asia", "Asia/", "Asia/India/", "new", "newzen/", "obj"} {
+		markers := []string{""}
+		for _, object := range objects {
+			if strings.HasPrefix(object, prefix) {
+				markers = append(markers, object)
+			}
+		}
+		for _, marker := range markers {
+			for _, maxKeys := range []int{1, 2, 3, 1000} {
+				serialPages := listPages(serialFS, prefix, marker, maxKeys)
+				parallelPages := listPages(parallelFS, prefix, marker, maxKeys)
+				if strings.Join(serialPages, "\n") != strings.Join(parallelPages, "\n") {
+					t.Errorf("Prefix \"%s\", marker \"%s\", maxKeys %d: Expected pages %v, but instead found %v", prefix, marker, maxKeys, serialPages, parallelPages)
+				}
+			}
+		}
+	}
 }
 
 func BenchmarkListObjects(b *testing.B) {
+	benchmarkListObjects(b, 0)
+}
+
+// Repeated identical listings served from the list cache.
+func BenchmarkListObjectsCached(b *testing.B) {
+	benchmarkListObjects(b, 100)
+}
+
+// benchmarkListObjects - lists a bucket over and over with a list cache
+// of cacheSize listings, 0 disables the cache.
+func benchmarkListObjects(b *testing.B, cacheSize int) {
 	// Make a temporary directory to use as the fs.
 	directory, e := ioutil.TempDir("", "minio-list-benchmark")
 	if e != nil {
@@ -572,6 +1074,13 @@ func BenchmarkListObjects(b *testing.B) {
 	}
 	defer os.RemoveAll(directory)
 
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{ListCacheSize: cacheSize},
+		rwMutex:    &sync.RWMutex{},
+	}
+
 	// Create the fs.
 	fs, err := newFS(directory)
 	if err != nil {
@@ -602,3 +1111,292 @@ func BenchmarkListObjects(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkListObjectsWideTree(b *testing.B) {
+	benchmarkListObjectsWideTree(b, 0)
+}
+
+func BenchmarkListObjectsWideTreeParallel(b *testing.B) {
+	benchmarkListObjectsWideTree(b, 8)
+}
+
+// benchmarkListObjectsWideTree - recursively lists a bucket of many top
+// level directories with walkers top level directories walked in
+// parallel, 0 walks serially.
+func benchmarkListObjectsWideTree(b *testing.B, walkers int) {
+	// Make a temporary directory to use as the fs.
+	directory, e := ioutil.TempDir("", "minio-list-benchmark")
+	if e != nil {
+		b.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{ListWalkers: walkers},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Create a bucket.
+	err = fs.MakeBucket("ls-benchmark-bucket")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < 200; i++ {
+		for j := 0; j < 10; j++ {
+			key := "dir" + strconv.Itoa(i) + "/sub" + strconv.Itoa(j%2) + "/obj" + strconv.Itoa(j)
+			_, err = fs.PutObject("ls-benchmark-bucket", key, int64(len(key)), bytes.NewBufferString(key), nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.ResetTimer()
+
+	// List all pages of the bucket over and over.
+	for i := 0; i < b.N; i++ {
+		marker := ""
+		for {
+			result, err := fs.ListObjects("ls-benchmark-bucket", "", marker, "", 1000)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if !result.IsTruncated {
+				break
+			}
+			marker = result.NextMarker
+		}
+	}
+}
+
+// Testing ListObjectsContext() cancelled in the middle of a walk.
+func TestListObjectsContextCancel(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-list-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-bucket-list-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		_, err = fs.PutObject("test-bucket-list-object", "obj"+strconv.Itoa(i), int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Cancel after a few entries were walked.
+	ctx := &cancelAfterContext{Context: context.Background(), checks: 3}
+	_, err = fs.(*Filesystem).ListObjectsContext(ctx, "test-bucket-list-object", "", "", "", 1000)
+	if err == nil {
+		t.Fatal("Expected ListObjectsContext to fail when cancelled, but it passed instead")
+	}
+	if err.ToGoError() != context.Canceled {
+		t.Fatalf("Expected context.Canceled, but instead found \"%s\"", err.Cause.Error())
+	}
+
+	// Already cancelled context returns right away.
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = fs.(*Filesystem).ListObjectsContext(cancelCtx, "test-bucket-list-object", "", "", "", 1000)
+	if err == nil || err.ToGoError() != context.Canceled {
+		t.Fatalf("Expected context.Canceled, but instead found \"%v\"", err)
+	}
+}
+
+// Tests that temporary files and multipart directories are never
+// listed, wherever they live in the bucket.
+func TestListObjectsSkipsInternalNames(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-list-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-bucket-list-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("test-bucket-list-object", "photos/photo.jpg", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write temporary files next to the object and a multipart
+	// directory at the top of the bucket.
+	bucketDir := filepath.Join(directory, "test-bucket-list-object")
+	internalFiles := []string{
+		"photos/$tmpobject123",
+		"$multiparts/photo.jpg/00001",
+	}
+	for _, internalFile := range internalFiles {
+		internalPath := filepath.Join(bucketDir, filepath.FromSlash(internalFile))
+		if e = os.MkdirAll(filepath.Dir(internalPath), 0700); e != nil {
+			t.Fatal(e)
+		}
+		if e = ioutil.WriteFile(internalPath, []byte("{}"), 0600); e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	testCases := []struct {
+		prefix    string
+		delimiter string
+		objects   []string
+		prefixes  []string
+	}{
+		{"", "", []string{"photos/photo.jpg"}, nil},
+		{"", "/", nil, []string{"photos/"}},
+		{"photos/", "/", []string{"photos/photo.jpg"}, nil},
+	}
+	for i, testCase := range testCases {
+		result, err := fs.ListObjects("test-bucket-list-object", testCase.prefix, "", testCase.delimiter, 1000)
+		if err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		var objects []string
+		for _, object := range result.Objects {
+			objects = append(objects, object.Name)
+		}
+		if strings.Join(objects, ",") != strings.Join(testCase.objects, ",") {
+			t.Errorf("Test %d: Expected objects %v, but found %v", i+1, testCase.objects, objects)
+		}
+		if strings.Join(result.Prefixes, ",") != strings.Join(testCase.prefixes, ",") {
+			t.Errorf("Test %d: Expected prefixes %v, but found %v", i+1, testCase.prefixes, result.Prefixes)
+		}
+	}
+
+	// Objects with internal names can not be created.
+	for _, object := range []string{"photos/$tmpobject", "$multiparts/object"} {
+		_, err = fs.PutObject("test-bucket-list-object", object, int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+		if err == nil {
+			t.Fatalf("Expected PutObject of %s to fail", object)
+		}
+		if _, ok := err.ToGoError().(ObjectNameInvalid); !ok {
+			t.Errorf("Expected ObjectNameInvalid for %s, but found %v", object, err)
+		}
+		_, err = fs.NewMultipartUpload("test-bucket-list-object", object)
+		if _, ok := err.ToGoError().(ObjectNameInvalid); !ok {
+			t.Errorf("Expected ObjectNameInvalid for new upload of %s, but found %v", object, err)
+		}
+	}
+
+	// Sidecar suffixes are valid object names, sidecars do not live in
+	// the data tree.
+	var objects []string
+	for _, suffix := range []string{objectMetaSuffix, objectTagsSuffix, partChecksumSuffix, uploadIDSuffix} {
+		object := "photos/photo.jpg" + suffix
+		if _, err = fs.PutObject("test-bucket-list-object", object, int64(len("abcd")), bytes.NewBufferString("abcd"), nil); err != nil {
+			t.Fatalf("Expected PutObject of %s to pass, but failed with: <ERROR> %s", object, err.Cause.Error())
+		}
+		objects = append(objects, object)
+	}
+	sort.Strings(objects)
+	objects = append([]string{"photos/photo.jpg"}, objects...)
+	result, err := fs.ListObjects("test-bucket-list-object", "photos/", "", "/", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []string
+	for _, object := range result.Objects {
+		listed = append(listed, object.Name)
+	}
+	if strings.Join(listed, ",") != strings.Join(objects, ",") {
+		t.Errorf("Expected objects %v, but found %v", objects, listed)
+	}
+}
+
+func TestListObjectsFiltered(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-list-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	err = fs.MakeBucket("test-bucket-list-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every other object was modified a day ago.
+	dayAgo := time.Now().Add(-24 * time.Hour)
+	for i, object := range []string{"a", "b", "c", "d", "dir/e", "f", "g"} {
+		_, err = fs.PutObject("test-bucket-list-object", object, int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 1 {
+			if e = os.Chtimes(filepath.Join(directory, "test-bucket-list-object", object), dayAgo, dayAgo); e != nil {
+				t.Fatal(e)
+			}
+		}
+	}
+	since := time.Now().Add(-time.Hour)
+
+	testCases := []struct {
+		marker      string
+		delimiter   string
+		maxKeys     int
+		objects     []string
+		prefixes    []string
+		isTruncated bool
+	}{
+		{"", "", 1000, []string{"a", "c", "dir/e", "g"}, nil, false},
+		// Skipped objects do not count towards maxKeys.
+		{"", "", 2, []string{"a", "c"}, nil, true},
+		{"c", "", 2, []string{"dir/e", "g"}, nil, false},
+		{"g", "", 2, nil, nil, false},
+		// Common prefixes are listed regardless of their objects.
+		{"", "/", 1000, []string{"a", "c", "g"}, []string{"dir/"}, false},
+	}
+	for i, testCase := range testCases {
+		result, err := fs.ListObjectsFiltered("test-bucket-list-object", "", testCase.marker, testCase.delimiter, testCase.maxKeys, since)
+		if err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		var objects []string
+		for _, object := range result.Objects {
+			objects = append(objects, object.Name)
+		}
+		if strings.Join(objects, ",") != strings.Join(testCase.objects, ",") {
+			t.Errorf("Test %d: Expected objects %v, but found %v", i+1, testCase.objects, objects)
+		}
+		if strings.Join(result.Prefixes, ",") != strings.Join(testCase.prefixes, ",") {
+			t.Errorf("Test %d: Expected prefixes %v, but found %v", i+1, testCase.prefixes, result.Prefixes)
+		}
+		if result.IsTruncated != testCase.isTruncated {
+			t.Errorf("Test %d: Expected IsTruncated %v, but found %v", i+1, testCase.isTruncated, result.IsTruncated)
+		}
+		if testCase.isTruncated && result.NextMarker != testCase.objects[len(testCase.objects)-1] {
+			t.Errorf("Test %d: Expected next marker %s, but found %s", i+1, testCase.objects[len(testCase.objects)-1], result.NextMarker)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// Name of the bucket quota file kept under the metadata path.
+const bucketQuotaFile = "quota.json"
+
+// fsBucketQuota - maximum total size of the objects of a bucket.
+type fsBucketQuota struct {
+	Bytes int64 `json:"bytes"`
+}
+
+// SetBucketQuota - limit the total size of the objects of a bucket,
+// a quota of zero removes the limit.
+func (fs Filesystem) SetBucketQuota(bucket string, bytes int64) *probe.Error {
+	bucketDirName, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return probe.NewError(e).Trace(bucket)
+	}
+	bucket = bucketDirName
+	if bytes < 0 {
+		return probe.NewError(fmt.Errorf("Invalid bucket quota %d", bytes)).Trace(bucket)
+	}
+	quotaPath := fs.bucketMetaPath(bucket, bucketQuotaFile)
+	if bytes == 0 {
+		if e = removeFileTree(quotaPath, fs.metaPath); e != nil && !os.IsNotExist(e) {
+			return probe.NewError(e).Trace(bucket)
+		}
+		return nil
+	}
+	if e = fs.writeMetaFile(quotaPath, fsBucketQuota{Bytes: bytes}); e != nil {
+		return probe.NewError(e).Trace(bucket)
+	}
+	return nil
+}
+
+// GetBucketQuota - get the quota of a bucket, zero when unlimited.
+func (fs Filesystem) GetBucketQuota(bucket string) (int64, *probe.Error) {
+	bucketDirName, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return 0, probe.NewError(e).Trace(bucket)
+	}
+	quota, e := fs.readBucketQuota(bucketDirName)
+	if e != nil {
+		return 0, probe.NewError(e).Trace(bucket)
+	}
+	return quota, nil
+}
+
+// readBucketQuota - read the quota of a bucket, zero when unlimited.
+func (fs Filesystem) readBucketQuota(bucket string) (int64, error) {
+	quota := fsBucketQuota{}
+	if e := readMetaFile(fs.bucketMetaPath(bucket, bucketQuotaFile), &quota); e != nil {
+		if os.IsNotExist(e) {
+			return 0, nil
+		}
+		return 0, e
+	}
+	return quota.Bytes, nil
+}
+
+// existingObjectSize - returns the size of the object file at
+// objectPath, false if there is none.
+func existingObjectSize(objectPath string) (int64, bool) {
+	st, e := os.Lstat(objectPath)
+	if e != nil || !isObjectFileMode(st.Mode()) {
+		return 0, false
+	}
+	return st.Size(), true
+}
+
+// checkBucketQuota - verifies replacing an object of oldSize with one
+// of newSize keeps the bucket within its quota, the usage of the
+// bucket is kept up to date by writes without walking it again.
+func (fs Filesystem) checkBucketQuota(bucket string, oldSize, newSize int64) error {
+	release, e := fs.reserveBucketQuota(bucket, oldSize, newSize)
+	if e != nil {
+		return e
+	}
+	release()
+	return nil
+}
+
+// reserveBucketQuota - verifies the write like checkBucketQuota and
+// reserves the growth of the bucket until release is called, which
+// writers do once the usage was updated. Concurrent writes of a bucket
+// are checked one at a time against its usage and the reservations of
+// the others, so that they can not exceed the quota together.
+func (fs Filesystem) reserveBucketQuota(bucket string, oldSize, newSize int64) (release func(), e error) {
+	release = func() {}
+	quota, e := fs.readBucketQuota(bucket)
+	if e != nil || quota == 0 {
+		return release, e
+	}
+	// Walks the bucket if its usage was never computed.
+	if _, _, err := fs.BucketUsage(bucket); err != nil {
+		return release, err.ToGoError()
+	}
+
+	fs.usage.mutex.Lock()
+	defer fs.usage.mutex.Unlock()
+	usage, _, e := fs.loadBucketUsage(bucket)
+	if e != nil {
+		return release, e
+	}
+	key := strings.ToLower(bucket)
+	if usage.Bytes+fs.usage.reserved[key]-oldSize+newSize > quota {
+		return release, QuotaExceeded{Bucket: bucket, Quota: quota}
+	}
+	// Shrinking objects free their room only once written.
+	growth := newSize - oldSize
+	if growth <= 0 {
+		return release, nil
+	}
+	fs.usage.reserved[key] += growth
+	return func() {
+		fs.usage.mutex.Lock()
+		defer fs.usage.mutex.Unlock()
+		fs.usage.reserved[key] -= growth
+		if fs.usage.reserved[key] == 0 {
+			delete(fs.usage.reserved, key)
+		}
+	}, nil
+}
+
+// Room reserved at once by streaming writes, so the quota is not read
+// again for every read of their data.
+const quotaReserveStep = 1024 * 1024
+
+// quotaReader - reserves the bucket quota for the data of a streaming
+// write as it is read, replacing an object of oldSize. Returns
+// QuotaExceeded once the data no longer fits. Writers call release
+// once the usage of the bucket includes the write.
+type quotaReader struct {
+	reader   io.Reader
+	fs       Filesystem
+	bucket   string
+	oldSize  int64
+	read     int64
+	reserved int64
+	release  func()
+}
+
+func (r *quotaReader) Read(p []byte) (int, error) {
+	n, e := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read <= r.reserved {
+		return n, e
+	}
+	// Replace the reservation with a larger one, near the quota only
+	// the data read so far is reserved.
+	r.release()
+	r.release, r.reserved = func() {}, 0
+	size := r.read + quotaReserveStep
+	release, qe := r.fs.reserveBucketQuota(r.bucket, r.oldSize, size)
+	if _, ok := qe.(QuotaExceeded); ok {
+		size = r.read
+		release, qe = r.fs.reserveBucketQuota(r.bucket, r.oldSize, size)
+	}
+	if qe != nil {
+		return n, qe
+	}
+	r.release, r.reserved = release, size
+	return n, e
+}
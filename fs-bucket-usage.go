@@ -0,0 +1,258 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// Name of the bucket usage file kept under the metadata path.
+const bucketUsageFile = "usage.json"
+
+// bucketUsage - number and total size of the objects of a bucket,
+// persisted and kept up to date by writes and deletes.
+type bucketUsage struct {
+	Objects int64 `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+	// Time the bucket was last walked.
+	Computed time.Time `json:"computed"`
+}
+
+// bucketUsageCache - persisted usage by bucket loaded so far, the
+// mutex serializes updates of the usage files, of the bytes reserved
+// by writes in progress and of the walks in progress.
+type bucketUsageCache struct {
+	mutex    *sync.Mutex
+	entries  map[string]bucketUsage
+	reserved map[string]int64
+	walks    map[string][]*usageWalk
+}
+
+// newBucketUsageCache - returns an empty cache.
+func newBucketUsageCache() *bucketUsageCache {
+	return &bucketUsageCache{
+		mutex:    &sync.Mutex{},
+		entries:  make(map[string]bucketUsage),
+		reserved: make(map[string]int64),
+		walks:    make(map[string][]*usageWalk),
+	}
+}
+
+// usageWalk - changes by writes and deletes landing while a bucket is
+// walked, by stored object name. Changes of objects the walk visits
+// afterwards are part of the walk and dropped, the rest is applied to
+// the usage walked.
+type usageWalk struct {
+	deltas map[string]bucketUsage
+}
+
+// walkBucketUsage - walks the data of a bucket, replaced in tests.
+var walkBucketUsage = filepath.Walk
+
+// loadBucketUsage - returns the usage of a bucket from the cache or
+// its usage file, false if it was never computed. Callers hold the
+// cache mutex.
+func (fs Filesystem) loadBucketUsage(bucket string) (bucketUsage, bool, error) {
+	if usage, ok := fs.usage.entries[strings.ToLower(bucket)]; ok {
+		return usage, true, nil
+	}
+	usage := bucketUsage{}
+	if e := readMetaFile(fs.bucketMetaPath(bucket, bucketUsageFile), &usage); e != nil {
+		if os.IsNotExist(e) {
+			return bucketUsage{}, false, nil
+		}
+		return bucketUsage{}, false, e
+	}
+	fs.usage.entries[strings.ToLower(bucket)] = usage
+	return usage, true, nil
+}
+
+// saveBucketUsage - persists and caches the usage of a bucket. Callers
+// hold the cache mutex.
+func (fs Filesystem) saveBucketUsage(bucket string, usage bucketUsage) error {
+	if e := fs.writeMetaFile(fs.bucketMetaPath(bucket, bucketUsageFile), usage); e != nil {
+		return e
+	}
+	fs.usage.entries[strings.ToLower(bucket)] = usage
+	return nil
+}
+
+// addBucketUsage - adjusts the usage of a bucket by a write or delete
+// of object, buckets never computed are walked when first needed.
+// Usage which could not be persisted is dropped, so that the bucket is
+// walked again instead of reporting a stale counter.
+func (fs Filesystem) addBucketUsage(bucket, object string, objects, bytes int64) {
+	fs.usage.mutex.Lock()
+	defer fs.usage.mutex.Unlock()
+	name := objectStorageName(object)
+	for _, walk := range fs.usage.walks[strings.ToLower(bucket)] {
+		delta := walk.deltas[name]
+		delta.Objects += objects
+		delta.Bytes += bytes
+		walk.deltas[name] = delta
+	}
+	usage, ok, e := fs.loadBucketUsage(bucket)
+	if e == nil && ok {
+		usage.Objects += objects
+		usage.Bytes += bytes
+		e = fs.saveBucketUsage(bucket, usage)
+	}
+	if e != nil {
+		errorIf(probe.NewError(e).Trace(bucket), "Unable to update bucket usage.", nil)
+		fs.removeBucketUsage(bucket)
+	}
+}
+
+// removeBucketUsage - removes the usage of a bucket. Callers hold the
+// cache mutex.
+func (fs Filesystem) removeBucketUsage(bucket string) {
+	delete(fs.usage.entries, strings.ToLower(bucket))
+	os.Remove(fs.bucketMetaPath(bucket, bucketUsageFile))
+}
+
+// BucketUsage - returns the number and total size of the objects of a
+// bucket, see BucketUsageContext.
+func (fs Filesystem) BucketUsage(bucket string) (objectCount int64, totalBytes int64, err *probe.Error) {
+	return fs.BucketUsageContext(context.Background(), bucket)
+}
+
+// BucketUsageContext - returns the number and total size of the
+// objects of a bucket. The bucket is walked only if its usage was
+// never computed, the walk stops when ctx is canceled.
+func (fs Filesystem) BucketUsageContext(ctx context.Context, bucket string) (objectCount int64, totalBytes int64, err *probe.Error) {
+	bucket, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return 0, 0, probe.NewError(e).Trace(bucket)
+	}
+	fs.usage.mutex.Lock()
+	usage, ok, e := fs.loadBucketUsage(bucket)
+	fs.usage.mutex.Unlock()
+	if e != nil {
+		return 0, 0, probe.NewError(e).Trace(bucket)
+	}
+	if ok {
+		return usage.Objects, usage.Bytes, nil
+	}
+	return fs.RecomputeUsageContext(ctx, bucket)
+}
+
+// RecomputeUsage - walks a bucket and persists its usage, correcting
+// drift of the counter such as after a crash between writing an object
+// and updating the usage.
+func (fs Filesystem) RecomputeUsage(bucket string) (objectCount int64, totalBytes int64, err *probe.Error) {
+	return fs.RecomputeUsageContext(context.Background(), bucket)
+}
+
+// RecomputeUsageContext - see RecomputeUsage, the walk stops when ctx
+// is canceled.
+func (fs Filesystem) RecomputeUsageContext(ctx context.Context, bucket string) (objectCount int64, totalBytes int64, err *probe.Error) {
+	bucket, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return 0, 0, probe.NewError(e).Trace(bucket)
+	}
+
+	// Record writes landing during the walk.
+	walk := &usageWalk{deltas: make(map[string]bucketUsage)}
+	fs.usage.mutex.Lock()
+	fs.usage.walks[strings.ToLower(bucket)] = append(fs.usage.walks[strings.ToLower(bucket)], walk)
+	fs.usage.mutex.Unlock()
+	defer fs.stopUsageWalk(bucket, walk)
+
+	usage := bucketUsage{Computed: time.Now().UTC()}
+	bucketDir := filepath.Join(fs.path, bucket)
+	walkFn := func(path string, info os.FileInfo, e error) error {
+		if e != nil {
+			// Objects removed while walking.
+			if os.IsNotExist(e) {
+				fs.visitUsageWalk(walk, bucketDir, path)
+				return nil
+			}
+			return e
+		}
+		if e = ctx.Err(); e != nil {
+			return e
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		object, e := filepath.Rel(bucketDir, path)
+		if e != nil {
+			return e
+		}
+		// Skip temporary files of writes in progress and sidecar files.
+		if isInternalName(object) {
+			return nil
+		}
+		fs.visitUsageWalk(walk, bucketDir, path)
+		usage.Objects++
+		usage.Bytes += info.Size()
+		return nil
+	}
+	if e = walkBucketUsage(bucketDir, walkFn); e != nil {
+		return 0, 0, probe.NewError(e).Trace(bucket)
+	}
+
+	fs.usage.mutex.Lock()
+	defer fs.usage.mutex.Unlock()
+	// Apply the writes of objects the walk did not visit afterwards.
+	for _, delta := range walk.deltas {
+		usage.Objects += delta.Objects
+		usage.Bytes += delta.Bytes
+	}
+	if e = fs.saveBucketUsage(bucket, usage); e != nil {
+		return 0, 0, probe.NewError(e).Trace(bucket)
+	}
+	return usage.Objects, usage.Bytes, nil
+}
+
+// visitUsageWalk - drops the changes recorded for the object at path,
+// the walk visiting it afterwards includes them.
+func (fs Filesystem) visitUsageWalk(walk *usageWalk, bucketDir, path string) {
+	object, e := filepath.Rel(bucketDir, path)
+	if e != nil {
+		return
+	}
+	fs.usage.mutex.Lock()
+	delete(walk.deltas, filepath.ToSlash(object))
+	fs.usage.mutex.Unlock()
+}
+
+// stopUsageWalk - stops recording writes for a walk of a bucket.
+func (fs Filesystem) stopUsageWalk(bucket string, walk *usageWalk) {
+	fs.usage.mutex.Lock()
+	defer fs.usage.mutex.Unlock()
+	key := strings.ToLower(bucket)
+	walks := fs.usage.walks[key]
+	for i := range walks {
+		if walks[i] == walk {
+			walks = append(walks[:i], walks[i+1:]...)
+			break
+		}
+	}
+	if len(walks) == 0 {
+		delete(fs.usage.walks, key)
+		return
+	}
+	fs.usage.walks[key] = walks
+}
@@ -21,6 +21,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/minio/minio/pkg/disk"
 	"github.com/minio/minio/pkg/probe"
@@ -32,36 +33,54 @@ import (
 func (fs Filesystem) DeleteBucket(bucket string) *probe.Error {
 	// Verify bucket is valid.
 	if !IsValidBucketName(bucket) {
-		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+		return probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket)
+	}
+	defer fs.listCache.invalidate(bucket)
+	if e := fs.checkRootPath(); e != nil {
+		return probe.NewError(e).Trace(bucket)
 	}
 	bucket = getActualBucketname(fs.path, bucket)
 	bucketDir := filepath.Join(fs.path, bucket)
 	if e := os.Remove(bucketDir); e != nil {
 		// Error if there was no bucket in the first place.
 		if os.IsNotExist(e) {
-			return probe.NewError(BucketNotFound{Bucket: bucket})
+			return probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket)
 		}
 		// On windows the string is slightly different, handle it here.
 		if strings.Contains(e.Error(), "directory is not empty") {
-			return probe.NewError(BucketNotEmpty{Bucket: bucket})
+			return probe.NewError(BucketNotEmpty{Bucket: bucket}).Trace(bucket)
 		}
 		// Hopefully for all other operating systems, this is
 		// assumed to be consistent.
 		if strings.Contains(e.Error(), "directory not empty") {
-			return probe.NewError(BucketNotEmpty{Bucket: bucket})
+			return probe.NewError(BucketNotEmpty{Bucket: bucket}).Trace(bucket)
+		}
+		return probe.NewError(e).Trace(bucket)
+	}
+	// Remove bucket metadata.
+	fs.usage.mutex.Lock()
+	delete(fs.usage.entries, strings.ToLower(bucket))
+	fs.usage.mutex.Unlock()
+	for _, metaFile := range []string{bucketEncryptionFile, bucketQuotaFile, bucketUsageFile, bucketMetaFile} {
+		e := removeFileTree(fs.bucketMetaPath(bucket, metaFile), fs.metaPath)
+		if e != nil && !os.IsNotExist(e) {
+			return probe.NewError(e).Trace(bucket)
 		}
-		return probe.NewError(e)
 	}
 	return nil
 }
 
 // ListBuckets - Get service.
 func (fs Filesystem) ListBuckets() ([]BucketInfo, *probe.Error) {
+	if e := fs.checkRootPath(); e != nil {
+		return []BucketInfo{}, probe.NewError(e)
+	}
 	files, e := ioutil.ReadDir(fs.path)
 	if e != nil {
 		return []BucketInfo{}, probe.NewError(e)
 	}
 	var buckets []BucketInfo
+	owner := getOwner()
 	for _, file := range files {
 		if !file.IsDir() {
 			// If not directory, ignore all file types.
@@ -74,7 +93,8 @@ func (fs Filesystem) ListBuckets() ([]BucketInfo, *probe.Error) {
 		}
 		bucket := BucketInfo{
 			Name:    dirName,
-			Created: file.ModTime(),
+			Created: fs.getBucketCreated(file.Name(), file),
+			Owner:   owner,
 		}
 		buckets = append(buckets, bucket)
 	}
@@ -105,36 +125,76 @@ func removeDuplicateBuckets(buckets []BucketInfo) []BucketInfo {
 
 // MakeBucket - PUT Bucket
 func (fs Filesystem) MakeBucket(bucket string) *probe.Error {
+	if e := fs.checkRootPath(); e != nil {
+		return probe.NewError(e).Trace(bucket)
+	}
 	di, err := disk.GetInfo(fs.path)
 	if err != nil {
-		return probe.NewError(err)
+		return probe.NewError(err).Trace(bucket)
 	}
 
 	// Remove 5% from total space for cumulative disk space used for
 	// journalling, inodes etc.
 	availableDiskSpace := (float64(di.Free) / (float64(di.Total) - (0.05 * float64(di.Total)))) * 100
 	if int64(availableDiskSpace) <= fs.minFreeDisk {
-		return probe.NewError(RootPathFull{Path: fs.path})
+		return probe.NewError(RootPathFull{Path: fs.path}).Trace(bucket)
 	}
 
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
-		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+		return probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket)
 	}
 
 	bucket = getActualBucketname(fs.path, bucket)
 	bucketDir := filepath.Join(fs.path, bucket)
 	if _, e := os.Stat(bucketDir); e == nil {
-		return probe.NewError(BucketExists{Bucket: bucket})
+		return probe.NewError(BucketExists{Bucket: bucket}).Trace(bucket)
 	}
 
 	// Make bucket.
-	if e := os.Mkdir(bucketDir, 0700); e != nil {
-		return probe.NewError(err)
+	if e := os.Mkdir(bucketDir, fs.dirPerm(0700)); e != nil {
+		return probe.NewError(e).Trace(bucket)
+	}
+
+	// Record bucket creation time.
+	bucketMeta := fsBucketMetadata{Created: time.Now().UTC()}
+	if e := fs.writeBucketMetadata(bucket, bucketMeta); e != nil {
+		os.Remove(bucketDir)
+		return probe.NewError(e).Trace(bucket)
 	}
 	return nil
 }
 
+// Name of the bucket metadata file kept under the metadata path.
+const bucketMetaFile = "bucket.json"
+
+// fsBucketMetadata - metadata persisted for every bucket created.
+type fsBucketMetadata struct {
+	Created time.Time `json:"created"`
+}
+
+// bucketMetaPath - path of a bucket level metadata file. Uploads of
+// keys with the same name are kept apart, under multipartsDir.
+func (fs Filesystem) bucketMetaPath(bucket, metaFile string) string {
+	return filepath.Join(fs.metaPath, bucket, metaFile)
+}
+
+// writeBucketMetadata - persist metadata of a bucket.
+func (fs Filesystem) writeBucketMetadata(bucket string, bucketMeta fsBucketMetadata) error {
+	return fs.writeMetaFile(fs.bucketMetaPath(bucket, bucketMetaFile), bucketMeta)
+}
+
+// getBucketCreated - returns the persisted creation time of a bucket,
+// buckets created before it was persisted fall back to the
+// modification time of the bucket directory.
+func (fs Filesystem) getBucketCreated(bucket string, fi os.FileInfo) time.Time {
+	bucketMeta := fsBucketMetadata{}
+	if e := readMetaFile(fs.bucketMetaPath(bucket, bucketMetaFile), &bucketMeta); e != nil || bucketMeta.Created.IsZero() {
+		return fi.ModTime()
+	}
+	return bucketMeta.Created
+}
+
 // getActualBucketname - will convert incoming bucket names to
 // corresponding actual bucketnames on the backend in a platform
 // compatible way for all operating systems.
@@ -160,7 +220,10 @@ func getActualBucketname(fsPath, bucket string) string {
 // GetBucketInfo - get bucket metadata.
 func (fs Filesystem) GetBucketInfo(bucket string) (BucketInfo, *probe.Error) {
 	if !IsValidBucketName(bucket) {
-		return BucketInfo{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+		return BucketInfo{}, probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket)
+	}
+	if e := fs.checkRootPath(); e != nil {
+		return BucketInfo{}, probe.NewError(e).Trace(bucket)
 	}
 	bucket = getActualBucketname(fs.path, bucket)
 	// Get bucket path.
@@ -169,12 +232,64 @@ func (fs Filesystem) GetBucketInfo(bucket string) (BucketInfo, *probe.Error) {
 	if e != nil {
 		// Check if bucket exists.
 		if os.IsNotExist(e) {
-			return BucketInfo{}, probe.NewError(BucketNotFound{Bucket: bucket})
+			return BucketInfo{}, probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket)
 		}
-		return BucketInfo{}, probe.NewError(e)
+		return BucketInfo{}, probe.NewError(e).Trace(bucket)
 	}
 	bucketMetadata := BucketInfo{}
 	bucketMetadata.Name = fi.Name()
-	bucketMetadata.Created = fi.ModTime()
+	bucketMetadata.Created = fs.getBucketCreated(bucket, fi)
+	bucketMetadata.Owner = getOwner()
 	return bucketMetadata, nil
 }
+
+// Actions granting read or write access to a bucket or its objects.
+var publicAccessActions = []string{"s3:GetObject", "s3:ListBucket", "s3:PutObject", "s3:DeleteObject"}
+
+// isPublicStatement - returns true if statement allows anonymous read
+// or write access without conditions. Conditions restrict a statement
+// to part of the bucket, so conditional statements are not public.
+// Policies with conditions other than s3:prefix or s3:max-keys, for
+// example on the source IP, are rejected by the policy parser.
+func isPublicStatement(statement policyStatement) bool {
+	if statement.Effect != "Allow" || len(statement.Conditions) > 0 {
+		return false
+	}
+	if !contains(statement.Principal.AWS, "*") {
+		return false
+	}
+	for _, action := range publicAccessActions {
+		if bucketPolicyActionMatch(action, statement) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBucketPolicyStatus - returns true if the bucket policy allows
+// anonymous read or write access, buckets without a policy are not
+// public.
+func (fs Filesystem) GetBucketPolicyStatus(bucket string) (bool, *probe.Error) {
+	bucketDirName, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return false, probe.NewError(e).Trace(bucket)
+	}
+	bucket = bucketDirName
+	policyBytes, err := readBucketPolicy(bucket)
+	if err != nil {
+		if _, ok := err.ToGoError().(BucketPolicyNotFound); ok {
+			return false, nil
+		}
+		return false, err.Trace(bucket)
+	}
+	policy, e := parseBucketPolicy(policyBytes)
+	if e != nil {
+		return false, probe.NewError(e).Trace(bucket)
+	}
+	for _, statement := range policy.Statements {
+		if isPublicStatement(statement) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
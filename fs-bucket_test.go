@@ -17,11 +17,17 @@
 package main
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/probe"
 )
 
 // The test not just includes asserting the correctness of the output,
@@ -156,6 +162,209 @@ func TestDeleteBucket(t *testing.T) {
 	}
 }
 
+// Testing bucket creation time persisted by MakeBucket().
+func TestBucketCreated(t *testing.T) {
+	// Make a temporary directory to use as the fs.
+	directory, e := ioutil.TempDir("", "minio-bucket-created")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucketInfo, err := fs.GetBucketInfo("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := bucketInfo.Created
+
+	// Creation time survives a touch of the bucket directory.
+	touched := created.Add(48 * time.Hour)
+	if e = os.Chtimes(filepath.Join(directory, "bucket"), touched, touched); e != nil {
+		t.Fatal(e)
+	}
+	bucketInfo, err = fs.GetBucketInfo("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bucketInfo.Created.Equal(created) {
+		t.Fatalf("Expected bucket creation time %s, but instead found %s", created, bucketInfo.Created)
+	}
+	buckets, err := fs.ListBuckets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 1 || !buckets[0].Created.Equal(created) {
+		t.Fatalf("Expected listed bucket creation time %s, but instead found %v", created, buckets)
+	}
+
+	// Buckets created before the creation time was persisted fall
+	// back to the directory modification time.
+	legacy := created.Add(-48 * time.Hour).Truncate(time.Second)
+	if e = os.Mkdir(filepath.Join(directory, "legacy"), 0700); e != nil {
+		t.Fatal(e)
+	}
+	if e = os.Chtimes(filepath.Join(directory, "legacy"), legacy, legacy); e != nil {
+		t.Fatal(e)
+	}
+	bucketInfo, err = fs.GetBucketInfo("legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bucketInfo.Created.Equal(legacy) {
+		t.Fatalf("Expected legacy bucket creation time %s, but instead found %s", legacy, bucketInfo.Created)
+	}
+
+	// Bucket metadata is removed along with the bucket.
+	err = fs.DeleteBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, e = os.Stat(filepath.Join(directory, configDir, "bucket")); !os.IsNotExist(e) {
+		t.Fatal("Expected bucket metadata to be removed")
+	}
+}
+
+// Testing PutBucketEncryption() and GetBucketEncryption().
+func TestBucketEncryption(t *testing.T) {
+	// Make a temporary directory to use as the fs.
+	directory, e := ioutil.TempDir("", "minio-bucket-encryption")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Bucket without encryption.
+	_, err = filesystem.GetBucketEncryption("bucket")
+	if _, ok := err.ToGoError().(BucketEncryptionNotFound); !ok {
+		t.Fatalf("Expected BucketEncryptionNotFound error, but instead found \"%v\"", err)
+	}
+
+	// Set and get encryption.
+	err = filesystem.PutBucketEncryption("bucket", "AES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	algorithm, err := filesystem.GetBucketEncryption("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algorithm != "AES256" {
+		t.Fatalf("Expected encryption algorithm AES256, but instead found %s", algorithm)
+	}
+
+	// Unknown algorithm is rejected and keeps the previous setting.
+	err = filesystem.PutBucketEncryption("bucket", "ROT13")
+	if _, ok := err.ToGoError().(InvalidEncryptionAlgorithm); !ok {
+		t.Fatalf("Expected InvalidEncryptionAlgorithm error, but instead found \"%v\"", err)
+	}
+	algorithm, err = filesystem.GetBucketEncryption("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algorithm != "AES256" {
+		t.Fatalf("Expected encryption algorithm AES256, but instead found %s", algorithm)
+	}
+
+	// Missing bucket.
+	err = filesystem.PutBucketEncryption("missing", "AES256")
+	if _, ok := err.ToGoError().(BucketNotFound); !ok {
+		t.Fatalf("Expected BucketNotFound error, but instead found \"%v\"", err)
+	}
+
+	// Bucket encryption is removed along with the bucket.
+	err = fs.DeleteBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, e = os.Stat(filepath.Join(directory, configDir, "bucket")); !os.IsNotExist(e) {
+		t.Fatal("Expected bucket encryption to be removed")
+	}
+}
+
+func TestGetBucketPolicyStatus(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-policy-status-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Keep bucket policies under the test directory.
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{MetaDir: filepath.Join(directory, ".minio")},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+
+	testCases := []struct {
+		bucket   string
+		policy   string
+		isPublic bool
+	}{
+		// No policy.
+		{"no-policy", "", false},
+		// Anonymous reads.
+		{"public-read", `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::public-read/*"]}]}`, true},
+		// Anonymous writes.
+		{"public-write", `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:PutObject"],"Resource":["arn:aws:s3:::public-write/*"]}]}`, true},
+		// Listing restricted to a prefix by a condition.
+		{"conditional", `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:ListBucket"],"Resource":["arn:aws:s3:::conditional"],"Condition":{"StringEquals":{"s3:prefix":"public/"}}}]}`, false},
+		// Only denies anonymous access.
+		{"deny-only", `{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::deny-only/*"]}]}`, false},
+		// Allows only actions which do not read or write data.
+		{"location-only", `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetBucketLocation"],"Resource":["arn:aws:s3:::location-only"]}]}`, false},
+	}
+	for i, testCase := range testCases {
+		if err = fs.MakeBucket(testCase.bucket); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		if testCase.policy != "" {
+			if err = writeBucketPolicy(testCase.bucket, []byte(testCase.policy)); err != nil {
+				t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+			}
+		}
+		isPublic, err := filesystem.GetBucketPolicyStatus(testCase.bucket)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		if isPublic != testCase.isPublic {
+			t.Errorf("Test %d: Expected bucket %s public to be %v, got %v", i+1, testCase.bucket, testCase.isPublic, isPublic)
+		}
+	}
+
+	// Missing bucket.
+	if _, err = filesystem.GetBucketPolicyStatus("missing-bucket"); err == nil {
+		t.Fatal("Expected policy status of a missing bucket to fail")
+	}
+}
+
 func BenchmarkListBuckets(b *testing.B) {
 	// Make a temporary directory to use as the fs.
 	directory, e := ioutil.TempDir("", "minio-benchmark")
@@ -254,3 +463,275 @@ func BenchmarkGetBucketInfo(b *testing.B) {
 		}
 	}
 }
+
+// Tests BucketUsage of a known set of objects.
+func TestBucketUsage(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-usage-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	objects := map[string]string{
+		"a":         "abcd",
+		"dir/b":     "abcdefgh",
+		"dir/sub/c": "",
+		"dir/":      "",
+	}
+	for object, data := range objects {
+		if _, err = fs.PutObject("bucket", object, int64(len(data)), strings.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Sidecars and uploads are not counted.
+	if err = fs.PutObjectTags("bucket", "a", map[string]string{"key": "value"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.NewMultipartUpload("bucket", "upload"); err != nil {
+		t.Fatal(err)
+	}
+
+	objectCount, totalBytes, err := fs.BucketUsage("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objectCount != 4 || totalBytes != 12 {
+		t.Fatalf("Expected 4 objects of 12 bytes, but instead found %d objects of %d bytes", objectCount, totalBytes)
+	}
+
+	// Writes and deletes update the usage without walking.
+	if _, err = fs.PutObject("bucket", "d", 4, strings.NewReader("abcd"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.DeleteObject("bucket", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if objectCount, totalBytes, err = fs.BucketUsage("bucket"); err != nil || objectCount != 4 || totalBytes != 12 {
+		t.Fatalf("Expected 4 objects of 12 bytes, but instead found %d objects of %d bytes, %v", objectCount, totalBytes, err)
+	}
+
+	// A missed update drifts the persisted counter, also after a
+	// restart, until the usage is recomputed.
+	if e = ioutil.WriteFile(filepath.Join(directory, "bucket", "e"), []byte("abcd"), 0644); e != nil {
+		t.Fatal(e)
+	}
+	obj, err = newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs = obj.(*Filesystem)
+	if objectCount, _, err = fs.BucketUsage("bucket"); err != nil || objectCount != 4 {
+		t.Fatalf("Expected persisted count of 4 objects, but instead found %d, %v", objectCount, err)
+	}
+	if objectCount, totalBytes, err = fs.RecomputeUsage("bucket"); err != nil || objectCount != 5 || totalBytes != 16 {
+		t.Fatalf("Expected 5 objects of 16 bytes, but instead found %d objects of %d bytes, %v", objectCount, totalBytes, err)
+	}
+	if objectCount, totalBytes, err = fs.BucketUsage("bucket"); err != nil || objectCount != 5 || totalBytes != 16 {
+		t.Fatalf("Expected corrected usage of 5 objects of 16 bytes, but instead found %d objects of %d bytes, %v", objectCount, totalBytes, err)
+	}
+
+	// Canceled walks fail.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err = fs.RecomputeUsageContext(ctx, "bucket"); err == nil || err.ToGoError() != context.Canceled {
+		t.Fatalf("Expected context.Canceled, but instead found \"%v\"", err)
+	}
+
+	// Missing buckets fail.
+	if _, _, err = fs.BucketUsage("missing"); err == nil {
+		t.Fatal("Expected usage of a missing bucket to fail")
+	}
+}
+
+// Tests writes and deletes landing while the usage of a bucket is
+// walked are part of the recomputed usage.
+func TestRecomputeUsageConcurrentWrites(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-usage-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"a", "c", "z"} {
+		if _, err = fs.PutObject("bucket", object, 4, strings.NewReader("abcd"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Write once the walk reached "c": "a" was visited before, "b" is
+	// created after the bucket was listed and "z" is visited after.
+	defer func(walk func(string, filepath.WalkFunc) error) { walkBucketUsage = walk }(walkBucketUsage)
+	walkBucketUsage = func(root string, walkFn filepath.WalkFunc) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, e error) error {
+			if filepath.Base(path) == "c" {
+				if _, err := fs.PutObject("bucket", "a", 8, strings.NewReader("abcdefgh"), nil); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := fs.PutObject("bucket", "b", 4, strings.NewReader("abcd"), nil); err != nil {
+					t.Fatal(err)
+				}
+				if err := fs.DeleteObject("bucket", "z"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return walkFn(path, info, e)
+		})
+	}
+	objectCount, totalBytes, err := fs.RecomputeUsage("bucket")
+	if err != nil || objectCount != 3 || totalBytes != 16 {
+		t.Fatalf("Expected 3 objects of 16 bytes, but instead found %d objects of %d bytes, %v", objectCount, totalBytes, err)
+	}
+	if len(fs.usage.walks) != 0 {
+		t.Fatalf("Expected no walks in progress, but instead found %d", len(fs.usage.walks))
+	}
+}
+
+// Tests writes are rejected once they would exceed the bucket quota.
+func TestBucketQuota(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-quota-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if quota, err := fs.GetBucketQuota("bucket"); err != nil || quota != 0 {
+		t.Fatalf("Expected no quota, but instead found %d, %v", quota, err)
+	}
+	if err = fs.SetBucketQuota("bucket", -1); err == nil {
+		t.Fatal("Expected a negative quota to be rejected")
+	}
+	if err = fs.SetBucketQuota("bucket", 10); err != nil {
+		t.Fatal(err)
+	}
+	if quota, err := fs.GetBucketQuota("bucket"); err != nil || quota != 10 {
+		t.Fatalf("Expected quota of 10 bytes, but instead found %d, %v", quota, err)
+	}
+
+	// putObject - writes an object of size bytes.
+	putObject := func(object string, size int) *probe.Error {
+		data := strings.Repeat("a", size)
+		_, err := fs.PutObject("bucket", object, int64(size), strings.NewReader(data), nil)
+		return err
+	}
+	// streamObject - writes an object of size bytes of unknown size.
+	streamObject := func(object string, size int) *probe.Error {
+		data := strings.Repeat("a", size)
+		_, err := fs.PutObject("bucket", object, -1, strings.NewReader(data), nil)
+		return err
+	}
+	// completeUpload - writes an object of size bytes by multipart.
+	completeUpload := func(object string, size int) *probe.Error {
+		uploadID, err := fs.NewMultipartUpload("bucket", object)
+		if err != nil {
+			return err
+		}
+		data := strings.Repeat("a", size)
+		etag, err := fs.PutObjectPart("bucket", object, uploadID, 1, int64(size), strings.NewReader(data), "")
+		if err != nil {
+			return err
+		}
+		_, err = fs.CompleteMultipartUpload("bucket", object, uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+		return err
+	}
+
+	testCases := []struct {
+		write    func(object string, size int) *probe.Error
+		object   string
+		size     int
+		exceeded bool
+	}{
+		// Within quota.
+		{putObject, "a", 4, false},
+		{completeUpload, "b", 4, false},
+		// Would exceed the quota.
+		{putObject, "c", 3, true},
+		{completeUpload, "c", 3, true},
+		{streamObject, "c", 3, true},
+		// Replacing an object only counts the difference.
+		{putObject, "a", 6, false},
+		{completeUpload, "b", 5, true},
+		{streamObject, "a", 7, true},
+		{streamObject, "a", 6, false},
+	}
+	for i, testCase := range testCases {
+		err := testCase.write(testCase.object, testCase.size)
+		if testCase.exceeded {
+			if err == nil {
+				t.Fatalf("Test %d: Expected quota to be exceeded, but passed instead", i+1)
+			}
+			if _, ok := err.ToGoError().(QuotaExceeded); !ok {
+				t.Fatalf("Test %d: Expected QuotaExceeded, but instead found %s", i+1, err.Cause.Error())
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+	}
+
+	// Room freed by deletes can be used again.
+	if err = fs.DeleteObject("bucket", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err = completeUpload("c", 6); err != nil {
+		t.Fatalf("Expected to pass after a delete, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if _, totalBytes, err := fs.BucketUsage("bucket"); err != nil || totalBytes != 10 {
+		t.Fatalf("Expected usage of 10 bytes, but instead found %d, %v", totalBytes, err)
+	}
+
+	// Room reserved by a write in progress is not available to others.
+	if err = fs.DeleteObject("bucket", "c"); err != nil {
+		t.Fatal(err)
+	}
+	release, e := fs.reserveBucketQuota("bucket", 0, 4)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if err = putObject("d", 4); err == nil {
+		t.Fatal("Expected quota reserved by another write to be exceeded, but passed instead")
+	} else if _, ok := err.ToGoError().(QuotaExceeded); !ok {
+		t.Fatalf("Expected QuotaExceeded, but instead found %s", err.Cause.Error())
+	}
+	if err = streamObject("d", 4); err == nil {
+		t.Fatal("Expected quota reserved by another write to be exceeded, but passed instead")
+	} else if _, ok := err.ToGoError().(QuotaExceeded); !ok {
+		t.Fatalf("Expected QuotaExceeded, but instead found %s", err.Cause.Error())
+	}
+	release()
+	if err = putObject("d", 4); err != nil {
+		t.Fatalf("Expected to pass after the reservation was released, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+
+	// Removing the quota removes the limit.
+	if err = fs.SetBucketQuota("bucket", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = putObject("e", 100); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"hash"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+const (
+	// Directory under the metadata path holding the content of
+	// deduplicated objects by sha256sum, not a valid bucket name.
+	blobsDir = "$blobs"
+	// Suffix of the reference count kept beside each blob.
+	blobRefsSuffix = ".refs.json"
+	// Namespace lock volume serializing updates of a blob, not a
+	// valid bucket name.
+	blobLockVolume = "$blobs"
+)
+
+// fsBlobRefs - number of objects referencing a blob.
+type fsBlobRefs struct {
+	Refs int `json:"refs"`
+}
+
+// blobPath - path of the blob holding content with the sha256sum hash.
+func (fs Filesystem) blobPath(hash string) string {
+	return filepath.Join(fs.metaPath, blobsDir, hash)
+}
+
+// readBlobRefs - read the reference count of a blob, zero when the
+// blob does not exist.
+func (fs Filesystem) readBlobRefs(hash string) (int, error) {
+	refs := fsBlobRefs{}
+	if e := readMetaFile(fs.blobPath(hash)+blobRefsSuffix, &refs); e != nil {
+		if os.IsNotExist(e) {
+			return 0, nil
+		}
+		return 0, e
+	}
+	return refs.Refs, nil
+}
+
+// dedupObject - replaces the object just written at objectPath by a
+// hard link to the blob of the same content, the object becomes the
+// blob when there is none yet. Objects are only ever replaced, never
+// written in place, so linked objects can not change each other.
+//
+// Removing a blob leaves the data of objects linked to it intact, a
+// reference count lagging behind after a crash at most loses the
+// saving of a later write or keeps an unused blob.
+func (fs Filesystem) dedupObject(objectPath, hash string) error {
+	fs.nsLock.lock(blobLockVolume, hash)
+	defer fs.nsLock.unlock(blobLockVolume, hash)
+
+	refs, e := fs.readBlobRefs(hash)
+	if e != nil {
+		return e
+	}
+	objSt, e := os.Stat(objectPath)
+	if e != nil {
+		return e
+	}
+
+	blobPath := fs.blobPath(hash)
+	linked := false
+	if blobSt, e := os.Stat(blobPath); e == nil && refs > 0 && blobSt.Size() == objSt.Size() {
+		// Link next to the object and rename over it, the object is
+		// never seen missing.
+		tmpPath := filepath.Join(filepath.Dir(objectPath), "$tmpobject-"+hash)
+		os.Remove(tmpPath)
+		if e = os.Link(blobPath, tmpPath); e != nil {
+			return e
+		}
+		if e = fs.retryTransient(func() error { return renameFile(tmpPath, objectPath) }); e != nil {
+			os.Remove(tmpPath)
+			return e
+		}
+		// Linked objects share the modification time of the blob.
+		now := time.Now()
+		if e = os.Chtimes(blobPath, now, now); e != nil {
+			return e
+		}
+		linked = true
+	}
+	if !linked {
+		// Missing or damaged blob, the object becomes the blob.
+		if e = os.MkdirAll(filepath.Dir(blobPath), 0700); e != nil {
+			return e
+		}
+		if e = os.Remove(blobPath); e != nil && !os.IsNotExist(e) {
+			return e
+		}
+		if e = os.Link(objectPath, blobPath); e != nil {
+			return e
+		}
+		refs = 0
+	}
+	return fs.writeMetaFile(blobPath+blobRefsSuffix, fsBlobRefs{Refs: refs + 1})
+}
+
+// releaseBlob - drops a reference to a blob of a replaced or deleted
+// object, removing the blob along with its last reference.
+func (fs Filesystem) releaseBlob(hash string) error {
+	fs.nsLock.lock(blobLockVolume, hash)
+	defer fs.nsLock.unlock(blobLockVolume, hash)
+
+	refs, e := fs.readBlobRefs(hash)
+	if e != nil {
+		return e
+	}
+	blobPath := fs.blobPath(hash)
+	if refs > 1 {
+		return fs.writeMetaFile(blobPath+blobRefsSuffix, fsBlobRefs{Refs: refs - 1})
+	}
+	if e = os.Remove(blobPath); e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	if e = removeFileTree(blobPath+blobRefsSuffix, fs.metaPath); e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	return nil
+}
+
+// dedupWrittenObject - with dedup enabled links an object just written
+// to the blob of its content sha256sum, then releases the blob of the
+// object it replaced. Returns the blob of the object, failures only
+// lose the saving and are logged.
+func (fs Filesystem) dedupWrittenObject(objectPath string, dedupHasher hash.Hash, oldBlob string) string {
+	var blob string
+	if fs.dedup {
+		blob = hex.EncodeToString(dedupHasher.Sum(nil))
+		if e := fs.dedupObject(objectPath, blob); e != nil {
+			errorIf(probe.NewError(e).Trace(objectPath), "Unable to deduplicate object.", nil)
+			blob = ""
+		}
+	}
+	if oldBlob != "" {
+		fs.releaseObjectBlob(oldBlob)
+	}
+	return blob
+}
+
+// releaseObjectBlob - releases the blob of a replaced or deleted
+// object, failures at most keep an unused blob and are logged.
+func (fs Filesystem) releaseObjectBlob(blob string) {
+	if e := fs.releaseBlob(blob); e != nil {
+		errorIf(probe.NewError(e).Trace(blob), "Unable to release deduplicated blob.", nil)
+	}
+}
+
+// objectBlob - sha256sum of the blob an object is linked to, empty
+// for objects which are not deduplicated.
+func (fs Filesystem) objectBlob(bucket, object string) string {
+	objMeta, e := fs.readObjectMetadata(bucket, object)
+	if e != nil {
+		return ""
+	}
+	return objMeta.Blob
+}
@@ -74,44 +74,47 @@ type treeWalker struct {
 	timedOut bool
 }
 
+// direntToObjectInfo - converts a dirent of prefixDir to ObjectInfo.
+func direntToObjectInfo(bucketDir, prefixDir string, dirent fsDirent) (ObjectInfo, error) {
+	objectInfo := ObjectInfo{}
+	// Convert to full object name.
+	objectInfo.Name = filepath.Join(prefixDir, dirent.name)
+	if dirent.modifiedTime.IsZero() && dirent.size == 0 {
+		// ModifiedTime and Size are zero, Stat() and figure out
+		// the actual values that need to be set.
+		fi, err := os.Stat(filepath.Join(bucketDir, prefixDir, dirent.name))
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		// Fill size and modtime.
+		objectInfo.ModifiedTime = fi.ModTime().Truncate(time.Second)
+		objectInfo.Size = fi.Size()
+		objectInfo.IsDir = fi.IsDir()
+	} else {
+		// If ModifiedTime or Size are set then use them
+		// without attempting another Stat operation.
+		objectInfo.ModifiedTime = dirent.modifiedTime.Truncate(time.Second)
+		objectInfo.Size = dirent.size
+		objectInfo.IsDir = dirent.isDir
+	}
+	if objectInfo.IsDir {
+		// Add os.PathSeparator suffix again for directories as
+		// filepath.Join would have removed it.
+		objectInfo.Size = 0
+		objectInfo.Name += string(os.PathSeparator)
+	} else if dirent.name == dirObjectMarker {
+		// Directory object is named after its directory.
+		objectInfo.Name = strings.TrimSuffix(objectInfo.Name, dirObjectMarker)
+	}
+	return objectInfo, nil
+}
+
 // treeWalk walks FS directory tree recursively pushing ObjectInfo into the channel as and when it encounters files.
 func treeWalk(bucketDir, prefixDir, entryPrefixMatch, marker string, recursive bool, send func(treeWalkResult) bool, count *int) bool {
 	// Example:
 	// if prefixDir="one/two/three/" and marker="four/five.txt" treeWalk is recursively
 	// called with prefixDir="one/two/three/four/" and marker="five.txt"
 
-	// Convert dirent to ObjectInfo
-	direntToObjectInfo := func(dirent fsDirent) (ObjectInfo, error) {
-		objectInfo := ObjectInfo{}
-		// Convert to full object name.
-		objectInfo.Name = filepath.Join(prefixDir, dirent.name)
-		if dirent.modifiedTime.IsZero() && dirent.size == 0 {
-			// ModifiedTime and Size are zero, Stat() and figure out
-			// the actual values that need to be set.
-			fi, err := os.Stat(filepath.Join(bucketDir, prefixDir, dirent.name))
-			if err != nil {
-				return ObjectInfo{}, err
-			}
-			// Fill size and modtime.
-			objectInfo.ModifiedTime = fi.ModTime()
-			objectInfo.Size = fi.Size()
-			objectInfo.IsDir = fi.IsDir()
-		} else {
-			// If ModifiedTime or Size are set then use them
-			// without attempting another Stat operation.
-			objectInfo.ModifiedTime = dirent.modifiedTime
-			objectInfo.Size = dirent.size
-			objectInfo.IsDir = dirent.isDir
-		}
-		if objectInfo.IsDir {
-			// Add os.PathSeparator suffix again for directories as
-			// filepath.Join would have removed it.
-			objectInfo.Size = 0
-			objectInfo.Name += string(os.PathSeparator)
-		}
-		return objectInfo, nil
-	}
-
 	var markerBase, markerDir string
 	if marker != "" {
 		// Ex: if marker="four/five.txt", markerDir="four/" markerBase="five.txt"
@@ -141,6 +144,33 @@ func treeWalk(bucketDir, prefixDir, entryPrefixMatch, marker string, recursive b
 			*count--
 			continue
 		}
+		if i == 0 && markerDir == dirent.name && !recursive && markerBase == "" {
+			// If the marker is the directory itself it was already
+			// returned as a prefix, skip it to resume with the next
+			// entry.
+			*count--
+			continue
+		}
+		if i == 0 && markerDir == dirent.name && !recursive && markerBase != "" {
+			// The marker is within the directory, it is a common
+			// prefix only if entries after the marker remain.
+			remaining := false
+			var walkErr error
+			var remainingCount int
+			treeWalk(bucketDir, filepath.Join(prefixDir, dirent.name), "", markerBase, true, func(walkResult treeWalkResult) bool {
+				remaining = true
+				walkErr = walkResult.err
+				return false
+			}, &remainingCount)
+			if walkErr != nil {
+				send(treeWalkResult{err: walkErr})
+				return false
+			}
+			if !remaining {
+				*count--
+				continue
+			}
+		}
 		if dirent.isDir && recursive {
 			// If the entry is a directory, we will need recurse into it.
 			markerArg := ""
@@ -148,6 +178,11 @@ func treeWalk(bucketDir, prefixDir, entryPrefixMatch, marker string, recursive b
 				// We need to pass "five.txt" as marker only if we are
 				// recursing into "four/"
 				markerArg = markerBase
+				if markerBase == "" {
+					// Marker is the directory object "four/" itself
+					// which was already returned.
+					markerArg = dirObjectMarker
+				}
 			}
 			*count--
 			if !treeWalk(bucketDir, filepath.Join(prefixDir, dirent.name), "", markerArg, recursive, send, count) {
@@ -155,7 +190,7 @@ func treeWalk(bucketDir, prefixDir, entryPrefixMatch, marker string, recursive b
 			}
 			continue
 		}
-		objectInfo, err := direntToObjectInfo(dirent)
+		objectInfo, err := direntToObjectInfo(bucketDir, prefixDir, dirent)
 		if err != nil {
 			send(treeWalkResult{err: err})
 			return false
@@ -168,8 +203,26 @@ func treeWalk(bucketDir, prefixDir, entryPrefixMatch, marker string, recursive b
 	return true
 }
 
+// treeWalkArgs - splits prefix into the directory to walk and the
+// prefix its entries must match, returns the marker relative to the
+// directory.
+func treeWalkArgs(prefix, marker string) (prefixDir, entryPrefixMatch, markerArg string) {
+	entryPrefixMatch = prefix
+	lastIndex := strings.LastIndex(prefix, string(os.PathSeparator))
+	if lastIndex != -1 {
+		entryPrefixMatch = prefix[lastIndex+1:]
+		prefixDir = prefix[:lastIndex+1]
+	}
+	if marker != "" && marker == prefixDir {
+		// Marker is the directory object of the prefix which was
+		// already returned.
+		marker = prefixDir + dirObjectMarker
+	}
+	return prefixDir, entryPrefixMatch, strings.TrimPrefix(marker, prefixDir)
+}
+
 // Initiate a new treeWalk in a goroutine.
-func startTreeWalk(fsPath, bucket, prefix, marker string, recursive bool) *treeWalker {
+func startTreeWalk(fsPath, bucket, prefix, marker string, recursive bool, stop <-chan struct{}) *treeWalker {
 	// Example 1
 	// If prefix is "one/two/three/" and marker is "one/two/three/four/five.txt"
 	// treeWalk is called with prefixDir="one/two/three/" and marker="four/five.txt"
@@ -181,15 +234,8 @@ func startTreeWalk(fsPath, bucket, prefix, marker string, recursive bool) *treeW
 	// and entryPrefixMatch="th"
 	ch := make(chan treeWalkResult, listObjectsLimit)
 	walkNotify := treeWalker{ch: ch}
-	entryPrefixMatch := prefix
-	prefixDir := ""
-	lastIndex := strings.LastIndex(prefix, string(os.PathSeparator))
-	if lastIndex != -1 {
-		entryPrefixMatch = prefix[lastIndex+1:]
-		prefixDir = prefix[:lastIndex+1]
-	}
+	prefixDir, entryPrefixMatch, marker := treeWalkArgs(prefix, marker)
 	count := 0
-	marker = strings.TrimPrefix(marker, prefixDir)
 	go func() {
 		defer close(ch)
 		send := func(walkResult treeWalkResult) bool {
@@ -205,6 +251,9 @@ func startTreeWalk(fsPath, bucket, prefix, marker string, recursive bool) *treeW
 			case <-timer:
 				walkNotify.timedOut = true
 				return false
+			case <-stop:
+				walkNotify.timedOut = true
+				return false
 			}
 		}
 		treeWalk(filepath.Join(fsPath, bucket), prefixDir, entryPrefixMatch, marker, recursive, send, &count)
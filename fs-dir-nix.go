@@ -65,6 +65,13 @@ func parseDirents(buf []byte) []fsDirent {
 		if name == "." || name == ".." {
 			continue
 		}
+		// Only directories and regular files are part of the object
+		// tree, filesystems not reporting the type list everything.
+		switch dirent.Type {
+		case syscall.DT_DIR, syscall.DT_REG, syscall.DT_UNKNOWN:
+		default:
+			continue
+		}
 		dirents = append(dirents, fsDirent{
 			name:  name,
 			isDir: (dirent.Type == syscall.DT_DIR),
@@ -42,6 +42,11 @@ func readDirAll(readDirPath, entryPrefixMatch string) ([]fsDirent, error) {
 			return nil, err
 		}
 		for _, fi := range fis {
+			// Only directories and regular files are part of the
+			// object tree.
+			if !fi.IsDir() && !isObjectFileMode(fi.Mode()) {
+				continue
+			}
 			dirent := fsDirent{
 				name:         fi.Name(),
 				size:         fi.Size(),
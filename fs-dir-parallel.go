@@ -0,0 +1,177 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// startParallelTreeWalk - initiate a recursive treeWalk walking up to
+// walkers top level directories of the prefix in parallel.
+//
+// Entries of a top level directory sort after the preceding and before
+// the following top level entries, the walks are therefore read back in
+// the order of the top level entries which gives the sorted order of a
+// serial walk.
+func startParallelTreeWalk(fsPath, bucket, prefix, marker string, walkers int, stop <-chan struct{}) *treeWalker {
+	ch := make(chan treeWalkResult, listObjectsLimit)
+	walkNotify := treeWalker{ch: ch}
+	prefixDir, entryPrefixMatch, marker := treeWalkArgs(prefix, marker)
+	bucketDir := filepath.Join(fsPath, bucket)
+
+	// Closed once the results are no longer read, stops the walks.
+	done := make(chan struct{})
+	// Results of each top level entry in order.
+	units := make(chan chan treeWalkResult, walkers)
+	go walkTopLevel(bucketDir, prefixDir, entryPrefixMatch, marker, walkers, units, done)
+
+	go func() {
+		defer close(ch)
+		defer close(done)
+		send := func(walkResult treeWalkResult) bool {
+			// Add the bucket.
+			walkResult.objectInfo.Bucket = bucket
+			timer := time.After(time.Second * 60)
+			select {
+			case ch <- walkResult:
+				return true
+			case <-timer:
+				walkNotify.timedOut = true
+				return false
+			case <-stop:
+				walkNotify.timedOut = true
+				return false
+			}
+		}
+		// Results are held back by one to flag the last one as the end.
+		var pending *treeWalkResult
+		for unit := range units {
+			for walkResult := range unit {
+				if walkResult.err != nil {
+					if pending != nil && !send(*pending) {
+						return
+					}
+					send(walkResult)
+					return
+				}
+				if pending != nil && !send(*pending) {
+					return
+				}
+				walkResult := walkResult
+				pending = &walkResult
+			}
+		}
+		if pending != nil {
+			pending.end = true
+			send(*pending)
+		}
+	}()
+	return &walkNotify
+}
+
+// walkTopLevel - sends a channel of results for each top level entry of
+// prefixDir to units in order, walking up to walkers directories at
+// the same time.
+func walkTopLevel(bucketDir, prefixDir, entryPrefixMatch, marker string, walkers int, units chan<- chan treeWalkResult, done <-chan struct{}) {
+	defer close(units)
+
+	// Ex: if marker="four/five.txt", markerDir="four/" markerBase="five.txt"
+	var markerBase, markerDir string
+	if marker != "" {
+		markerSplit := strings.SplitN(marker, string(os.PathSeparator), 2)
+		markerDir = markerSplit[0]
+		if len(markerSplit) == 2 {
+			markerDir += string(os.PathSeparator)
+			markerBase = markerSplit[1]
+		}
+	}
+
+	// addUnit - queues the results of a top level entry.
+	addUnit := func(unit chan treeWalkResult) bool {
+		select {
+		case units <- unit:
+			return true
+		case <-done:
+			return false
+		}
+	}
+
+	dirents, err := readDirAll(filepath.Join(bucketDir, prefixDir), entryPrefixMatch)
+	if err != nil {
+		unit := make(chan treeWalkResult, 1)
+		unit <- treeWalkResult{err: err}
+		close(unit)
+		addUnit(unit)
+		return
+	}
+	dirents = dirents[searchDirents(dirents, markerDir):]
+
+	// Bounds the number of directories walked at the same time.
+	walkerSlots := make(chan struct{}, walkers)
+	for i, dirent := range dirents {
+		if i == 0 && markerDir == dirent.name && !dirent.isDir {
+			// The marker itself was already returned.
+			continue
+		}
+		if !dirent.isDir {
+			unit := make(chan treeWalkResult, 1)
+			objectInfo, err := direntToObjectInfo(bucketDir, prefixDir, dirent)
+			unit <- treeWalkResult{objectInfo: objectInfo, err: err}
+			close(unit)
+			if !addUnit(unit) {
+				return
+			}
+			continue
+		}
+		markerArg := ""
+		if dirent.name == markerDir {
+			// Resume within the directory of the marker.
+			markerArg = markerBase
+			if markerBase == "" {
+				// Marker is the directory object which was already
+				// returned.
+				markerArg = dirObjectMarker
+			}
+		}
+		select {
+		case walkerSlots <- struct{}{}:
+		case <-done:
+			return
+		}
+		unit := make(chan treeWalkResult, listObjectsLimit)
+		go func(dirName, markerArg string) {
+			defer func() { <-walkerSlots }()
+			defer close(unit)
+			send := func(walkResult treeWalkResult) bool {
+				select {
+				case unit <- walkResult:
+					return true
+				case <-done:
+					return false
+				}
+			}
+			var count int
+			treeWalk(bucketDir, filepath.Join(prefixDir, dirName), "", markerArg, true, send, &count)
+		}(dirent.name, markerArg)
+		if !addUnit(unit) {
+			return
+		}
+	}
+}
@@ -43,6 +43,33 @@ func (e RootPathFull) Error() string {
 	return "Root path " + e.Path + " reached its minimum free disk threshold."
 }
 
+// RootPathNotFound root path does not exist
+type RootPathNotFound struct {
+	Path string
+}
+
+func (e RootPathNotFound) Error() string {
+	return "Root path " + e.Path + " not found"
+}
+
+// RootPathNotDir root path is not a directory
+type RootPathNotDir struct {
+	Path string
+}
+
+func (e RootPathNotDir) Error() string {
+	return "Root path " + e.Path + " is not a directory"
+}
+
+// FilesystemClosed filesystem was closed
+type FilesystemClosed struct {
+	Path string
+}
+
+func (e FilesystemClosed) Error() string {
+	return "Filesystem " + e.Path + " is closed"
+}
+
 // BucketNotFound bucket does not exist
 type BucketNotFound struct {
 	Bucket string
@@ -81,6 +108,16 @@ func (e ObjectExistsAsPrefix) Error() string {
 	return "Object exists on : " + e.Bucket + " as prefix " + e.Prefix
 }
 
+// PrefixExistsAsObject prefix already exists with a requested object name.
+type PrefixExistsAsObject struct {
+	Bucket string
+	Object string
+}
+
+func (e PrefixExistsAsObject) Error() string {
+	return "Prefix exists on : " + e.Bucket + " as object " + e.Object
+}
+
 // ObjectCorrupted object found to be corrupted
 type ObjectCorrupted struct {
 	Object string
@@ -126,12 +163,13 @@ func (e InvalidDisksArgument) Error() string {
 
 // BadDigest - Content-MD5 you specified did not match what we received.
 type BadDigest struct {
-	ExpectedMD5   string
-	CalculatedMD5 string
+	Algorithm  string
+	Expected   string
+	Calculated string
 }
 
 func (e BadDigest) Error() string {
-	return "Bad digest expected " + e.ExpectedMD5 + " is not valid with what we calculated " + e.CalculatedMD5
+	return "Bad " + e.Algorithm + " digest expected " + e.Expected + " is not valid with what we calculated " + e.Calculated
 }
 
 // InternalError - generic internal error
@@ -162,6 +200,22 @@ func (e BucketPolicyNotFound) Error() string {
 	return "No bucket policy found for bucket: " + e.Bucket
 }
 
+// BucketEncryptionNotFound - no bucket encryption found.
+type BucketEncryptionNotFound GenericBucketError
+
+func (e BucketEncryptionNotFound) Error() string {
+	return "No bucket encryption found for bucket: " + e.Bucket
+}
+
+// InvalidEncryptionAlgorithm - unsupported server side encryption algorithm.
+type InvalidEncryptionAlgorithm struct {
+	Algorithm string
+}
+
+func (e InvalidEncryptionAlgorithm) Error() string {
+	return "Invalid encryption algorithm: " + e.Algorithm
+}
+
 // GenericObjectError - generic object error
 type GenericObjectError struct {
 	Bucket string
@@ -260,6 +314,95 @@ func (e InvalidRange) Error() string {
 	return fmt.Sprintf("Invalid range start:%d length:%d", e.Start, e.Length)
 }
 
+// InvalidTag - object tags violate S3 tagging limits
+type InvalidTag struct {
+	Reason string
+}
+
+func (e InvalidTag) Error() string {
+	return "Invalid tag: " + e.Reason
+}
+
+// InvalidResumeToken resumable put token is unknown
+type InvalidResumeToken struct {
+	Token string
+}
+
+func (e InvalidResumeToken) Error() string {
+	return "Invalid resume token " + e.Token
+}
+
+// InvalidResumeOffset append offset does not match the data received
+// so far by a resumable put
+type InvalidResumeOffset struct {
+	Token    string
+	Offset   int64
+	Expected int64
+}
+
+func (e InvalidResumeOffset) Error() string {
+	return fmt.Sprintf("Invalid offset %d for resume token %s, expected %d", e.Offset, e.Token, e.Expected)
+}
+
+// ObjectLocked object is under retention and can not be deleted or
+// overwritten
+type ObjectLocked struct {
+	Bucket string
+	Object string
+}
+
+func (e ObjectLocked) Error() string {
+	return "Object is locked: " + e.Bucket + "#" + e.Object
+}
+
+// EntityTooLarge object is larger than the maximum object size
+type EntityTooLarge struct {
+	Size    int64
+	MaxSize int64
+}
+
+func (e EntityTooLarge) Error() string {
+	return fmt.Sprintf("Object size %d exceeds the maximum object size %d", e.Size, e.MaxSize)
+}
+
+// QuotaExceeded write would exceed the quota of a bucket
+type QuotaExceeded struct {
+	Bucket string
+	Quota  int64
+}
+
+func (e QuotaExceeded) Error() string {
+	return fmt.Sprintf("Bucket quota of %d bytes exceeded: %s", e.Quota, e.Bucket)
+}
+
+// InvalidStorageClass storage class is unknown or not supported
+type InvalidStorageClass struct {
+	StorageClass string
+}
+
+func (e InvalidStorageClass) Error() string {
+	return "Storage class not supported: " + e.StorageClass
+}
+
+// InvalidHeaderValue header value set by the client can not be sent back
+type InvalidHeaderValue struct {
+	Header string
+}
+
+func (e InvalidHeaderValue) Error() string {
+	return "Invalid value of header " + e.Header
+}
+
+// MetadataTooLarge user defined metadata exceeds the maximum size
+type MetadataTooLarge struct {
+	Size    int
+	MaxSize int
+}
+
+func (e MetadataTooLarge) Error() string {
+	return fmt.Sprintf("User defined metadata of %d bytes exceeds the maximum of %d bytes", e.Size, e.MaxSize)
+}
+
 /// Multipart related errors
 
 // InvalidUploadID invalid upload id
@@ -271,11 +414,48 @@ func (e InvalidUploadID) Error() string {
 	return "Invalid upload id " + e.UploadID
 }
 
-// InvalidPart One or more of the specified parts could not be found
-type InvalidPart struct{}
+// InvalidPart One or more of the specified parts could not be found,
+// the part was either never uploaded or uploaded with another ETag
+type InvalidPart struct {
+	PartNumber int
+	ETag       string
+	// Part number was uploaded, with UploadedETag.
+	Uploaded     bool
+	UploadedETag string
+}
 
 func (e InvalidPart) Error() string {
-	return "One or more of the specified parts could not be found"
+	if e.Uploaded {
+		return fmt.Sprintf("Part %d ETag %s does not match the ETag %s of the uploaded part", e.PartNumber, e.ETag, e.UploadedETag)
+	}
+	return fmt.Sprintf("Part %d with ETag %s was never uploaded", e.PartNumber, e.ETag)
+}
+
+// InvalidPartNumber part number is out of range
+type InvalidPartNumber struct {
+	PartNumber int
+}
+
+func (e InvalidPartNumber) Error() string {
+	return fmt.Sprintf("Invalid part number %d, should be between 1 and %d", e.PartNumber, maxPartID)
+}
+
+// InvalidPartNumberMarker part number marker is out of range
+type InvalidPartNumberMarker struct {
+	PartNumberMarker int
+}
+
+func (e InvalidPartNumberMarker) Error() string {
+	return fmt.Sprintf("Invalid part number marker %d", e.PartNumberMarker)
+}
+
+// EntityTooSmall part other than the last part is empty
+type EntityTooSmall struct {
+	PartNumber int
+}
+
+func (e EntityTooSmall) Error() string {
+	return fmt.Sprintf("Part %d is empty, only the last part may be empty", e.PartNumber)
 }
 
 // InvalidPartOrder parts are not ordered as Requested
@@ -0,0 +1,258 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// notifyConfig carries the event notification targets.
+type notifyConfig struct {
+	Webhook webhookConfig `json:"webhook"`
+	// Add new targets here.
+}
+
+// webhookConfig - target POSTing object events as JSON to endpoint,
+// authHeader is sent as the Authorization header if set.
+type webhookConfig struct {
+	Enable     bool   `json:"enable"`
+	Endpoint   string `json:"endpoint"`
+	AuthHeader string `json:"authHeader"`
+}
+
+const (
+	// Events queued for delivery, further events are dropped.
+	webhookQueueSize = 1000
+	// Delivery attempts of an event before it is dropped.
+	webhookRetryAttempts = 5
+)
+
+// webhookRetryDelay - delay before the first retry of a delivery,
+// doubled for every further retry, replaced in tests.
+var webhookRetryDelay = time.Second
+
+// webhookDrainTimeout - time events queued when stopped are delivered
+// for, the rest are dropped. Bounds how long closing waits on an
+// unreachable webhook, replaced in tests.
+var webhookDrainTimeout = 10 * time.Second
+
+// eventRecord - S3 event notification record.
+type eventRecord struct {
+	EventVersion string `json:"eventVersion"`
+	EventSource  string `json:"eventSource"`
+	AwsRegion    string `json:"awsRegion"`
+	EventTime    string `json:"eventTime"`
+	EventName    string `json:"eventName"`
+	S3           struct {
+		SchemaVersion string `json:"s3SchemaVersion"`
+		Bucket        struct {
+			Name string `json:"name"`
+			ARN  string `json:"arn"`
+		} `json:"bucket"`
+		Object struct {
+			Key  string `json:"key"`
+			Size int64  `json:"size,omitempty"`
+			ETag string `json:"eTag,omitempty"`
+		} `json:"object"`
+	} `json:"s3"`
+}
+
+// eventNotification - S3 event notification document.
+type eventNotification struct {
+	Records []eventRecord `json:"Records"`
+}
+
+// newEventNotification - returns the S3 event notification of event.
+func newEventNotification(event Event, eventTime time.Time) eventNotification {
+	// Records name events without the s3: prefix of event types.
+	record := eventRecord{
+		EventVersion: "2.0",
+		EventSource:  "aws:s3",
+		EventTime:    eventTime.UTC().Format(time.RFC3339Nano),
+		EventName:    strings.TrimPrefix(string(event.Type), "s3:"),
+	}
+	if serverConfig != nil {
+		record.AwsRegion = serverConfig.GetRegion()
+	}
+	record.S3.SchemaVersion = "1.0"
+	record.S3.Bucket.Name = event.Bucket
+	record.S3.Bucket.ARN = "arn:aws:s3:::" + event.Bucket
+	record.S3.Object.Key = event.Object
+	record.S3.Object.Size = event.Size
+	record.S3.Object.ETag = event.ETag
+	return eventNotification{Records: []eventRecord{record}}
+}
+
+// webhookTarget - delivers events asynchronously to a webhook.
+type webhookTarget struct {
+	config    webhookConfig
+	transport *http.Transport
+	client    *http.Client
+	queue     chan Event
+}
+
+// newWebhookTarget - validates the configuration, events are delivered
+// by run.
+func newWebhookTarget(config webhookConfig) (*webhookTarget, error) {
+	u, e := url.Parse(config.Endpoint)
+	if e != nil {
+		return nil, e
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("Invalid webhook endpoint %s", config.Endpoint)
+	}
+	// Dedicated transport, idle connections are closed once stopped.
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        2,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	target := &webhookTarget{
+		config:    config,
+		transport: transport,
+		client:    &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		queue:     make(chan Event, webhookQueueSize),
+	}
+	return target, nil
+}
+
+// send - queues event for delivery without blocking, the event is
+// dropped if the queue is full.
+func (t *webhookTarget) send(event Event) {
+	select {
+	case t.queue <- event:
+	default:
+		errorIf(probe.NewError(errors.New("queue full")), "Dropped event of webhook.", map[string]interface{}{"endpoint": t.config.Endpoint, "bucket": event.Bucket, "object": event.Object})
+	}
+}
+
+// run - delivers queued events in order, retrying failed deliveries
+// with backoff. Once stopped the queued events are drained.
+func (t *webhookTarget) run(stop <-chan struct{}) {
+	for {
+		// Stopping takes precedence over the queued events, which
+		// are left to the bounded drain.
+		select {
+		case <-stop:
+			t.drain(stop)
+			t.transport.CloseIdleConnections()
+			return
+		default:
+		}
+		select {
+		case event := <-t.queue:
+			t.deliver(context.Background(), event, stop)
+		case <-stop:
+			t.drain(stop)
+			t.transport.CloseIdleConnections()
+			return
+		}
+	}
+}
+
+// drain - delivers the queued events without retries until
+// webhookDrainTimeout passed, a delivery in flight is then canceled
+// and the remaining events are dropped.
+func (t *webhookTarget) drain(stop <-chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookDrainTimeout)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			if dropped := len(t.queue); dropped > 0 {
+				errorIf(probe.NewError(errors.New("drain timed out")), "Dropped events of webhook.", map[string]interface{}{"endpoint": t.config.Endpoint, "events": dropped})
+			}
+			return
+		default:
+		}
+		select {
+		case event := <-t.queue:
+			t.deliver(ctx, event, stop)
+		default:
+			return
+		}
+	}
+}
+
+// deliver - POSTs the notification of event, retrying until stopped.
+// Canceling ctx aborts a POST in flight.
+func (t *webhookTarget) deliver(ctx context.Context, event Event, stop <-chan struct{}) {
+	notification := newEventNotification(event, time.Now())
+	var e error
+	delay := webhookRetryDelay
+	for attempt := 1; attempt <= webhookRetryAttempts; attempt++ {
+		if e = t.post(ctx, notification); e == nil {
+			return
+		}
+		if attempt == webhookRetryAttempts || !sleepUntilStopped(delay, stop) {
+			break
+		}
+		delay *= 2
+	}
+	errorIf(probe.NewError(e), "Dropped event of webhook.", map[string]interface{}{"endpoint": t.config.Endpoint, "bucket": event.Bucket, "object": event.Object})
+}
+
+// sleepUntilStopped - waits for delay, returns false if stopped before.
+func sleepUntilStopped(delay time.Duration, stop <-chan struct{}) bool {
+	select {
+	case <-time.After(delay):
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// post - POSTs a notification, any status other than 2xx fails.
+func (t *webhookTarget) post(ctx context.Context, notification eventNotification) error {
+	body, e := json.Marshal(notification)
+	if e != nil {
+		return e
+	}
+	req, e := http.NewRequest("POST", t.config.Endpoint, bytes.NewReader(body))
+	if e != nil {
+		return e
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.config.AuthHeader != "" {
+		req.Header.Set("Authorization", t.config.AuthHeader)
+	}
+	resp, e := t.client.Do(req.WithContext(ctx))
+	if e != nil {
+		return e
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Webhook responded with %s", resp.Status)
+	}
+	return nil
+}
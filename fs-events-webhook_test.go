@@ -0,0 +1,159 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tests that object events are POSTed to the configured webhook,
+// retrying failed deliveries.
+func TestWebhookTarget(t *testing.T) {
+	defer func(delay time.Duration) { webhookRetryDelay = delay }(webhookRetryDelay)
+	webhookRetryDelay = time.Millisecond
+
+	requests := 0
+	notifications := make(chan eventNotification, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Fail the first delivery to exercise the retries.
+		if requests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Method != "POST" {
+			t.Errorf("Expected POST, but instead found %s", r.Method)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("Expected Authorization header \"Bearer token\", but instead found %q", auth)
+		}
+		var notification eventNotification
+		if e := json.NewDecoder(r.Body).Decode(&notification); e != nil {
+			t.Error(e)
+		}
+		notifications <- notification
+	}))
+	defer server.Close()
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Region:     "us-east-1",
+		Notify: notifyConfig{
+			Webhook: webhookConfig{Enable: true, Endpoint: server.URL, AuthHeader: "Bearer token"},
+		},
+		rwMutex: &sync.RWMutex{},
+	}
+
+	directory, e := ioutil.TempDir("", "minio-webhook-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.(*Filesystem).Close()
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.PutObject("bucket", "object", int64(len("data")), bytes.NewBufferString("data"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.DeleteObject("bucket", "object"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, eventName := range []string{"ObjectCreated:Put", "ObjectRemoved:Delete"} {
+		select {
+		case notification := <-notifications:
+			if len(notification.Records) != 1 {
+				t.Fatalf("Test %d: Expected 1 record, but instead found %d", i+1, len(notification.Records))
+			}
+			record := notification.Records[0]
+			if record.EventName != eventName {
+				t.Errorf("Test %d: Expected event %s, but instead found %s", i+1, eventName, record.EventName)
+			}
+			if record.S3.Bucket.Name != "bucket" {
+				t.Errorf("Test %d: Expected bucket \"bucket\", but instead found %q", i+1, record.S3.Bucket.Name)
+			}
+			if record.S3.Object.Key != "object" {
+				t.Errorf("Test %d: Expected object \"object\", but instead found %q", i+1, record.S3.Object.Key)
+			}
+			if record.AwsRegion != "us-east-1" {
+				t.Errorf("Test %d: Expected region \"us-east-1\", but instead found %q", i+1, record.AwsRegion)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Test %d: Timed out waiting for event %s", i+1, eventName)
+		}
+	}
+}
+
+// Tests that invalid webhook endpoints are rejected.
+func TestWebhookTargetInvalidEndpoint(t *testing.T) {
+	for i, endpoint := range []string{"", "localhost:8080", "ftp://localhost/events", "http://"} {
+		if _, e := newWebhookTarget(webhookConfig{Enable: true, Endpoint: endpoint}); e == nil {
+			t.Errorf("Test %d: Expected endpoint %q to be rejected", i+1, endpoint)
+		}
+	}
+}
+
+// Tests that draining the queue of an unreachable webhook is bounded.
+func TestWebhookTargetDrainTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { webhookDrainTimeout = timeout }(webhookDrainTimeout)
+	webhookDrainTimeout = 100 * time.Millisecond
+
+	// Webhook never responding until the test ends.
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	target, e := newWebhookTarget(webhookConfig{Enable: true, Endpoint: server.URL})
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i := 0; i < webhookQueueSize; i++ {
+		target.send(Event{Type: ObjectCreatedPut, Bucket: "bucket", Object: "object"})
+	}
+	stop := make(chan struct{})
+	close(stop)
+	done := make(chan struct{})
+	go func() {
+		target.run(stop)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected draining to stop at the deadline, but it is still running")
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// EventType - type of an object event, named like S3 event types.
+type EventType string
+
+const (
+	// ObjectCreatedPut - an object was written by a PUT.
+	ObjectCreatedPut EventType = "s3:ObjectCreated:Put"
+	// ObjectCreatedCompleteMultipartUpload - an object was written by
+	// completing a multipart upload.
+	ObjectCreatedCompleteMultipartUpload EventType = "s3:ObjectCreated:CompleteMultipartUpload"
+	// ObjectRemovedDelete - an object was deleted.
+	ObjectRemovedDelete EventType = "s3:ObjectRemoved:Delete"
+)
+
+// Event - notification of an object write or delete, size and ETag
+// are empty for removed objects.
+type Event struct {
+	Type   EventType
+	Bucket string
+	Object string
+	Size   int64
+	ETag   string
+}
+
+// EventFilter - filter of the objects of events, like the key filter
+// of S3 notifications. Empty fields match all objects.
+type EventFilter struct {
+	Prefix string
+	Suffix string
+}
+
+// match - returns whether the object of an event passes the filter.
+func (f EventFilter) match(event Event) bool {
+	return strings.HasPrefix(event.Object, f.Prefix) && strings.HasSuffix(event.Object, f.Suffix)
+}
+
+// eventHandler - registered handler along with its filter.
+type eventHandler struct {
+	filter EventFilter
+	h      func(Event)
+}
+
+// eventNotifier - dispatches events to the registered handlers.
+type eventNotifier struct {
+	mutex    *sync.RWMutex
+	handlers []eventHandler
+}
+
+// newEventNotifier - returns a notifier without handlers.
+func newEventNotifier() *eventNotifier {
+	return &eventNotifier{mutex: &sync.RWMutex{}}
+}
+
+// RegisterEventHandler - registers h to be called after every
+// successful write and delete of an object matching filter.
+//
+// Handlers are called synchronously in the call path of the operation
+// once it completed, in the order they were registered. A handler
+// therefore delays the response of the operation and should hand off
+// any slow work. Every handler with a matching filter is called, also
+// if the filters of several handlers overlap.
+func (fs *Filesystem) RegisterEventHandler(filter EventFilter, h func(Event)) {
+	fs.events.mutex.Lock()
+	defer fs.events.mutex.Unlock()
+	fs.events.handlers = append(fs.events.handlers, eventHandler{filter: filter, h: h})
+}
+
+// notify - calls the registered handlers matching event.
+func (n *eventNotifier) notify(event Event) {
+	n.mutex.RLock()
+	handlers := n.handlers
+	n.mutex.RUnlock()
+	for _, handler := range handlers {
+		if handler.filter.match(event) {
+			handler.h(event)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// HealthStatus - health of the filesystem backend.
+type HealthStatus struct {
+	// Root path exists, is a directory and the filesystem is not closed.
+	RootPathValid bool
+	// Root path is writable.
+	Writable bool
+	// Root path has more free space than the minimum free disk threshold.
+	DiskFree bool
+	// Server config is loaded with valid credentials.
+	ConfigValid bool
+	// Human readable summary of the checks.
+	Summary string
+}
+
+// IsHealthy - returns true if all health checks passed.
+func (h HealthStatus) IsHealthy() bool {
+	return h.RootPathValid && h.Writable && h.DiskFree && h.ConfigValid
+}
+
+// HealthCheck - verify the root path is writable, has enough free
+// space and the server config is loaded with valid credentials. A
+// missing root path or a closed filesystem is reported as unhealthy.
+func (fs Filesystem) HealthCheck() (HealthStatus, *probe.Error) {
+	status := HealthStatus{}
+	var problems []string
+
+	// Verify root path, without one the other checks of it fail too.
+	if e := fs.checkRootPath(); e != nil {
+		switch e.(type) {
+		case RootPathNotFound, RootPathNotDir, FilesystemClosed:
+			problems = append(problems, e.Error())
+		default:
+			return HealthStatus{}, probe.NewError(e)
+		}
+	} else {
+		status.RootPathValid = true
+	}
+
+	if status.RootPathValid {
+		// Verify root path is writable.
+		tmpFile, e := ioutil.TempFile(fs.path, "$tmpobject.health")
+		if e != nil {
+			problems = append(problems, "root path "+fs.path+" is not writable")
+		} else {
+			tmpFile.Close()
+			if e = os.Remove(tmpFile.Name()); e != nil {
+				return HealthStatus{}, probe.NewError(e)
+			}
+			status.Writable = true
+		}
+
+		// Verify free disk space.
+		if e = fs.checkDiskFree(); e != nil {
+			switch e.(type) {
+			case RootPathFull, RootPathNotFound, RootPathNotDir, FilesystemClosed:
+				problems = append(problems, e.Error())
+			default:
+				return HealthStatus{}, probe.NewError(e)
+			}
+		} else {
+			status.DiskFree = true
+		}
+	}
+
+	// Verify server config and its credentials.
+	if serverConfig == nil {
+		problems = append(problems, "server config is not loaded")
+	} else {
+		cred := serverConfig.GetCredential()
+		if !isValidAccessKey.MatchString(cred.AccessKeyID) || !isValidSecretKey.MatchString(cred.SecretAccessKey) {
+			problems = append(problems, "server config has invalid credentials")
+		} else {
+			status.ConfigValid = true
+		}
+	}
+
+	if len(problems) == 0 {
+		status.Summary = "OK"
+	} else {
+		status.Summary = strings.Join(problems, ", ")
+	}
+	return status, nil
+}
@@ -0,0 +1,138 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+// Testing HealthCheck().
+func TestHealthCheck(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-health-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Use a server config with valid credentials.
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Healthy backend.
+	status, err := fs.(*Filesystem).HealthCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.IsHealthy() {
+		t.Fatalf("Expected backend to be healthy, but instead found \"%s\"", status.Summary)
+	}
+
+	// Simulate a full disk.
+	fs.(*Filesystem).minFreeDisk = 100
+	status, err = fs.(*Filesystem).HealthCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.DiskFree || !status.Writable || status.IsHealthy() {
+		t.Fatalf("Expected backend to report a full disk, but instead found \"%s\"", status.Summary)
+	}
+	fs.(*Filesystem).minFreeDisk = 5
+
+	// Unwritable root path.
+	if e = os.Chmod(directory, 0500); e != nil {
+		t.Fatal(e)
+	}
+	defer os.Chmod(directory, 0700)
+	status, err = fs.(*Filesystem).HealthCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Writable || !status.DiskFree || status.IsHealthy() {
+		t.Fatalf("Expected backend to report an unwritable root path, but instead found \"%s\"", status.Summary)
+	}
+	if e = os.Chmod(directory, 0700); e != nil {
+		t.Fatal(e)
+	}
+
+	// Missing server config.
+	serverConfig = nil
+	status, err = fs.(*Filesystem).HealthCheck()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ConfigValid || status.IsHealthy() {
+		t.Fatalf("Expected backend to report missing server config, but instead found \"%s\"", status.Summary)
+	}
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	// Missing root path.
+	if e = os.RemoveAll(directory); e != nil {
+		t.Fatal(e)
+	}
+	status, err = fs.(*Filesystem).HealthCheck()
+	if err != nil {
+		t.Fatalf("Expected missing root path to be unhealthy, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if status.RootPathValid || status.Writable || status.DiskFree || status.IsHealthy() {
+		t.Fatalf("Expected backend to report a missing root path, but instead found \"%s\"", status.Summary)
+	}
+
+	// Root path not a directory.
+	if e = ioutil.WriteFile(directory, nil, 0600); e != nil {
+		t.Fatal(e)
+	}
+	status, err = fs.(*Filesystem).HealthCheck()
+	if err != nil {
+		t.Fatalf("Expected root path not a directory to be unhealthy, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if status.RootPathValid || status.IsHealthy() {
+		t.Fatalf("Expected backend to report root path not a directory, but instead found \"%s\"", status.Summary)
+	}
+	if e = os.Remove(directory); e != nil {
+		t.Fatal(e)
+	}
+	if e = os.Mkdir(directory, 0700); e != nil {
+		t.Fatal(e)
+	}
+
+	// Closed filesystem.
+	if e = fs.(*Filesystem).Close(); e != nil {
+		t.Fatal(e)
+	}
+	status, err = fs.(*Filesystem).HealthCheck()
+	if err != nil {
+		t.Fatalf("Expected closed filesystem to be unhealthy, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if status.RootPathValid || status.IsHealthy() {
+		t.Fatalf("Expected backend to report a closed filesystem, but instead found \"%s\"", status.Summary)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// ImportDirectory - puts each file under localDir as an object of
+// bucket, keyed by its path relative to localDir. Symlinks and hidden
+// files or directories are skipped, md5sum and content type are
+// computed as for any other put. Returns the number of objects
+// imported, including those imported before a failure.
+func (fs Filesystem) ImportDirectory(bucket, localDir string) (int, *probe.Error) {
+	if _, e := fs.checkBucketArg(bucket); e != nil {
+		return 0, probe.NewError(e).Trace(bucket, localDir)
+	}
+
+	imported := 0
+	var err *probe.Error
+	e := filepath.Walk(localDir, func(path string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+		if path != localDir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Walk does not follow symlinks, anything else is not a file.
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, e := filepath.Rel(localDir, path)
+		if e != nil {
+			return e
+		}
+		file, e := os.Open(path)
+		if e != nil {
+			return e
+		}
+		defer file.Close()
+		if _, err = fs.PutObject(bucket, filepath.ToSlash(relPath), info.Size(), file, nil); err != nil {
+			return err.ToGoError()
+		}
+		imported++
+		return nil
+	})
+	if err != nil {
+		return imported, err.Trace(bucket, localDir)
+	}
+	if e != nil {
+		return imported, probe.NewError(e).Trace(bucket, localDir)
+	}
+	return imported, nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Testing ImportDirectory() puts files keyed by their relative path.
+func TestImportDirectory(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-import-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+	localDir, e := ioutil.TempDir("", "minio-import-source-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(localDir)
+
+	files := map[string]string{
+		"jack.txt":            "abcd",
+		"Asia/India/jill.txt": "efgh",
+		"Asia/.hidden":        "ijkl",
+		".git/config":         "mnop",
+	}
+	for name, content := range files {
+		path := filepath.Join(localDir, filepath.FromSlash(name))
+		if e = os.MkdirAll(filepath.Dir(path), 0700); e != nil {
+			t.Fatal(e)
+		}
+		if e = ioutil.WriteFile(path, []byte(content), 0600); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if e = os.Symlink(filepath.Join(localDir, "jack.txt"), filepath.Join(localDir, "Asia", "link.txt")); e != nil {
+		t.Fatal(e)
+	}
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if _, err = fs.ImportDirectory("bucket", localDir); err == nil {
+		t.Fatal("Expected import into a missing bucket to fail, but it passed instead")
+	}
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := fs.ImportDirectory("bucket", localDir)
+	if err != nil {
+		t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if imported != 2 {
+		t.Fatalf("Expected 2 objects imported, but instead found %d", imported)
+	}
+
+	result, err := fs.ListObjects("bucket", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, objInfo := range result.Objects {
+		keys = append(keys, objInfo.Name)
+	}
+	if fmt.Sprint(keys) != fmt.Sprint([]string{"Asia/India/jill.txt", "jack.txt"}) {
+		t.Fatalf("Expected imported objects [Asia/India/jill.txt jack.txt], but instead found %v", keys)
+	}
+	objInfo, err := fs.GetObjectInfo("bucket", "jack.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.MD5Sum != "e2fc714c4727ee9395f324cd2e7f331f" || objInfo.ContentType != "text/plain" {
+		t.Errorf("Expected md5sum and content type to be computed, but instead found %s, %s", objInfo.MD5Sum, objInfo.ContentType)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// listCacheKey - parameters of a cached ListObjects call.
+type listCacheKey struct {
+	bucket    string
+	prefix    string
+	marker    string
+	delimiter string
+	maxKeys   int
+}
+
+// listCacheEntry - cached ListObjects result.
+type listCacheEntry struct {
+	key    listCacheKey
+	result ListObjectsInfo
+}
+
+// listCache - bounded LRU cache of ListObjects results, entries of a
+// bucket are invalidated whenever an object of the bucket changes. A
+// nil cache caches nothing.
+type listCache struct {
+	mutex      *sync.Mutex
+	size       int
+	entries    *list.List
+	index      map[listCacheKey]*list.Element
+	generation uint64
+}
+
+// newListCache - returns a cache of up to size results.
+func newListCache(size int) *listCache {
+	return &listCache{
+		mutex:   &sync.Mutex{},
+		size:    size,
+		entries: list.New(),
+		index:   make(map[listCacheKey]*list.Element),
+	}
+}
+
+// copyListObjectsInfo - returns a result not sharing its slices, cached
+// results must not be modified by callers.
+func copyListObjectsInfo(result ListObjectsInfo) ListObjectsInfo {
+	if result.Objects != nil {
+		result.Objects = append([]ObjectInfo(nil), result.Objects...)
+	}
+	if result.Prefixes != nil {
+		result.Prefixes = append([]string(nil), result.Prefixes...)
+	}
+	return result
+}
+
+// get - returns the cached result of a listing and the generation
+// to store a result listed on a miss with.
+func (c *listCache) get(key listCacheKey) (ListObjectsInfo, uint64, bool) {
+	if c == nil {
+		return ListObjectsInfo{}, 0, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.index[key]
+	if !ok {
+		return ListObjectsInfo{}, c.generation, false
+	}
+	c.entries.MoveToFront(element)
+	return copyListObjectsInfo(element.Value.(*listCacheEntry).result), c.generation, true
+}
+
+// put - caches the result of a listing, unless the cache was
+// invalidated since the listing started at generation.
+func (c *listCache) put(key listCacheKey, generation uint64, result ListObjectsInfo) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if element, ok := c.index[key]; ok {
+		element.Value.(*listCacheEntry).result = copyListObjectsInfo(result)
+		c.entries.MoveToFront(element)
+		return
+	}
+	c.index[key] = c.entries.PushFront(&listCacheEntry{key: key, result: copyListObjectsInfo(result)})
+	// Evict the least recently used results.
+	for c.entries.Len() > c.size {
+		element := c.entries.Back()
+		c.entries.Remove(element)
+		delete(c.index, element.Value.(*listCacheEntry).key)
+	}
+}
+
+// invalidate - removes all cached results of a bucket, bucket names
+// compare case insensitively like the names of bucket directories.
+func (c *listCache) invalidate(bucket string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	for key, element := range c.index {
+		if strings.EqualFold(key.bucket, bucket) {
+			c.entries.Remove(element)
+			delete(c.index, key)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "sync"
+
+// nsParam - namespace key of a lock.
+type nsParam struct {
+	bucket string
+	object string
+}
+
+// nsLock - lock of a single namespace key along with the number of
+// callers holding or waiting on it.
+type nsLock struct {
+	*sync.Mutex
+	ref uint
+}
+
+// nsLockMap - keyed lock map, serializes writers of the same object
+// while writers of different objects proceed in parallel.
+type nsLockMap struct {
+	lockMap map[nsParam]*nsLock
+	mutex   *sync.Mutex
+}
+
+// newNSLock - instantiate a new keyed lock map.
+func newNSLock() *nsLockMap {
+	return &nsLockMap{
+		lockMap: make(map[nsParam]*nsLock),
+		mutex:   &sync.Mutex{},
+	}
+}
+
+// lock - lock the object, blocks until the lock is available.
+func (n *nsLockMap) lock(bucket, object string) {
+	param := nsParam{bucket, object}
+	n.mutex.Lock()
+	nsLk, found := n.lockMap[param]
+	if !found {
+		nsLk = &nsLock{
+			Mutex: &sync.Mutex{},
+		}
+		n.lockMap[param] = nsLk
+	}
+	nsLk.ref++
+	n.mutex.Unlock()
+
+	// Lock outside the map mutex, otherwise writers of other objects
+	// would wait as well.
+	nsLk.Lock()
+}
+
+// unlock - unlock the object, the lock is removed from the map once
+// no caller holds or waits on it.
+func (n *nsLockMap) unlock(bucket, object string) {
+	param := nsParam{bucket, object}
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if nsLk, found := n.lockMap[param]; found {
+		nsLk.Unlock()
+		nsLk.ref--
+		if nsLk.ref == 0 {
+			delete(n.lockMap, param)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// OpMetrics - operation counters of the filesystem backend.
+type OpMetrics struct {
+	PutObject               uint64
+	GetObject               uint64
+	ListObjects             uint64
+	CompleteMultipartUpload uint64
+	// Failed operations of all the above.
+	Errors uint64
+}
+
+// opMetrics - operation counters updated atomically, 64-bit fields
+// come first to keep them aligned on 32-bit platforms.
+type opMetrics struct {
+	putObject               uint64
+	getObject               uint64
+	listObjects             uint64
+	completeMultipartUpload uint64
+	errors                  uint64
+}
+
+// record - count an operation along with its failure.
+func (m *opMetrics) record(counter *uint64, err *probe.Error) {
+	atomic.AddUint64(counter, 1)
+	if err != nil {
+		atomic.AddUint64(&m.errors, 1)
+	}
+}
+
+// Metrics - returns operation counters since the filesystem was created.
+func (fs Filesystem) Metrics() OpMetrics {
+	return OpMetrics{
+		PutObject:               atomic.LoadUint64(&fs.metrics.putObject),
+		GetObject:               atomic.LoadUint64(&fs.metrics.getObject),
+		ListObjects:             atomic.LoadUint64(&fs.metrics.listObjects),
+		CompleteMultipartUpload: atomic.LoadUint64(&fs.metrics.completeMultipartUpload),
+		Errors:                  atomic.LoadUint64(&fs.metrics.errors),
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// repairFromMirror - replaces a corrupted object by its copy under the
+// mirror path, laid out like the data path, once the copy matches the
+// checksum the object was written with. The object keeps its
+// modification time. Returns the repaired object opened for reading.
+func (fs Filesystem) repairFromMirror(bucket, object string, checksum checksumInfo) (*os.File, error) {
+	fs.nsLock.lock(bucket, object)
+	defer fs.nsLock.unlock(bucket, object)
+
+	// The object may have been replaced since it was verified.
+	objMeta, e := fs.readObjectMetadata(bucket, object)
+	if e != nil {
+		return nil, e
+	}
+	if objMeta.Checksum != checksum {
+		return nil, ObjectCorrupted{Object: object}
+	}
+
+	objectPath := filepath.Join(fs.path, bucket, objectStorageName(object))
+	objSt, e := os.Stat(objectPath)
+	if e != nil {
+		return nil, e
+	}
+	mirrorPath := filepath.Join(fs.mirrorPath, bucket, objectStorageName(object))
+	mirrorFile, e := os.Open(mirrorPath)
+	if e != nil {
+		return nil, e
+	}
+	defer mirrorFile.Close()
+
+	hasher, e := newChecksumHasher(checksum.Algorithm)
+	if e != nil {
+		return nil, e
+	}
+	safeFile, e := fs.createSafeFile(objectPath)
+	if e != nil {
+		return nil, e
+	}
+	if _, e = io.Copy(io.MultiWriter(safeFile, hasher), mirrorFile); e != nil {
+		safeFile.CloseAndRemove()
+		return nil, e
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != checksum.Hash {
+		// The mirror copy is corrupted as well.
+		safeFile.CloseAndRemove()
+		return nil, ObjectCorrupted{Object: object}
+	}
+	if e = fs.closeSafeFile(safeFile, objectPath); e != nil {
+		return nil, e
+	}
+	if e = os.Chtimes(objectPath, objSt.ModTime(), objSt.ModTime()); e != nil {
+		return nil, e
+	}
+	errorIf(probe.NewError(ObjectCorrupted{Object: object}).Trace(bucket, object), "Repaired corrupted object from mirror.", map[string]interface{}{"mirror": mirrorPath})
+	return os.Open(objectPath)
+}
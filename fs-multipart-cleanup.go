@@ -0,0 +1,90 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"time"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// defaultMultipartExpiry - age of uploads reclaimed by the periodic
+// cleanup unless configured.
+const defaultMultipartExpiry = 7 * 24 * time.Hour
+
+// CleanupStaleUploads - aborts the uploads of all buckets initiated
+// longer than expiry ago, returns the number of uploads aborted.
+func (fs Filesystem) CleanupStaleUploads(expiry time.Duration) (int, *probe.Error) {
+	buckets, err := fs.ListBuckets()
+	if err != nil {
+		return 0, err.Trace()
+	}
+	staleBefore := time.Now().Add(-expiry)
+	aborted := 0
+	for _, bucket := range buckets {
+		// Collect the uploads first, aborting changes the listing.
+		var stale []uploadMetadata
+		keyMarker, uploadIDMarker := "", ""
+		for {
+			result, err := fs.ListMultipartUploads(bucket.Name, "", keyMarker, uploadIDMarker, "", maxUploadsList)
+			if err != nil {
+				return aborted, err.Trace(bucket.Name)
+			}
+			for _, upload := range result.Uploads {
+				if upload.Initiated.Before(staleBefore) {
+					stale = append(stale, upload)
+				}
+			}
+			if !result.IsTruncated {
+				break
+			}
+			keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+		}
+		for _, upload := range stale {
+			if err := fs.AbortMultipartUpload(bucket.Name, upload.Object, upload.UploadID); err != nil {
+				// Completed or aborted since listed.
+				if _, ok := err.ToGoError().(InvalidUploadID); ok {
+					continue
+				}
+				return aborted, err.Trace(bucket.Name, upload.Object, upload.UploadID)
+			}
+			aborted++
+		}
+	}
+	return aborted, nil
+}
+
+// runUploadCleanup - returns a background worker reclaiming stale
+// uploads every interval until stopped.
+func (fs Filesystem) runUploadCleanup(interval, expiry time.Duration) func(stop <-chan struct{}) {
+	return func(stop <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+			aborted, err := fs.CleanupStaleUploads(expiry)
+			errorIf(err.Trace(), "Unable to reclaim stale multipart uploads.", map[string]interface{}{"aborted": aborted})
+			if aborted > 0 {
+				log.WithFields(map[string]interface{}{"aborted": aborted, "expiry": expiry.String()}).Info("Reclaimed stale multipart uploads.")
+			}
+		}
+	}
+}
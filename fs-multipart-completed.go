@@ -0,0 +1,101 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// completedUploadTTL - how long the result of completing a multipart
+// upload is kept for clients retrying the complete request.
+var completedUploadTTL = 10 * time.Minute
+
+// completedUploadKey - multipart upload which was completed.
+type completedUploadKey struct {
+	bucket   string
+	object   string
+	uploadID string
+}
+
+// completedUpload - parts an upload was completed with and the
+// resulting object.
+type completedUpload struct {
+	parts   []completePart
+	objInfo ObjectInfo
+	expires time.Time
+}
+
+// completedUploadCache - recently completed multipart uploads, so that
+// a retried complete request returns the same object instead of
+// failing with an unknown upload ID.
+type completedUploadCache struct {
+	mutex   *sync.Mutex
+	entries map[completedUploadKey]completedUpload
+}
+
+// newCompletedUploadCache - returns an empty cache.
+func newCompletedUploadCache() *completedUploadCache {
+	return &completedUploadCache{
+		mutex:   &sync.Mutex{},
+		entries: make(map[completedUploadKey]completedUpload),
+	}
+}
+
+// add - records the object an upload was completed to, expired
+// entries are dropped along the way.
+func (c *completedUploadCache) add(key completedUploadKey, parts []completePart, objInfo ObjectInfo) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = completedUpload{
+		parts:   append([]completePart(nil), parts...),
+		objInfo: objInfo,
+		expires: now.Add(completedUploadTTL),
+	}
+}
+
+// get - returns the object an upload was recently completed to with
+// the same parts.
+func (c *completedUploadCache) get(key completedUploadKey, parts []completePart) (ObjectInfo, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) || !isSameCompleteParts(entry.parts, parts) {
+		return ObjectInfo{}, false
+	}
+	return entry.objInfo, true
+}
+
+// isSameCompleteParts - parts are equal ignoring the quotes of ETags.
+func isSameCompleteParts(a, b []completePart) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].PartNumber != b[i].PartNumber || strings.Trim(a[i].ETag, "\"") != strings.Trim(b[i].ETag, "\"") {
+			return false
+		}
+	}
+	return true
+}
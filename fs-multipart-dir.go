@@ -130,7 +130,26 @@ func filteredReaddirnames(dirname string, filter func(string) bool) ([]string, e
 	return result, nil
 }
 
-func scanMultipartDir(bucketDir, prefixPath, markerPath, uploadIDMarker string, recursive bool) multipartObjectInfoChannel {
+// hasUploadIDFile - returns true if an uploadid file is found under
+// dirname.
+func hasUploadIDFile(dirname string) bool {
+	entries, err := filteredReaddir(dirname,
+		func(entry DirEntry) bool {
+			return entry.IsDir() || (entry.IsRegular() && strings.HasSuffix(entry.Name, uploadIDSuffix))
+		},
+		true)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsRegular() || hasUploadIDFile(entry.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func scanMultipartDir(bucketDir, prefixPath, markerPath, uploadIDMarker string, recursive bool, stop <-chan struct{}) multipartObjectInfoChannel {
 	objectInfoCh := make(chan multipartObjectInfo, listObjectsLimit)
 	timeoutCh := make(chan struct{}, 1)
 
@@ -205,7 +224,8 @@ func scanMultipartDir(bucketDir, prefixPath, markerPath, uploadIDMarker string,
 		defer close(timeoutCh)
 
 		// send function - returns true if ObjectInfo is sent
-		// within (time.Second * 15) else false on timeout.
+		// within (time.Second * 15) else false on timeout or when
+		// stopped.
 		send := func(oi multipartObjectInfo) bool {
 			timer := time.After(time.Second * 15)
 			select {
@@ -214,6 +234,9 @@ func scanMultipartDir(bucketDir, prefixPath, markerPath, uploadIDMarker string,
 			case <-timer:
 				timeoutCh <- struct{}{}
 				return false
+			case <-stop:
+				timeoutCh <- struct{}{}
+				return false
 			}
 		}
 
@@ -250,7 +273,7 @@ func scanMultipartDir(bucketDir, prefixPath, markerPath, uploadIDMarker string,
 					objInfo := multipartObjectInfo{
 						Name:         name,
 						UploadID:     uploadID,
-						ModifiedTime: entry.ModTime,
+						ModifiedTime: entry.ModTime.Truncate(time.Second),
 					}
 
 					if !send(objInfo) {
@@ -272,14 +295,18 @@ func scanMultipartDir(bucketDir, prefixPath, markerPath, uploadIDMarker string,
 
 				subDirFound := false
 				uploadIDEntries := []DirEntry{}
-				// If subentries has a directory, then current entry needs to be sent
+				// If subentries has a directory with uploads, then current
+				// entry needs to be sent. Directories left without uploads
+				// are no prefix of any upload.
 				for _, subentry := range subentries {
 					if subentry.IsDir() {
-						subDirFound = true
-
 						if recursive {
+							subDirFound = true
 							break
 						}
+						if !subDirFound {
+							subDirFound = hasUploadIDFile(subentry.Name)
+						}
 					}
 
 					if !recursive && subentry.IsRegular() {
@@ -287,10 +314,10 @@ func scanMultipartDir(bucketDir, prefixPath, markerPath, uploadIDMarker string,
 					}
 				}
 
-				if subDirFound || len(subentries) == 0 {
+				if subDirFound {
 					objInfo := multipartObjectInfo{
 						Name:         strings.Replace(entry.Name, bucketDir, "", 1),
-						ModifiedTime: entry.ModTime,
+						ModifiedTime: entry.ModTime.Truncate(time.Second),
 						IsDir:        true,
 					}
 
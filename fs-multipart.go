@@ -17,16 +17,23 @@
 package main
 
 import (
+	"context"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/minio/minio/pkg/disk"
 	"github.com/minio/minio/pkg/mimedb"
@@ -35,9 +42,24 @@ import (
 	"github.com/skyrings/skyring-common/tools/uuid"
 )
 
+// Default metadata directory under the root path, holds multipart
+// uploads and object metadata.
 const configDir = ".minio"
 const uploadIDSuffix = ".uploadid"
 
+// Directory under the metadata path of a bucket holding its multipart
+// uploads, apart from bucket metadata files and object sidecars. Not a
+// valid object name component, so no upload collides with them.
+const multipartsDir = "$multiparts"
+
+// multipartDir - directory holding the uploads of object.
+func (fs Filesystem) multipartDir(bucket, object string) string {
+	return filepath.Join(fs.metaPath, bucket, multipartsDir, object)
+}
+
+// Maximum part number of a multipart upload.
+const maxPartID = 10000
+
 func removeFileTree(fileName string, level string) error {
 	if e := os.Remove(fileName); e != nil {
 		return e
@@ -58,41 +80,315 @@ func removeFileTree(fileName string, level string) error {
 	return nil
 }
 
-// Takes an input stream and safely writes to disk, additionally
-// verifies checksum.
-func safeWriteFile(fileName string, data io.Reader, size int64, md5sum string) error {
-	safeFile, e := safe.CreateFileWithSuffix(fileName, "-")
+// fsyncFile - flushes file contents to disk, replaced in tests to
+// verify durable writes.
+var fsyncFile = func(file *os.File) error {
+	return file.Sync()
+}
+
+// fsyncDir - flushes directory entries to disk so that a file renamed
+// into dirPath survives a power failure.
+func fsyncDir(dirPath string) error {
+	// Directories cannot be flushed on windows.
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	dir, e := os.Open(dirPath)
 	if e != nil {
 		return e
 	}
+	defer dir.Close()
+	return fsyncFile(dir)
+}
 
-	md5Hasher := md5.New()
-	multiWriter := io.MultiWriter(md5Hasher, safeFile)
+// renameFile - renames a file, replaced in tests to simulate transient
+// errors of networked filesystems.
+var renameFile = os.Rename
+
+// retryBaseDelay - delay before the first retry of a transient error,
+// doubled for every further retry.
+var retryBaseDelay = 10 * time.Millisecond
+
+// isTransientError - returns true for filesystem errors which may
+// succeed when retried, such as EAGAIN or EBUSY on networked
+// filesystems. Errors like ENOENT or EACCES are permanent.
+func isTransientError(e error) bool {
+	switch err := e.(type) {
+	case *os.PathError:
+		e = err.Err
+	case *os.LinkError:
+		e = err.Err
+	case *os.SyscallError:
+		e = err.Err
+	}
+	return e == syscall.EAGAIN || e == syscall.EBUSY
+}
+
+// isCrossDeviceError - returns true for renames failing with EXDEV,
+// source and destination are on different filesystems.
+func isCrossDeviceError(e error) bool {
+	if err, ok := e.(*os.LinkError); ok {
+		e = err.Err
+	}
+	return e == syscall.EXDEV
+}
+
+// retryTransient - runs op, retrying transient errors with exponential
+// backoff up to the configured number of attempts.
+func (fs Filesystem) retryTransient(op func() error) error {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		e := op()
+		if e == nil || attempt >= fs.retryAttempts || !isTransientError(e) {
+			return e
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// createSafeFile - creates a temporary file for safe writes to
+// fileName, in the configured temporary directory or next to fileName.
+func (fs Filesystem) createSafeFile(fileName string) (*safe.File, error) {
+	if fs.tempPath != "" {
+		return safe.CreateFileInDirMode(fileName, fs.tempPath, "$tmpobject", fs.dirPerm(0700), fs.filePerm(0600))
+	}
+	return safe.CreateFileWithPrefixMode(fileName, "$tmpobject", fs.dirPerm(0700), fs.filePerm(0600))
+}
+
+// checkTempDir - verifies temporary files of the configured temporary
+// directory can be renamed into the data path. A directory on another
+// filesystem is not used with a warning, temporary files are then
+// created next to their target.
+func (fs *Filesystem) checkTempDir() error {
+	if e := os.MkdirAll(fs.tempPath, fs.dirPerm(0700)); e != nil {
+		return e
+	}
+	probeFile, e := ioutil.TempFile(fs.tempPath, "$tmpobject")
+	if e != nil {
+		return e
+	}
+	probeFile.Close()
+	target := filepath.Join(fs.path, filepath.Base(probeFile.Name()))
+	if e = renameFile(probeFile.Name(), target); e != nil {
+		os.Remove(probeFile.Name())
+		if !isCrossDeviceError(e) {
+			return e
+		}
+		log.WithFields(map[string]interface{}{"tempDir": fs.tempPath}).Warn("Temporary directory is on a different filesystem than the data path, creating temporary files next to their target.")
+		fs.tempPath = ""
+		return nil
+	}
+	return os.Remove(target)
+}
+
+// closeSafeFile - closes safeFile and renames it to fileName, with
+// fsync enabled data is flushed to disk before the rename and the
+// parent directory after it. The temporary file is removed on errors.
+func (fs Filesystem) closeSafeFile(safeFile *safe.File, fileName string) error {
+	if fs.fsync {
+		if e := fsyncFile(safeFile.File); e != nil {
+			safeFile.CloseAndRemove()
+			return e
+		}
+	}
+	if e := safeFile.File.Close(); e != nil {
+		// Closing failed, CloseAndRemove would fail the same way.
+		os.Remove(safeFile.Name())
+		return e
+	}
+	// Atomically rename into place, copied from another filesystem.
+	if e := fs.moveFile(safeFile.Name(), fileName); e != nil {
+		os.Remove(safeFile.Name())
+		return e
+	}
+	if fs.fsync {
+		if e := fsyncDir(filepath.Dir(fileName)); e != nil {
+			return e
+		}
+	}
+	return fs.verifyVisible(fileName)
+}
+
+// moveFile - renames src to dst, falling back to copying when they
+// are on different filesystems, such as with the metadata path on a
+// separate disk. The copy is written next to dst and renamed into
+// place, so dst is never seen partially written.
+func (fs Filesystem) moveFile(src, dst string) error {
+	e := fs.retryTransient(func() error { return renameFile(src, dst) })
+	if !isCrossDeviceError(e) {
+		return e
+	}
+	srcFile, e := os.Open(src)
+	if e != nil {
+		return e
+	}
+	defer srcFile.Close()
+	safeFile, e := safe.CreateFileWithPrefixMode(dst, "$tmpobject", fs.dirPerm(0700), fs.filePerm(0600))
+	if e != nil {
+		return e
+	}
+	if _, e = io.Copy(safeFile, srcFile); e != nil {
+		safeFile.CloseAndRemove()
+		return e
+	}
+	if e = fs.closeSafeFile(safeFile, dst); e != nil {
+		return e
+	}
+	return os.Remove(src)
+}
+
+// lstatFile - returns file info without following symlinks, replaced
+// in tests to simulate lagging directory entries.
+var lstatFile = os.Lstat
+
+// verifyVisible - with write verification enabled, confirms a file
+// renamed into place is visible to subsequent reads. Directory entries
+// may lag on some networked filesystems.
+func (fs Filesystem) verifyVisible(fileName string) error {
+	if !fs.verifyWrites {
+		return nil
+	}
+	if _, e := lstatFile(fileName); e != nil {
+		if os.IsNotExist(e) {
+			return errWriteNotVisible
+		}
+		return e
+	}
+	return nil
+}
+
+// diskCheckInterval - number of bytes written between free disk space
+// checks of streaming writes.
+var diskCheckInterval int64 = 64 * 1024 * 1024
+
+// diskCheckWriter - writer verifying free disk space every
+// diskCheckInterval bytes, fails with RootPathFull once the disk has
+// less free space than minFreeDisk.
+type diskCheckWriter struct {
+	writer    io.Writer
+	fs        Filesystem
+	unchecked int64
+}
+
+func (w *diskCheckWriter) Write(p []byte) (int, error) {
+	n, e := w.writer.Write(p)
+	if e != nil {
+		return n, e
+	}
+	w.unchecked += int64(n)
+	if w.unchecked >= diskCheckInterval {
+		w.unchecked = 0
+		if e = w.fs.checkDiskFree(); e != nil {
+			return n, e
+		}
+	}
+	return n, nil
+}
+
+// maxSizeReader - reader failing with EntityTooLarge once more than
+// maxSize bytes are read, for writes of unknown size.
+type maxSizeReader struct {
+	reader  io.Reader
+	maxSize int64
+	read    int64
+}
+
+func (r *maxSizeReader) Read(p []byte) (int, error) {
+	n, e := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.maxSize {
+		return n, EntityTooLarge{Size: r.read, MaxSize: r.maxSize}
+	}
+	return n, e
+}
+
+// Takes an input stream and safely writes to disk, additionally
+// verifies the data against each check. Returns the number of bytes
+// written and the hex encoded md5sum of the written data. A size of
+// zero or less is unknown and data is written until EOF, an empty
+// stream writes an empty file with the md5sum of empty content.
+func (fs Filesystem) safeWriteFile(fileName string, data io.Reader, size int64, checks []dataCheck) (int64, string, error) {
+	verifier, e := newDataVerifier(checks)
+	if e != nil {
+		return 0, "", e
+	}
+	safeFile, e := fs.createSafeFile(fileName)
+	if e != nil {
+		return 0, "", e
+	}
+
+	multiWriter := io.MultiWriter(verifier, safeFile)
+	var written int64
 	if size > 0 {
-		if _, e = io.CopyN(multiWriter, data, size); e != nil {
+		if written, e = io.CopyN(multiWriter, data, size); e != nil {
 			// Closes the file safely and removes it in a single atomic operation.
 			safeFile.CloseAndRemove()
-			return e
+			if e == io.EOF {
+				// Reader was drained before size bytes were read.
+				e = io.ErrUnexpectedEOF
+			}
+			return written, "", e
 		}
 	} else {
-		if _, e = io.Copy(multiWriter, data); e != nil {
+		// Size is unknown for streaming writes, verify free disk space
+		// periodically so the disk is not filled past minFreeDisk.
+		diskWriter := &diskCheckWriter{writer: multiWriter, fs: fs}
+		if written, e = io.Copy(diskWriter, data); e != nil {
 			// Closes the file safely and removes it in a single atomic operation.
 			safeFile.CloseAndRemove()
-			return e
+			return written, "", e
 		}
 	}
 
-	dataMd5sum := hex.EncodeToString(md5Hasher.Sum(nil))
-	if md5sum != "" && !isMD5SumEqual(md5sum, dataMd5sum) {
+	if e = verifier.verify(); e != nil {
 		// Closes the file safely and removes it in a single atomic operation.
 		safeFile.CloseAndRemove()
-		return BadDigest{ExpectedMD5: md5sum, CalculatedMD5: dataMd5sum}
+		return written, "", e
 	}
 
 	// Safely close the file and atomically renames it the actual filePath.
-	safeFile.Close()
+	if e = fs.closeSafeFile(safeFile, fileName); e != nil {
+		return written, "", e
+	}
 
 	// Safely wrote the file.
+	return written, verifier.md5Hex(), nil
+}
+
+// errPartChecksumMismatch - part data does not match its saved checksum.
+var errPartChecksumMismatch = errors.New("Part checksum mismatch")
+
+// copyPartVerified - copies a part file to writer while verifying its
+// data against the checksum saved by PutObjectPart, parts without a
+// saved checksum are copied unverified.
+func copyPartVerified(writer io.Writer, partFileName string) error {
+	partChecksum := checksumInfo{}
+	if e := readMetaFile(partFileName+partChecksumSuffix, &partChecksum); e != nil && !os.IsNotExist(e) {
+		return e
+	}
+
+	partFile, e := os.Open(partFileName)
+	if e != nil {
+		return e
+	}
+	defer partFile.Close()
+
+	if partChecksum.Algorithm == "" {
+		_, e = io.Copy(writer, partFile)
+		return e
+	}
+
+	hasher, e := newChecksumHasher(partChecksum.Algorithm)
+	if e != nil {
+		return e
+	}
+	if _, e = io.Copy(io.MultiWriter(writer, hasher), partFile); e != nil {
+		return e
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != partChecksum.Hash {
+		return errPartChecksumMismatch
+	}
 	return nil
 }
 
@@ -109,6 +405,28 @@ func isFileExist(filename string) (bool, error) {
 	return fi.Mode().IsRegular(), nil
 }
 
+// invalidPart - returns the error for a part of an upload not found
+// with md5sum, telling whether the part number was uploaded with
+// another md5sum.
+func invalidPart(metaObjectDir, uploadID string, partNumber int, md5sum string) InvalidPart {
+	err := InvalidPart{PartNumber: partNumber, ETag: md5sum}
+	partPrefix := fmt.Sprintf("%s.%d.", uploadID, partNumber)
+	entries, e := ioutil.ReadDir(metaObjectDir)
+	if e != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Mode().IsRegular() || !strings.HasPrefix(name, partPrefix) || strings.HasSuffix(name, partChecksumSuffix) {
+			continue
+		}
+		err.Uploaded = true
+		err.UploadedETag = strings.TrimPrefix(name, partPrefix)
+		break
+	}
+	return err
+}
+
 // Create an s3 compatible MD5sum for complete multipart transaction.
 func makeS3MD5(md5Strs ...string) (string, *probe.Error) {
 	var finalMD5Bytes []byte
@@ -125,49 +443,95 @@ func makeS3MD5(md5Strs ...string) (string, *probe.Error) {
 	return s3MD5, nil
 }
 
-func (fs Filesystem) newUploadID(bucket, object string) (string, error) {
-	metaObjectDir := filepath.Join(fs.path, configDir, bucket, object)
+// maxUploadIDAttempts - number of attempts to generate an unused upload id.
+const maxUploadIDAttempts = 10
+
+// newUUID - generates upload ids, replaced in tests.
+var newUUID = func() (string, error) {
+	uuid, e := uuid.New()
+	if e != nil {
+		return "", e
+	}
+	return uuid.String(), nil
+}
+
+// fsUploadMetadata - metadata of an upload applied to the object on
+// completion, persisted in its uploadIDFile.
+type fsUploadMetadata struct {
+	StorageClass string `json:"storageClass,omitempty"`
+	contentHeaders
+	UserDefined map[string]string `json:"userDefined,omitempty"`
+}
+
+// readUploadMetadata - reads the metadata of an upload, uploads
+// initiated before metadata was persisted have an empty uploadIDFile.
+func (fs Filesystem) readUploadMetadata(bucket, object, uploadID string) (fsUploadMetadata, error) {
+	uploadMeta := fsUploadMetadata{}
+	metaBytes, e := ioutil.ReadFile(filepath.Join(fs.multipartDir(bucket, object), uploadID+uploadIDSuffix))
+	if e != nil {
+		return fsUploadMetadata{}, e
+	}
+	if len(metaBytes) == 0 {
+		return uploadMeta, nil
+	}
+	if e = json.Unmarshal(metaBytes, &uploadMeta); e != nil {
+		return fsUploadMetadata{}, e
+	}
+	return uploadMeta, nil
+}
+
+func (fs Filesystem) newUploadID(bucket, object string, uploadMeta fsUploadMetadata) (string, error) {
+	metaObjectDir := fs.multipartDir(bucket, object)
+	metaBytes, e := json.Marshal(uploadMeta)
+	if e != nil {
+		return "", e
+	}
 
 	// create metaObjectDir if not exist
 	if status, e := isDirExist(metaObjectDir); e != nil {
 		return "", e
 	} else if !status {
-		if e := os.MkdirAll(metaObjectDir, 0755); e != nil {
+		if e := os.MkdirAll(metaObjectDir, fs.dirPerm(0755)); e != nil {
 			return "", e
 		}
 	}
 
-	for {
-		uuid, e := uuid.New()
+	for i := 0; i < maxUploadIDAttempts; i++ {
+		uploadID, e := newUUID()
 		if e != nil {
 			return "", e
 		}
 
-		uploadID := uuid.String()
+		// Create uploadIDFile exclusively to reserve the name.
 		uploadIDFile := filepath.Join(metaObjectDir, uploadID+uploadIDSuffix)
-		if _, e := os.Lstat(uploadIDFile); e != nil {
-			if !os.IsNotExist(e) {
-				return "", e
-			}
-
-			// uploadIDFile doesn't exist, so create empty file to reserve the name
-			if e := ioutil.WriteFile(uploadIDFile, []byte{}, 0644); e != nil {
-				return "", e
+		file, e := os.OpenFile(uploadIDFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fs.filePerm(0644))
+		if e != nil {
+			if os.IsExist(e) {
+				// uploadIDFile already exists, loop again to try
+				// with different uuid generated.
+				continue
 			}
-
-			return uploadID, nil
+			return "", e
+		}
+		if _, e = file.Write(metaBytes); e != nil {
+			file.Close()
+			os.Remove(uploadIDFile)
+			return "", e
+		}
+		if e = file.Close(); e != nil {
+			return "", e
 		}
-		// uploadIDFile already exists.
-		// loop again to try with different uuid generated.
+		return uploadID, nil
 	}
+	return "", errUploadIDAttemptsExhausted
 }
 
 func (fs Filesystem) isUploadIDExist(bucket, object, uploadID string) (bool, error) {
-	return isFileExist(filepath.Join(fs.path, configDir, bucket, object, uploadID+uploadIDSuffix))
+	return isFileExist(filepath.Join(fs.multipartDir(bucket, object), uploadID+uploadIDSuffix))
 }
 
 func (fs Filesystem) cleanupUploadID(bucket, object, uploadID string) error {
-	metaObjectDir := filepath.Join(fs.path, configDir, bucket, object)
+	metaObjectDir := fs.multipartDir(bucket, object)
 	uploadIDPrefix := uploadID + "."
 
 	names, e := filteredReaddirnames(metaObjectDir,
@@ -192,7 +556,7 @@ func (fs Filesystem) cleanupUploadID(bucket, object, uploadID string) error {
 		//return InternalError{Err: err}
 		return e
 	} else if status {
-		if e := removeFileTree(metaObjectDir, filepath.Join(fs.path, configDir, bucket)); e != nil {
+		if e := removeFileTree(metaObjectDir, filepath.Join(fs.metaPath, bucket)); e != nil {
 			// TODO: add log than returning error
 			//return InternalError{Err: err}
 			return e
@@ -206,6 +570,9 @@ func (fs Filesystem) checkBucketArg(bucket string) (string, error) {
 	if !IsValidBucketName(bucket) {
 		return "", BucketNameInvalid{Bucket: bucket}
 	}
+	if e := fs.checkRootPath(); e != nil {
+		return "", e
+	}
 
 	bucket = getActualBucketname(fs.path, bucket)
 	if status, e := isDirExist(filepath.Join(fs.path, bucket)); e != nil {
@@ -218,8 +585,36 @@ func (fs Filesystem) checkBucketArg(bucket string) (string, error) {
 	return bucket, nil
 }
 
+// getDiskInfo - returns disk usage of a path, replaced in tests to
+// simulate a disk filling up.
+var getDiskInfo = disk.GetInfo
+
+// checkRootPath - verifies the data path is still a directory, it may
+// be removed or replaced underneath a running server.
+func (fs Filesystem) checkRootPath() error {
+	select {
+	case <-fs.closed:
+		return FilesystemClosed{Path: fs.path}
+	default:
+	}
+	st, e := os.Stat(fs.path)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return RootPathNotFound{Path: fs.path}
+		}
+		return e
+	}
+	if !st.IsDir() {
+		return RootPathNotDir{Path: fs.path}
+	}
+	return nil
+}
+
 func (fs Filesystem) checkDiskFree() error {
-	di, e := disk.GetInfo(fs.path)
+	if e := fs.checkRootPath(); e != nil {
+		return e
+	}
+	di, e := getDiskInfo(fs.path)
 	if e != nil {
 		return e
 	}
@@ -248,19 +643,48 @@ func (fs Filesystem) checkMultipartArgs(bucket, object string) (string, error) {
 
 // NewMultipartUpload - initiate a new multipart session
 func (fs Filesystem) NewMultipartUpload(bucket, object string) (string, *probe.Error) {
+	return fs.NewMultipartUploadWithMetadata(bucket, object, nil)
+}
+
+// NewMultipartUploadWithMetadata - initiate a new multipart session
+// with metadata of the object, supports "storageClass", the content
+// headers and user defined metadata.
+func (fs Filesystem) NewMultipartUploadWithMetadata(bucket, object string, metadata map[string]string) (string, *probe.Error) {
 	if bucketDirName, e := fs.checkMultipartArgs(bucket, object); e == nil {
 		bucket = bucketDirName
 	} else {
-		return "", probe.NewError(e)
+		return "", probe.NewError(e).Trace(bucket, object)
+	}
+	// Internal names would not be listed once completed.
+	if isInternalName(object) {
+		return "", probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+
+	storageClass, e := checkStorageClass(metadata["storageClass"])
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object)
+	}
+	headers, e := parseContentHeaders(metadata)
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object)
+	}
+	userDefined, e := parseUserMetadata(metadata)
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object)
 	}
 
 	if e := fs.checkDiskFree(); e != nil {
-		return "", probe.NewError(e)
+		return "", probe.NewError(e).Trace(bucket, object)
 	}
 
-	uploadID, e := fs.newUploadID(bucket, object)
+	uploadMeta := fsUploadMetadata{
+		StorageClass:   storageClass,
+		contentHeaders: headers,
+		UserDefined:    userDefined,
+	}
+	uploadID, e := fs.newUploadID(bucket, object, uploadMeta)
 	if e != nil {
-		return "", probe.NewError(e)
+		return "", probe.NewError(e).Trace(bucket, object)
 	}
 
 	return uploadID, nil
@@ -268,106 +692,302 @@ func (fs Filesystem) NewMultipartUpload(bucket, object string) (string, *probe.E
 
 // PutObjectPart - create a part in a multipart session
 func (fs Filesystem) PutObjectPart(bucket, object, uploadID string, partNumber int, size int64, data io.Reader, md5Hex string) (string, *probe.Error) {
+	return fs.PutObjectPartWithMetadata(bucket, object, uploadID, partNumber, size, data, map[string]string{"md5Sum": md5Hex})
+}
+
+// PutObjectPartWithMetadata - create a part in a multipart session
+// verified against the checksums declared in metadata, the hex encoded
+// "md5Sum", "crc32c" and "sha256".
+func (fs Filesystem) PutObjectPartWithMetadata(bucket, object, uploadID string, partNumber int, size int64, data io.Reader, metadata map[string]string) (string, *probe.Error) {
+	md5Hex := metadata["md5Sum"]
 	if bucketDirName, e := fs.checkMultipartArgs(bucket, object); e == nil {
 		bucket = bucketDirName
 	} else {
-		return "", probe.NewError(e)
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
 	}
 
 	if status, e := fs.isUploadIDExist(bucket, object, uploadID); e != nil {
-		//return "", probe.NewError(InternalError{Err: err})
-		return "", probe.NewError(e)
+		//return "", probe.NewError(InternalError{Err: err}).Trace(bucket, object, uploadID)
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
 	} else if !status {
-		return "", probe.NewError(InvalidUploadID{UploadID: uploadID})
+		return "", probe.NewError(InvalidUploadID{UploadID: uploadID}).Trace(bucket, object, uploadID)
 	}
 
-	// Part id cannot be negative.
-	if partNumber <= 0 {
-		return "", probe.NewError(errors.New("invalid part id, cannot be zero or less than zero"))
+	// Part id must be within 1 and maxPartID inclusive.
+	if partNumber <= 0 || partNumber > maxPartID {
+		return "", probe.NewError(InvalidPartNumber{PartNumber: partNumber}).Trace(bucket, object, uploadID)
 	}
 
-	if partNumber > 10000 {
-		return "", probe.NewError(errors.New("invalid part id, should be not more than 10000"))
+	if e := fs.checkDiskFree(); e != nil {
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
 	}
 
-	if e := fs.checkDiskFree(); e != nil {
-		return "", probe.NewError(e)
+	checksumHasher, e := newChecksumHasher(fs.checksumAlgo)
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
 	}
 
+	// Limit concurrent part writes, waiting for a free slot.
+	fs.partWrites <- struct{}{}
+	defer func() { <-fs.partWrites }()
+
 	partSuffix := fmt.Sprintf("%s.%d.%s", uploadID, partNumber, md5Hex)
-	partFilePath := filepath.Join(fs.path, configDir, bucket, object, partSuffix)
-	if e := safeWriteFile(partFilePath, data, size, md5Hex); e != nil {
-		return "", probe.NewError(e)
+	partFilePath := filepath.Join(fs.multipartDir(bucket, object), partSuffix)
+	if _, _, e := fs.safeWriteFile(partFilePath, io.TeeReader(fs.limitReader(data), checksumHasher), size, transferChecks(metadata)); e != nil {
+		// Client sent fewer bytes than it declared.
+		if e == io.ErrUnexpectedEOF {
+			return "", probe.NewError(IncompleteBody{Bucket: bucket, Object: object}).Trace(bucket, object, uploadID)
+		}
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+
+	// Save part checksum, verified while completing the upload.
+	partChecksum := checksumInfo{
+		Algorithm: fs.checksumAlgo,
+		Hash:      hex.EncodeToString(checksumHasher.Sum(nil)),
+	}
+	if e := fs.writeMetaFile(partFilePath+partChecksumSuffix, partChecksum); e != nil {
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
 	}
 	return md5Hex, nil
 }
 
+// CopyObjectPart - copy length bytes of a source object from
+// startOffset into a part of a multipart upload, a length of zero or
+// less copies until the end of the source object. Returns the md5sum
+// of the part.
+func (fs Filesystem) CopyObjectPart(destBucket, destObject, uploadID string, partNumber int, srcBucket, srcObject string, startOffset, length int64) (string, *probe.Error) {
+	// Part id must be within 1 and maxPartID inclusive.
+	if partNumber <= 0 || partNumber > maxPartID {
+		return "", probe.NewError(InvalidPartNumber{PartNumber: partNumber}).Trace(destBucket, destObject, uploadID)
+	}
+
+	srcInfo, err := fs.GetObjectInfo(srcBucket, srcObject)
+	if err != nil {
+		return "", err.Trace(srcBucket, srcObject)
+	}
+	if length <= 0 {
+		length = srcInfo.Size - startOffset
+	}
+	if startOffset < 0 || length < 0 || startOffset+length > srcInfo.Size {
+		return "", probe.NewError(InvalidRange{Start: startOffset, Length: length}).Trace(destBucket, destObject, uploadID)
+	}
+
+	// Serialize with writers of the source object so it does not
+	// change between computing the md5sum and copying.
+	srcBucket = getActualBucketname(fs.path, srcBucket)
+	fs.nsLock.lock(srcBucket, srcObject)
+	defer fs.nsLock.unlock(srcBucket, srcObject)
+
+	file, _, err := fs.getObject(srcBucket, srcObject, startOffset)
+	if err != nil {
+		return "", err.Trace(srcBucket, srcObject)
+	}
+	defer file.Close()
+
+	// Part files are named after their md5sum, compute it first.
+	md5Hasher := md5.New()
+	if _, e := io.CopyN(md5Hasher, file, length); e != nil {
+		return "", probe.NewError(e).Trace(destBucket, destObject, uploadID)
+	}
+	md5Hex := hex.EncodeToString(md5Hasher.Sum(nil))
+
+	// Rewind to copy the range.
+	if _, e := file.Seek(startOffset, os.SEEK_SET); e != nil {
+		return "", probe.NewError(e).Trace(destBucket, destObject, uploadID)
+	}
+	return fs.PutObjectPart(destBucket, destObject, uploadID, partNumber, length, io.LimitReader(file, length), md5Hex)
+}
+
 // AbortMultipartUpload - abort an incomplete multipart session
 func (fs Filesystem) AbortMultipartUpload(bucket, object, uploadID string) *probe.Error {
 	if bucketDirName, e := fs.checkMultipartArgs(bucket, object); e == nil {
 		bucket = bucketDirName
 	} else {
-		return probe.NewError(e)
+		return probe.NewError(e).Trace(bucket, object, uploadID)
 	}
 
 	if status, e := fs.isUploadIDExist(bucket, object, uploadID); e != nil {
-		//return probe.NewError(InternalError{Err: err})
-		return probe.NewError(e)
+		//return probe.NewError(InternalError{Err: err}).Trace(bucket, object, uploadID)
+		return probe.NewError(e).Trace(bucket, object, uploadID)
 	} else if !status {
-		return probe.NewError(InvalidUploadID{UploadID: uploadID})
+		return probe.NewError(InvalidUploadID{UploadID: uploadID}).Trace(bucket, object, uploadID)
 	}
 
 	if e := fs.cleanupUploadID(bucket, object, uploadID); e != nil {
-		return probe.NewError(e)
+		return probe.NewError(e).Trace(bucket, object, uploadID)
 	}
 
 	return nil
 }
 
+// byPartNumber - sort parts by part number.
+type byPartNumber []partInfo
+
+func (p byPartNumber) Len() int           { return len(p) }
+func (p byPartNumber) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p byPartNumber) Less(i, j int) bool { return p[i].PartNumber < p[j].PartNumber }
+
+// isPartOrderValid - parts must be in strictly ascending part number
+// order, without duplicates.
+func isPartOrderValid(parts []completePart) bool {
+	for i := 1; i < len(parts); i++ {
+		if parts[i].PartNumber <= parts[i-1].PartNumber {
+			return false
+		}
+	}
+	return true
+}
+
 // CompleteMultipartUpload - complete a multipart upload and persist the data
 func (fs Filesystem) CompleteMultipartUpload(bucket, object, uploadID string, parts []completePart) (ObjectInfo, *probe.Error) {
+	return fs.CompleteMultipartUploadContext(context.Background(), bucket, object, uploadID, parts)
+}
+
+// CompleteMultipartUploadContext - complete a multipart upload and
+// persist the data, aborts between parts once ctx is done leaving the
+// upload intact.
+func (fs Filesystem) CompleteMultipartUploadContext(ctx context.Context, bucket, object, uploadID string, parts []completePart) (ObjectInfo, *probe.Error) {
+	objInfo, err := fs.completeMultipartUpload(ctx, bucket, object, uploadID, parts)
+	fs.metrics.record(&fs.metrics.completeMultipartUpload, err)
+	// The object may have changed even if completing failed part way.
+	fs.listCache.invalidate(bucket)
+	if err == nil {
+		fs.events.notify(Event{Type: ObjectCreatedCompleteMultipartUpload, Bucket: bucket, Object: object, Size: objInfo.Size, ETag: objInfo.MD5Sum})
+	}
+	return objInfo, err
+}
+
+// completeMultipartUpload - see CompleteMultipartUploadContext.
+func (fs Filesystem) completeMultipartUpload(ctx context.Context, bucket, object, uploadID string, parts []completePart) (ObjectInfo, *probe.Error) {
 	if bucketDirName, e := fs.checkMultipartArgs(bucket, object); e == nil {
 		bucket = bucketDirName
 	} else {
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+
+	if !isPartOrderValid(parts) {
+		return ObjectInfo{}, probe.NewError(InvalidPartOrder{UploadID: uploadID}).Trace(bucket, object, uploadID)
+	}
+
+	// Retries of an upload completed recently return the same object.
+	completedKey := completedUploadKey{bucket: bucket, object: object, uploadID: uploadID}
+	if objInfo, ok := fs.completedUploads.get(completedKey, parts); ok {
+		return objInfo, nil
 	}
 
 	if status, e := fs.isUploadIDExist(bucket, object, uploadID); e != nil {
-		//return probe.NewError(InternalError{Err: err})
-		return ObjectInfo{}, probe.NewError(e)
+		//return probe.NewError(InternalError{Err: err}).Trace(bucket, object, uploadID)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
 	} else if !status {
-		return ObjectInfo{}, probe.NewError(InvalidUploadID{UploadID: uploadID})
+		return ObjectInfo{}, probe.NewError(InvalidUploadID{UploadID: uploadID}).Trace(bucket, object, uploadID)
 	}
 
 	if e := fs.checkDiskFree(); e != nil {
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
 	}
 
-	metaObjectDir := filepath.Join(fs.path, configDir, bucket, object)
+	// Serialize writers of the same object.
+	fs.nsLock.lock(bucket, object)
+	defer fs.nsLock.unlock(bucket, object)
+
+	// A concurrent retry may have completed the upload meanwhile.
+	if objInfo, ok := fs.completedUploads.get(completedKey, parts); ok {
+		return objInfo, nil
+	}
+
+	// Locked objects can not be overwritten.
+	if e := fs.checkObjectLock(bucket, object); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	oldBlob := fs.objectBlob(bucket, object)
+
+	// Verify object name does not collide with existing prefixes.
+	if err := checkObjectCollision(filepath.Join(fs.path, bucket), bucket, objectStorageName(object)); err != nil {
+		return ObjectInfo{}, err.Trace(bucket, object)
+	}
+
+	uploadMeta, e := fs.readUploadMetadata(bucket, object, uploadID)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	storageClass, e := checkStorageClass(uploadMeta.StorageClass)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+
+	metaObjectDir := fs.multipartDir(bucket, object)
 
 	var md5Sums []string
-	for _, part := range parts {
+	var layout []PartLayout
+	var totalSize int64
+	for i, part := range parts {
 		partNumber := part.PartNumber
 		md5sum := strings.Trim(part.ETag, "\"")
 		partFile := filepath.Join(metaObjectDir, uploadID+"."+strconv.Itoa(partNumber)+"."+md5sum)
-		if status, err := isFileExist(partFile); err != nil {
-			return ObjectInfo{}, probe.NewError(err)
-		} else if !status {
-			return ObjectInfo{}, probe.NewError(InvalidPart{})
+		st, e := os.Lstat(partFile)
+		if e != nil {
+			if os.IsNotExist(e) {
+				return ObjectInfo{}, probe.NewError(invalidPart(metaObjectDir, uploadID, partNumber, md5sum)).Trace(bucket, object, uploadID)
+			}
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+		}
+		if !st.Mode().IsRegular() {
+			return ObjectInfo{}, probe.NewError(InvalidPart{PartNumber: partNumber, ETag: md5sum}).Trace(bucket, object, uploadID)
+		}
+		// Only the last part may be empty.
+		if st.Size() == 0 && i < len(parts)-1 {
+			return ObjectInfo{}, probe.NewError(EntityTooSmall{PartNumber: partNumber}).Trace(bucket, object, uploadID)
+		}
+		layout = append(layout, PartLayout{PartNumber: partNumber, ETag: md5sum, Offset: totalSize, Size: st.Size()})
+		totalSize += st.Size()
+		if fs.maxObjectSize > 0 && totalSize > fs.maxObjectSize {
+			return ObjectInfo{}, probe.NewError(EntityTooLarge{Size: totalSize, MaxSize: fs.maxObjectSize}).Trace(bucket, object, uploadID)
 		}
 		md5Sums = append(md5Sums, md5sum)
 	}
 
-	// Save the s3 md5.
+	// Verify the bucket quota before concatenating the parts.
+	objectPath := filepath.Join(fs.path, bucket, objectStorageName(object))
+	oldSize, replaced := existingObjectSize(objectPath)
+	release, e := fs.reserveBucketQuota(bucket, oldSize, totalSize)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	// Released once the usage of the bucket includes the upload.
+	defer release()
+
+	// Save the s3 md5, optionally without the part count for a single
+	// part which is then the md5sum of the object.
 	s3MD5, err := makeS3MD5(md5Sums...)
 	if err != nil {
 		return ObjectInfo{}, err.Trace(md5Sums...)
 	}
+	if fs.plainSinglePartETag && len(md5Sums) == 1 {
+		s3MD5 = md5Sums[0]
+	}
 
-	completeObjectFile := filepath.Join(metaObjectDir, uploadID+".complete.")
-	safeFile, e := safe.CreateFileWithSuffix(completeObjectFile, "-")
+	// Checksum of the complete object for internal integrity verification.
+	checksumHasher, e := newChecksumHasher(fs.checksumAlgo)
 	if e != nil {
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	// Content sha256sum of deduplicated objects.
+	hashWriter := io.Writer(checksumHasher)
+	dedupHasher := sha256.New()
+	if fs.dedup {
+		hashWriter = io.MultiWriter(checksumHasher, dedupHasher)
+	}
+
+	// Concatenate the parts in the temporary directory if configured.
+	completeDir := metaObjectDir
+	if fs.tempPath != "" {
+		completeDir = fs.tempPath
+	}
+	completeObjectFile := filepath.Join(completeDir, uploadID+".complete.")
+	safeFile, e := safe.CreateFileWithSuffixMode(completeObjectFile, "-", fs.dirPerm(0700), fs.filePerm(0600))
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
 	}
 	for _, part := range parts {
 		partNumber := part.PartNumber
@@ -375,56 +995,102 @@ func (fs Filesystem) CompleteMultipartUpload(bucket, object, uploadID string, pa
 		md5sum := strings.TrimPrefix(part.ETag, "\"")
 		md5sum = strings.TrimSuffix(md5sum, "\"")
 		partFileStr := filepath.Join(metaObjectDir, fmt.Sprintf("%s.%d.%s", uploadID, partNumber, md5sum))
-		var partFile *os.File
-		partFile, e = os.Open(partFileStr)
-		if e != nil {
-			// Remove the complete file safely.
+		if e = ctx.Err(); e != nil {
+			// Request cancelled, remove the complete file safely.
 			safeFile.CloseAndRemove()
-			return ObjectInfo{}, probe.NewError(e)
-		} else if _, e = io.Copy(safeFile, partFile); e != nil {
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+		}
+		if e = copyPartVerified(io.MultiWriter(safeFile, hashWriter), partFileStr); e != nil {
 			// Remove the complete file safely.
 			safeFile.CloseAndRemove()
-			return ObjectInfo{}, probe.NewError(e)
+			if e == errPartChecksumMismatch {
+				return ObjectInfo{}, probe.NewError(ObjectCorrupted{Object: object}).Trace(bucket, object, uploadID)
+			}
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
 		}
-		partFile.Close() // Close part file after successful copy.
 	}
 	// All parts concatenated, safely close the temp file.
-	safeFile.Close()
+	if fs.fsync {
+		if e = fsyncFile(safeFile.File); e != nil {
+			safeFile.CloseAndRemove()
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+		}
+	}
+	if e = safeFile.Close(); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
 
 	// Stat to gather fresh stat info.
 	objSt, e := os.Stat(completeObjectFile)
 	if e != nil {
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
 	}
 
-	bucketPath := filepath.Join(fs.path, bucket)
-	objectPath := filepath.Join(bucketPath, object)
-	if e = os.MkdirAll(filepath.Dir(objectPath), 0755); e != nil {
+	if e = os.MkdirAll(filepath.Dir(objectPath), fs.dirPerm(0755)); e != nil {
 		os.Remove(completeObjectFile)
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
 	}
-	if e = os.Rename(completeObjectFile, objectPath); e != nil {
+	if e = fs.moveFile(completeObjectFile, objectPath); e != nil {
 		os.Remove(completeObjectFile)
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	if fs.fsync {
+		if e = fsyncDir(filepath.Dir(objectPath)); e != nil {
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+		}
+	}
+	if e = fs.verifyVisible(objectPath); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	if replaced {
+		fs.addBucketUsage(bucket, object, 0, objSt.Size()-oldSize)
+	} else {
+		fs.addBucketUsage(bucket, object, 1, objSt.Size())
 	}
+	blob := fs.dedupWrittenObject(objectPath, dedupHasher, oldBlob)
 
 	fs.cleanupUploadID(bucket, object, uploadID) // TODO: handle and log the error
 
+	// Persist object metadata.
+	objMeta := fsObjectMetadata{
+		MD5Sum:         s3MD5,
+		StorageClass:   storageClass,
+		contentHeaders: uploadMeta.contentHeaders,
+		UserDefined:    uploadMeta.UserDefined,
+		Checksum: checksumInfo{
+			Algorithm: fs.checksumAlgo,
+			Hash:      hex.EncodeToString(checksumHasher.Sum(nil)),
+		},
+		Blob: blob,
+	}
+	if e = fs.writeObjectMetadata(bucket, object, objMeta); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	// Persist the part ranges, for copying the original parts.
+	if e = fs.writeMetaFile(fs.objectPartsPath(bucket, object), layout); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+
 	contentType := "application/octet-stream"
-	if objectExt := filepath.Ext(objectPath); objectExt != "" {
+	if objectExt := filepath.Ext(object); objectExt != "" {
 		if content, ok := mimedb.DB[strings.ToLower(strings.TrimPrefix(objectExt, "."))]; ok {
 			contentType = content.ContentType
 		}
 	}
 
 	newObject := ObjectInfo{
-		Bucket:       bucket,
-		Name:         object,
-		ModifiedTime: objSt.ModTime(),
-		Size:         objSt.Size(),
-		ContentType:  contentType,
-		MD5Sum:       s3MD5,
+		Bucket:         bucket,
+		Name:           object,
+		ModifiedTime:   objSt.ModTime().Truncate(time.Second),
+		Size:           objSt.Size(),
+		ContentType:    contentType,
+		MD5Sum:         s3MD5,
+		Owner:          getOwner(),
+		StorageClass:   storageClass,
+		contentHeaders: uploadMeta.contentHeaders,
+		UserDefined:    uploadMeta.UserDefined,
 	}
+	fs.completedUploads.add(completedKey, parts, newObject)
 
 	return newObject, nil
 }
@@ -472,35 +1138,35 @@ func (fs Filesystem) ListMultipartUploads(bucket, objectPrefix, keyMarker, uploa
 	if bucketDirName, err := fs.checkBucketArg(bucket); err == nil {
 		bucket = bucketDirName
 	} else {
-		return result, probe.NewError(err)
+		return result, probe.NewError(err).Trace(bucket, objectPrefix)
 	}
 
 	if !IsValidObjectPrefix(objectPrefix) {
-		return result, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: objectPrefix})
+		return result, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: objectPrefix}).Trace(bucket, objectPrefix)
 	}
 
 	prefixPath := filepath.FromSlash(objectPrefix)
 
 	// Verify if delimiter is anything other than '/', which we do not support.
 	if delimiter != "" && delimiter != "/" {
-		return result, probe.NewError(fmt.Errorf("delimiter '%s' is not supported", delimiter))
+		return result, probe.NewError(fmt.Errorf("delimiter '%s' is not supported", delimiter)).Trace(bucket, objectPrefix)
 	}
 
 	if keyMarker != "" && !strings.HasPrefix(keyMarker, objectPrefix) {
-		return result, probe.NewError(fmt.Errorf("Invalid combination of marker '%s' and prefix '%s'", keyMarker, objectPrefix))
+		return result, probe.NewError(fmt.Errorf("Invalid combination of marker '%s' and prefix '%s'", keyMarker, objectPrefix)).Trace(bucket, objectPrefix)
 	}
 
 	markerPath := filepath.FromSlash(keyMarker)
 	if uploadIDMarker != "" {
 		if strings.HasSuffix(markerPath, string(os.PathSeparator)) {
-			return result, probe.NewError(fmt.Errorf("Invalid combination of uploadID marker '%s' and marker '%s'", uploadIDMarker, keyMarker))
+			return result, probe.NewError(fmt.Errorf("Invalid combination of uploadID marker '%s' and marker '%s'", uploadIDMarker, keyMarker)).Trace(bucket, objectPrefix)
 		}
 		id, e := uuid.Parse(uploadIDMarker)
 		if e != nil {
-			return result, probe.NewError(e)
+			return result, probe.NewError(e).Trace(bucket, objectPrefix)
 		}
 		if id.IsZero() {
-			return result, probe.NewError(fmt.Errorf("Invalid upload ID marker %s", uploadIDMarker))
+			return result, probe.NewError(fmt.Errorf("Invalid upload ID marker %s", uploadIDMarker)).Trace(bucket, objectPrefix)
 		}
 	}
 
@@ -521,7 +1187,7 @@ func (fs Filesystem) ListMultipartUploads(bucket, objectPrefix, keyMarker, uploa
 		recursive = false
 	}
 
-	bucketDir := filepath.Join(fs.path, configDir, bucket)
+	bucketDir := fs.multipartDir(bucket, "")
 	// Lookup of if listMultipartObjectChannel is available for given
 	// parameters, else create a new one.
 	multipartObjectInfoCh := fs.lookupListMultipartObjectCh(listMultipartObjectParams{
@@ -532,12 +1198,14 @@ func (fs Filesystem) ListMultipartUploads(bucket, objectPrefix, keyMarker, uploa
 		uploadIDMarker: uploadIDMarker,
 	})
 	if multipartObjectInfoCh == nil {
-		ch := scanMultipartDir(bucketDir, objectPrefix, keyMarker, uploadIDMarker, recursive)
+		ch := scanMultipartDir(bucketDir, objectPrefix, keyMarker, uploadIDMarker, recursive, fs.closed)
 		multipartObjectInfoCh = &ch
 	}
 
 	nextKeyMarker := ""
 	nextUploadIDMarker := ""
+	// Common prefixes already in the result.
+	commonPrefixes := make(map[string]struct{})
 	for i := 0; i < maxUploads; {
 		multipartObjInfo, ok := multipartObjectInfoCh.Read()
 		if !ok {
@@ -549,11 +1217,10 @@ func (fs Filesystem) ListMultipartUploads(bucket, objectPrefix, keyMarker, uploa
 			if os.IsNotExist(multipartObjInfo.Err) {
 				return ListMultipartsInfo{}, nil
 			}
-			return ListMultipartsInfo{}, probe.NewError(multipartObjInfo.Err)
+			return ListMultipartsInfo{}, probe.NewError(multipartObjInfo.Err).Trace(bucket, objectPrefix)
 		}
 
-		if strings.Contains(multipartObjInfo.Name, "$multiparts") ||
-			strings.Contains(multipartObjInfo.Name, "$tmpobject") {
+		if isInternalName(multipartObjInfo.Name) {
 			continue
 		}
 
@@ -562,6 +1229,10 @@ func (fs Filesystem) ListMultipartUploads(bucket, objectPrefix, keyMarker, uploa
 		}
 
 		if multipartObjInfo.IsDir {
+			if _, ok := commonPrefixes[multipartObjInfo.Name]; ok {
+				continue
+			}
+			commonPrefixes[multipartObjInfo.Name] = struct{}{}
 			result.CommonPrefixes = append(result.CommonPrefixes, multipartObjInfo.Name)
 		} else {
 			result.Uploads = append(result.Uploads, uploadMetadata{
@@ -585,28 +1256,155 @@ func (fs Filesystem) ListMultipartUploads(bucket, objectPrefix, keyMarker, uploa
 	return result, nil
 }
 
+// byInitiated - sort uploads by initiation time, uploads initiated at
+// the same time by object name and upload id.
+type byInitiated []uploadMetadata
+
+func (u byInitiated) Len() int      { return len(u) }
+func (u byInitiated) Swap(i, j int) { u[i], u[j] = u[j], u[i] }
+func (u byInitiated) Less(i, j int) bool {
+	return isUploadBefore(u[i], u[j].Initiated, u[j].Object, u[j].UploadID)
+}
+
+// isUploadBefore - returns true if upload sorts before the upload of
+// object initiated at initiated with uploadID.
+func isUploadBefore(upload uploadMetadata, initiated time.Time, object, uploadID string) bool {
+	if !upload.Initiated.Equal(initiated) {
+		return upload.Initiated.Before(initiated)
+	}
+	if upload.Object != object {
+		return upload.Object < object
+	}
+	return upload.UploadID < uploadID
+}
+
+// ListMultipartUploadsByInitiated - list multipart uploads of objects
+// under objectPrefix oldest first, for cleaning up stale uploads. A
+// listing resumes after the upload of keyMarker initiated at
+// initiatedMarker with uploadIDMarker, the last upload of the previous
+// page, which may have been aborted meanwhile. All uploads under the
+// prefix are read to order them.
+func (fs Filesystem) ListMultipartUploadsByInitiated(bucket, objectPrefix string, initiatedMarker time.Time, keyMarker, uploadIDMarker string, maxUploads int) (ListMultipartsInfo, *probe.Error) {
+	result := ListMultipartsInfo{}
+
+	if bucketDirName, e := fs.checkBucketArg(bucket); e == nil {
+		bucket = bucketDirName
+	} else {
+		return result, probe.NewError(e).Trace(bucket, objectPrefix)
+	}
+
+	if !IsValidObjectPrefix(objectPrefix) {
+		return result, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: objectPrefix}).Trace(bucket, objectPrefix)
+	}
+
+	// Return empty response if maxUploads is zero
+	if maxUploads == 0 {
+		return result, nil
+	}
+
+	// set listObjectsLimit to maxUploads for out-of-range limit
+	if maxUploads < 0 || maxUploads > listObjectsLimit {
+		maxUploads = listObjectsLimit
+	}
+
+	multipartObjectInfoCh := scanMultipartDir(fs.multipartDir(bucket, ""), objectPrefix, "", "", true, fs.closed)
+	var uploads []uploadMetadata
+	for {
+		multipartObjInfo, ok := multipartObjectInfoCh.Read()
+		if !ok {
+			break
+		}
+		if multipartObjInfo.Err != nil {
+			if os.IsNotExist(multipartObjInfo.Err) {
+				return ListMultipartsInfo{}, nil
+			}
+			return ListMultipartsInfo{}, probe.NewError(multipartObjInfo.Err).Trace(bucket, objectPrefix)
+		}
+		if multipartObjInfo.IsDir || isInternalName(multipartObjInfo.Name) {
+			continue
+		}
+		uploads = append(uploads, uploadMetadata{
+			Object:    multipartObjInfo.Name,
+			UploadID:  multipartObjInfo.UploadID,
+			Initiated: multipartObjInfo.ModifiedTime,
+		})
+	}
+	sort.Sort(byInitiated(uploads))
+
+	// Skip uploads up to and including the marker.
+	if !initiatedMarker.IsZero() || keyMarker != "" || uploadIDMarker != "" {
+		marker := uploadMetadata{Initiated: initiatedMarker, Object: keyMarker, UploadID: uploadIDMarker}
+		start := sort.Search(len(uploads), func(i int) bool {
+			return isUploadBefore(marker, uploads[i].Initiated, uploads[i].Object, uploads[i].UploadID)
+		})
+		uploads = uploads[start:]
+	}
+	if len(uploads) > maxUploads {
+		uploads = uploads[:maxUploads]
+		result.IsTruncated = true
+		result.NextKeyMarker = uploads[maxUploads-1].Object
+		result.NextUploadIDMarker = uploads[maxUploads-1].UploadID
+	}
+	result.Uploads = uploads
+	return result, nil
+}
+
+// CountMultipartUploads - count active multipart uploads in a bucket.
+func (fs Filesystem) CountMultipartUploads(bucket string) (int, *probe.Error) {
+	if bucketDirName, e := fs.checkBucketArg(bucket); e == nil {
+		bucket = bucketDirName
+	} else {
+		return 0, probe.NewError(e).Trace(bucket)
+	}
+
+	count := 0
+	uploadsDir := fs.multipartDir(bucket, "")
+	walkFn := func(path string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+		if info.Mode().IsRegular() && strings.HasSuffix(info.Name(), uploadIDSuffix) {
+			count++
+		}
+		return nil
+	}
+	if e := filepath.Walk(uploadsDir, walkFn); e != nil {
+		// No multipart metadata yet for this bucket.
+		if os.IsNotExist(e) {
+			return 0, nil
+		}
+		return 0, probe.NewError(e).Trace(bucket)
+	}
+	return count, nil
+}
+
 // ListObjectParts - list parts from incomplete multipart session for a given ObjectResourcesMetadata
 func (fs Filesystem) ListObjectParts(bucket, object, uploadID string, partNumberMarker, maxParts int) (ListPartsInfo, *probe.Error) {
 	if bucketDirName, err := fs.checkMultipartArgs(bucket, object); err == nil {
 		bucket = bucketDirName
 	} else {
-		return ListPartsInfo{}, probe.NewError(err)
+		return ListPartsInfo{}, probe.NewError(err).Trace(bucket, object, uploadID)
 	}
 
 	if status, err := fs.isUploadIDExist(bucket, object, uploadID); err != nil {
-		//return probe.NewError(InternalError{Err: err})
-		return ListPartsInfo{}, probe.NewError(err)
+		//return probe.NewError(InternalError{Err: err}).Trace(bucket, object, uploadID)
+		return ListPartsInfo{}, probe.NewError(err).Trace(bucket, object, uploadID)
 	} else if !status {
-		return ListPartsInfo{}, probe.NewError(InvalidUploadID{UploadID: uploadID})
+		return ListPartsInfo{}, probe.NewError(InvalidUploadID{UploadID: uploadID}).Trace(bucket, object, uploadID)
 	}
 
-	metaObjectDir := filepath.Join(fs.path, configDir, bucket, object)
+	// Parts are listed after the marker, which is a part number.
+	if partNumberMarker < 0 || partNumberMarker > maxPartID {
+		return ListPartsInfo{}, probe.NewError(InvalidPartNumberMarker{PartNumberMarker: partNumberMarker}).Trace(bucket, object, uploadID)
+	}
+
+	metaObjectDir := fs.multipartDir(bucket, object)
 	entries, err := filteredReaddir(metaObjectDir,
 		func(entry DirEntry) bool {
 			if tokens := strings.Split(entry.Name, "."); len(tokens) == 3 {
 				if tokens[0] == uploadID {
 					if partNumber, err := strconv.Atoi(tokens[1]); err == nil {
-						if partNumber >= 1 && partNumber <= 10000 && partNumber > partNumberMarker {
+						if partNumber >= 1 && partNumber <= maxPartID && partNumber > partNumberMarker {
 							return true
 						}
 					}
@@ -619,7 +1417,7 @@ func (fs Filesystem) ListObjectParts(bucket, object, uploadID string, partNumber
 	)
 
 	if err != nil {
-		return ListPartsInfo{}, probe.NewError(err)
+		return ListPartsInfo{}, probe.NewError(err).Trace(bucket, object, uploadID)
 	}
 
 	isTruncated := false
@@ -630,24 +1428,23 @@ func (fs Filesystem) ListObjectParts(bucket, object, uploadID string, partNumber
 
 	parts := []partInfo{}
 	for i := range entries {
-		if i == maxParts {
-			isTruncated = true
-			break
-		}
-
 		tokens := strings.Split(entries[i].Name, ".")
 		partNumber, _ := strconv.Atoi(tokens[1])
 		md5sum := tokens[2]
 		parts = append(parts, partInfo{
 			PartNumber:   partNumber,
-			LastModified: entries[i].ModTime,
+			LastModified: entries[i].ModTime.Truncate(time.Second),
 			ETag:         md5sum,
 			Size:         entries[i].Size,
 		})
 	}
 
-	if isTruncated {
-		nextPartNumberMarker = 0
+	// Part files sort by name, "10" before "2", list by part number.
+	sort.Sort(byPartNumber(parts))
+	if len(parts) > maxParts {
+		parts = parts[:maxParts]
+		isTruncated = true
+		nextPartNumberMarker = parts[len(parts)-1].PartNumber
 	}
 
 	return ListPartsInfo{
@@ -661,3 +1458,41 @@ func (fs Filesystem) ListObjectParts(bucket, object, uploadID string, partNumber
 		Parts:                parts,
 	}, nil
 }
+
+// ListObjectPartsVerified - list parts like ListObjectParts, verifying
+// the data of each listed part against the md5sum of its ETag. Parts
+// which do not match are listed as corrupt, parts uploaded without an
+// md5sum have nothing to verify against.
+func (fs Filesystem) ListObjectPartsVerified(bucket, object, uploadID string, partNumberMarker, maxParts int) (ListPartsInfo, *probe.Error) {
+	result, err := fs.ListObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
+	if err != nil {
+		return ListPartsInfo{}, err.Trace(bucket, object, uploadID)
+	}
+	metaObjectDir := fs.multipartDir(result.Bucket, object)
+	for i, part := range result.Parts {
+		if part.ETag == "" {
+			continue
+		}
+		partFile := filepath.Join(metaObjectDir, fmt.Sprintf("%s.%d.%s", uploadID, part.PartNumber, part.ETag))
+		md5Hex, e := fileMD5Hex(partFile)
+		if e != nil {
+			return ListPartsInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+		}
+		result.Parts[i].Corrupt = md5Hex != part.ETag
+	}
+	return result, nil
+}
+
+// fileMD5Hex - returns the hex encoded md5sum of a file.
+func fileMD5Hex(fileName string) (string, error) {
+	file, e := os.Open(fileName)
+	if e != nil {
+		return "", e
+	}
+	defer file.Close()
+	hasher := md5.New()
+	if _, e = io.Copy(hasher, file); e != nil {
+		return "", e
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
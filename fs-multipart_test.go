@@ -0,0 +1,1981 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/disk"
+	"github.com/minio/minio/pkg/safe"
+)
+
+// Testing PutObjectPart() with a body shorter than the declared size.
+func TestPutObjectPartIncompleteBody(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Declared size is larger than the data actually sent.
+	data := "abcd"
+	_, err = fs.PutObjectPart("test-multipart", "object", uploadID, 1, int64(len(data))+1, bytes.NewBufferString(data), "")
+	if err == nil {
+		t.Fatal("Expected PutObjectPart to fail with a short body, but it passed instead")
+	}
+	if _, ok := err.ToGoError().(IncompleteBody); !ok {
+		t.Fatalf("Expected IncompleteBody error, but instead found \"%s\"", err.Cause.Error())
+	}
+
+	// Only the upload id file is expected, no part or temporary files.
+	names, e := ioutil.ReadDir(filepath.Join(directory, configDir, "test-multipart", multipartsDir, "object"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name.Name(), uploadIDSuffix) {
+			t.Errorf("Expected no part to be committed, found \"%s\"", name.Name())
+		}
+	}
+}
+
+// Testing CompleteMultipartUpload() with a part corrupted on disk.
+func TestCompleteMultipartUploadCorruptedPart(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.(*Filesystem).checksumAlgo = checksumSHA256
+
+	err = fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := "abcd"
+	md5Hex := "e2fc714c4727ee9395f324cd2e7f331f"
+	etag, err := fs.PutObjectPart("test-multipart", "object", uploadID, 1, int64(len(data)), bytes.NewBufferString(data), md5Hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt the part on disk.
+	partFile := filepath.Join(directory, configDir, "test-multipart", multipartsDir, "object", uploadID+".1."+md5Hex)
+	if e = ioutil.WriteFile(partFile, []byte("abce"), 0600); e != nil {
+		t.Fatal(e)
+	}
+
+	_, err = fs.CompleteMultipartUpload("test-multipart", "object", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+	if err == nil {
+		t.Fatal("Expected CompleteMultipartUpload to fail with a corrupted part, but it passed instead")
+	}
+	if _, ok := err.ToGoError().(ObjectCorrupted); !ok {
+		t.Fatalf("Expected ObjectCorrupted error, but instead found \"%s\"", err.Cause.Error())
+	}
+	if _, e = os.Stat(filepath.Join(directory, "test-multipart", "object")); !os.IsNotExist(e) {
+		t.Fatal("Expected object not to be created from a corrupted part")
+	}
+}
+
+// Testing object writes and multipart completion with fsync enabled.
+func TestFsyncWrites(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-fsync-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Count flushed files and directories.
+	var syncedFiles, syncedDirs int
+	defer func(fn func(*os.File) error) { fsyncFile = fn }(fsyncFile)
+	fsyncFile = func(file *os.File) error {
+		st, e := file.Stat()
+		if e != nil {
+			return e
+		}
+		if st.IsDir() {
+			syncedDirs++
+		} else {
+			syncedFiles++
+		}
+		return file.Sync()
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-fsync")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No flushes by default.
+	data := "abcd"
+	_, err = fs.PutObject("test-fsync", "object", int64(len(data)), bytes.NewBufferString(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if syncedFiles != 0 || syncedDirs != 0 {
+		t.Fatalf("Expected no flushes with fsync disabled, found %d files %d directories", syncedFiles, syncedDirs)
+	}
+
+	fs.(*Filesystem).fsync = true
+	_, err = fs.PutObject("test-fsync", "object", int64(len(data)), bytes.NewBufferString(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Object and its metadata along with their directories.
+	if syncedFiles != 2 || syncedDirs != 2 {
+		t.Fatalf("Expected object, its metadata and their directories to be flushed, found %d files %d directories", syncedFiles, syncedDirs)
+	}
+
+	// Multipart completion flushes the complete object and its directory.
+	uploadID, err := fs.NewMultipartUpload("test-fsync", "multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("test-fsync", "multipart", uploadID, 1, int64(len(data)), bytes.NewBufferString(data), "e2fc714c4727ee9395f324cd2e7f331f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncedFiles, syncedDirs = 0, 0
+	_, err = fs.CompleteMultipartUpload("test-fsync", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Complete object, its metadata and its part layout along with
+	// their directories.
+	if syncedFiles != 3 || syncedDirs != 3 {
+		t.Fatalf("Expected complete object, its metadata and their directories to be flushed, found %d files %d directories", syncedFiles, syncedDirs)
+	}
+}
+
+// Testing CompleteMultipartUpload() with object names colliding with prefixes.
+func TestCompleteMultipartUploadPrefixCollision(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"Asia/India/India-summer-photos-1", "Europe"} {
+		_, err = fs.PutObject("test-multipart", object, int64(len("photos")), bytes.NewBufferString("photos"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		object      string
+		expectedErr error
+	}{
+		// Prefix then object.
+		{"Asia/India", PrefixExistsAsObject{Bucket: "test-multipart", Object: "Asia/India"}},
+		// Object then prefix.
+		{"Europe/France", ObjectExistsAsPrefix{Bucket: "test-multipart", Prefix: "Europe/France"}},
+	}
+	for i, testCase := range testCases {
+		uploadID, err := fs.NewMultipartUpload("test-multipart", testCase.object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		etag, err := fs.PutObjectPart("test-multipart", testCase.object, uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = fs.CompleteMultipartUpload("test-multipart", testCase.object, uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+		if err == nil {
+			t.Fatalf("Test %d: Expected CompleteMultipartUpload to fail, but it passed instead", i+1)
+		}
+		if err.ToGoError() != testCase.expectedErr {
+			t.Fatalf("Test %d: Expected error \"%s\", but instead found \"%s\"", i+1, testCase.expectedErr, err.Cause.Error())
+		}
+	}
+}
+
+// Testing NewMultipartUpload() gives up when upload ids keep colliding.
+func TestNewMultipartUploadCollisions(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Generate the same upload id always.
+	attempts := 0
+	defer func(fn func() (string, error)) { newUUID = fn }(newUUID)
+	newUUID = func() (string, error) {
+		attempts++
+		return "11111111-1111-1111-1111-111111111111", nil
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// First upload reserves the upload id.
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploadID != "11111111-1111-1111-1111-111111111111" {
+		t.Fatalf("Expected injected upload id, but instead found %s", uploadID)
+	}
+
+	// Second upload collides on every attempt.
+	attempts = 0
+	_, err = fs.NewMultipartUpload("test-multipart", "object")
+	if err == nil {
+		t.Fatal("Expected NewMultipartUpload to fail on persistent collisions, but it passed instead")
+	}
+	if err.ToGoError() != errUploadIDAttemptsExhausted {
+		t.Fatalf("Expected errUploadIDAttemptsExhausted, but instead found \"%s\"", err.Cause.Error())
+	}
+	if attempts != maxUploadIDAttempts {
+		t.Fatalf("Expected %d attempts, but instead found %d", maxUploadIDAttempts, attempts)
+	}
+}
+
+// Testing metadata kept on a separate metadata path.
+func TestMetadataPath(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-data-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+	metaDir, e := ioutil.TempDir("", "minio-meta-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(metaDir)
+
+	// Use a server config with a separate metadata directory.
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{MetaDir: metaDir},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Objects go to the data path.
+	_, err = fs.PutObject("test-metadata", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, e = os.Stat(filepath.Join(directory, "test-metadata", "object")); e != nil {
+		t.Fatalf("Expected object under the data path, but instead found \"%s\"", e)
+	}
+
+	// Uploads go to the metadata path.
+	uploadID, err := fs.NewMultipartUpload("test-metadata", "multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, e = os.Stat(filepath.Join(metaDir, "test-metadata", multipartsDir, "multipart", uploadID+uploadIDSuffix)); e != nil {
+		t.Fatalf("Expected upload id under the metadata path, but instead found \"%s\"", e)
+	}
+	if _, e = os.Stat(filepath.Join(directory, configDir)); !os.IsNotExist(e) {
+		t.Fatal("Expected no metadata under the data path")
+	}
+	etag, err := fs.PutObjectPart("test-metadata", "multipart", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.CompleteMultipartUpload("test-metadata", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, e = os.Stat(filepath.Join(directory, "test-metadata", "multipart")); e != nil {
+		t.Fatalf("Expected complete object under the data path, but instead found \"%s\"", e)
+	}
+
+	// Policies go to the metadata path.
+	if err = writeBucketPolicy("test-metadata", []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	if _, e = os.Stat(filepath.Join(metaDir, metaBucketsConfigDir, "test-metadata", "access-policy.json")); e != nil {
+		t.Fatalf("Expected bucket policy under the metadata path, but instead found \"%s\"", e)
+	}
+	if _, err = readBucketPolicy("test-metadata"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bucket metadata shares the metadata path, the policy directory
+	// can not collide with a bucket.
+	if IsValidBucketName(metaBucketsConfigDir) {
+		t.Fatalf("Expected policy directory %s not to be a valid bucket name", metaBucketsConfigDir)
+	}
+}
+
+// Testing read-after-write verification of written files.
+func TestVerifyWrites(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-verify-writes-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.(*Filesystem).verifyWrites = true
+	err = fs.MakeBucket("test-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Object is visible as soon as PutObject returns.
+	data := "abcd"
+	_, err = fs.PutObject("test-verify", "object", int64(len(data)), bytes.NewBufferString(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.GetObjectInfo("test-verify", "object"); err != nil {
+		t.Fatalf("Expected object to be visible after PutObject, but instead found \"%s\"", err.Cause.Error())
+	}
+
+	// Simulate a lagging directory entry.
+	defer func(fn func(string) (os.FileInfo, error)) { lstatFile = fn }(lstatFile)
+	lstatFile = func(name string) (os.FileInfo, error) {
+		return nil, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+	_, err = fs.PutObject("test-verify", "object", int64(len(data)), bytes.NewBufferString(data), nil)
+	if err == nil {
+		t.Fatal("Expected PutObject to fail when the object is not visible, but it passed instead")
+	}
+	if err.ToGoError() != errWriteNotVisible {
+		t.Fatalf("Expected errWriteNotVisible, but instead found \"%s\"", err.Cause.Error())
+	}
+}
+
+// Testing closeSafeFile() removes the temporary file when closing fails.
+func TestCloseSafeFileError(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-close-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(directory, "object")
+	safeFile, e := safe.CreateFileWithPrefix(fileName, "$tmpobject")
+	if e != nil {
+		t.Fatal(e)
+	}
+	// Closed already, closing again fails.
+	safeFile.File.Close()
+	if e = fs.(*Filesystem).closeSafeFile(safeFile, fileName); e == nil {
+		t.Fatal("Expected closeSafeFile to fail, but it passed instead")
+	}
+	if _, e = os.Stat(safeFile.Name()); !os.IsNotExist(e) {
+		t.Fatalf("Expected temporary file to be removed, but instead found \"%v\"", e)
+	}
+	if _, e = os.Stat(fileName); !os.IsNotExist(e) {
+		t.Fatalf("Expected no file at %s, but instead found \"%v\"", fileName, e)
+	}
+}
+
+// Testing PutObjectPart() of unknown size aborts once the disk fills up.
+func TestPutObjectPartStreamingDiskFull(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Check free disk space every 1MiB.
+	defer func(interval int64) { diskCheckInterval = interval }(diskCheckInterval)
+	diskCheckInterval = 1024 * 1024
+
+	// Disk fills up after the first check during the stream.
+	checks := 0
+	defer func(fn func(string) (disk.Info, error)) { getDiskInfo = fn }(getDiskInfo)
+	getDiskInfo = func(path string) (disk.Info, error) {
+		checks++
+		if checks > 2 {
+			return disk.Info{Total: 100, Free: 1}, nil
+		}
+		return disk.Info{Total: 100, Free: 100}, nil
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks = 0
+
+	// Stream 16MiB without declaring its size.
+	dataSize := int64(16 * 1024 * 1024)
+	data := io.LimitReader(bytes.NewReader(make([]byte, dataSize)), dataSize)
+	_, err = fs.PutObjectPart("test-multipart", "object", uploadID, 1, -1, data, "")
+	if err == nil {
+		t.Fatal("Expected PutObjectPart to fail on a full disk, but it passed instead")
+	}
+	if _, ok := err.ToGoError().(RootPathFull); !ok {
+		t.Fatalf("Expected RootPathFull error, but instead found \"%s\"", err.Cause.Error())
+	}
+	if checks != 3 {
+		t.Fatalf("Expected streaming to abort at the first failed check, but instead found %d checks", checks)
+	}
+
+	// Only the upload id file is expected, no part or temporary files.
+	names, e := ioutil.ReadDir(filepath.Join(directory, configDir, "test-multipart", multipartsDir, "object"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name.Name(), uploadIDSuffix) {
+			t.Errorf("Expected no part to be committed, found \"%s\"", name.Name())
+		}
+	}
+}
+
+// cancelAfterContext - context cancelled after its error was checked
+// a number of times, cancels deterministically in the middle of loops.
+type cancelAfterContext struct {
+	context.Context
+	checks int
+}
+
+func (ctx *cancelAfterContext) Err() error {
+	if ctx.checks <= 0 {
+		return context.Canceled
+	}
+	ctx.checks--
+	return nil
+}
+
+// Testing CompleteMultipartUploadContext() cancelled between parts.
+func TestCompleteMultipartUploadContextCancel(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []completePart
+	for i := 1; i <= 3; i++ {
+		etag, err := fs.PutObjectPart("test-multipart", "object", uploadID, i, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, completePart{PartNumber: i, ETag: etag})
+	}
+
+	// Cancel after the first part was concatenated.
+	ctx := &cancelAfterContext{Context: context.Background(), checks: 1}
+	_, err = fs.(*Filesystem).CompleteMultipartUploadContext(ctx, "test-multipart", "object", uploadID, parts)
+	if err == nil {
+		t.Fatal("Expected CompleteMultipartUploadContext to fail when cancelled, but it passed instead")
+	}
+	if err.ToGoError() != context.Canceled {
+		t.Fatalf("Expected context.Canceled, but instead found \"%s\"", err.Cause.Error())
+	}
+	if _, e = os.Stat(filepath.Join(directory, "test-multipart", "object")); !os.IsNotExist(e) {
+		t.Fatal("Expected object not to be created when cancelled")
+	}
+
+	// Upload is left intact without temporary files.
+	names, e := ioutil.ReadDir(filepath.Join(directory, configDir, "test-multipart", multipartsDir, "object"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, name := range names {
+		if strings.Contains(name.Name(), ".complete.") {
+			t.Errorf("Expected complete file to be removed, found \"%s\"", name.Name())
+		}
+	}
+	_, err = fs.CompleteMultipartUpload("test-multipart", "object", uploadID, parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Testing ListObjectParts() part number marker.
+func TestListObjectPartsMarker(t *testing.T) {
+	execObjectLayerTest(t, testListObjectPartsMarker)
+}
+
+func testListObjectPartsMarker(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 10; i++ {
+		_, err = fs.PutObjectPart("test-multipart", "object", uploadID, i, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		partNumberMarker     int
+		maxParts             int
+		expectedParts        []int
+		isTruncated          bool
+		nextPartNumberMarker int
+	}{
+		{5, 1000, []int{6, 7, 8, 9, 10}, false, 0},
+		{0, 3, []int{1, 2, 3}, true, 3},
+		{3, 3, []int{4, 5, 6}, true, 6},
+		{9, 3, []int{10}, false, 0},
+		{10, 3, []int{}, false, 0},
+	}
+	for i, testCase := range testCases {
+		result, err := fs.ListObjectParts("test-multipart", "object", uploadID, testCase.partNumberMarker, testCase.maxParts)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		var partNumbers []int
+		for _, part := range result.Parts {
+			partNumbers = append(partNumbers, part.PartNumber)
+		}
+		if fmt.Sprint(partNumbers) != fmt.Sprint(testCase.expectedParts) {
+			t.Errorf("Test %d: Expected parts %v, but instead found %v", i+1, testCase.expectedParts, partNumbers)
+		}
+		if result.IsTruncated != testCase.isTruncated {
+			t.Errorf("Test %d: Expected truncated %v, but instead found %v", i+1, testCase.isTruncated, result.IsTruncated)
+		}
+		if result.NextPartNumberMarker != testCase.nextPartNumberMarker {
+			t.Errorf("Test %d: Expected next part number marker %d, but instead found %d", i+1, testCase.nextPartNumberMarker, result.NextPartNumberMarker)
+		}
+		if result.PartNumberMarker != testCase.partNumberMarker {
+			t.Errorf("Test %d: Expected part number marker %d, but instead found %d", i+1, testCase.partNumberMarker, result.PartNumberMarker)
+		}
+	}
+
+	// Out of range markers.
+	for _, partNumberMarker := range []int{-1, 10001} {
+		_, err = fs.ListObjectParts("test-multipart", "object", uploadID, partNumberMarker, 1000)
+		if _, ok := err.ToGoError().(InvalidPartNumberMarker); !ok {
+			t.Errorf("Expected InvalidPartNumberMarker error for marker %d, but instead found \"%v\"", partNumberMarker, err)
+		}
+	}
+}
+
+// Testing PutObjectPart() part number boundaries.
+func TestPutObjectPartNumberBoundary(t *testing.T) {
+	execObjectLayerTest(t, testPutObjectPartNumberBoundary)
+}
+
+func testPutObjectPartNumberBoundary(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		partNumber int
+		shouldPass bool
+	}{
+		{-1, false},
+		{0, false},
+		{1, true},
+		{10000, true},
+		{10001, false},
+	}
+	for i, testCase := range testCases {
+		_, err = fs.PutObjectPart("test-multipart", "object", uploadID, testCase.partNumber, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if err != nil && !testCase.shouldPass {
+			if _, ok := err.ToGoError().(InvalidPartNumber); !ok {
+				t.Errorf("Test %d: Expected InvalidPartNumber error, but instead found \"%s\"", i+1, err.Cause.Error())
+			}
+		}
+	}
+
+	// Part 10000 is listed after part 1.
+	result, err := fs.ListObjectParts("test-multipart", "object", uploadID, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Parts) != 2 || result.Parts[0].PartNumber != 1 || result.Parts[1].PartNumber != 10000 {
+		t.Fatalf("Expected parts 1 and 10000, but instead found %v", result.Parts)
+	}
+
+	// Upload completed with part 10000 as its last part.
+	objInfo, err := fs.CompleteMultipartUpload("test-multipart", "object", uploadID, []completePart{
+		{PartNumber: 1, ETag: result.Parts[0].ETag},
+		{PartNumber: 10000, ETag: result.Parts[1].ETag},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != int64(len("abcdabcd")) {
+		t.Fatalf("Expected object size %d, but instead found %d", len("abcdabcd"), objInfo.Size)
+	}
+	listResult, err := fs.ListObjects("test-multipart", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listResult.Objects) != 1 || listResult.Objects[0].Name != "object" || listResult.Objects[0].Size != objInfo.Size {
+		t.Fatalf("Expected completed object to be listed, but instead found %v", listResult.Objects)
+	}
+}
+
+func TestCompleteMultipartUploadPartOrder(t *testing.T) {
+	execObjectLayerTest(t, testCompleteMultipartUploadPartOrder)
+}
+
+func testCompleteMultipartUploadPartOrder(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var etags []string
+	for partNumber := 1; partNumber <= 3; partNumber++ {
+		etag, err := fs.PutObjectPart("test-multipart", "object", uploadID, partNumber, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+		if err != nil {
+			t.Fatal(err)
+		}
+		etags = append(etags, etag)
+	}
+
+	testCases := []struct {
+		parts      []completePart
+		shouldPass bool
+	}{
+		// Descending part numbers.
+		{[]completePart{{PartNumber: 3, ETag: etags[2]}, {PartNumber: 2, ETag: etags[1]}, {PartNumber: 1, ETag: etags[0]}}, false},
+		// Duplicate part numbers.
+		{[]completePart{{PartNumber: 1, ETag: etags[0]}, {PartNumber: 1, ETag: etags[0]}, {PartNumber: 2, ETag: etags[1]}}, false},
+		// Ascending part numbers.
+		{[]completePart{{PartNumber: 1, ETag: etags[0]}, {PartNumber: 2, ETag: etags[1]}, {PartNumber: 3, ETag: etags[2]}}, true},
+	}
+	for i, testCase := range testCases {
+		objInfo, err := fs.CompleteMultipartUpload("test-multipart", "object", uploadID, testCase.parts)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if err != nil && !testCase.shouldPass {
+			if _, ok := err.ToGoError().(InvalidPartOrder); !ok {
+				t.Errorf("Test %d: Expected InvalidPartOrder, got %s", i+1, err.Cause.Error())
+			}
+		}
+		if err == nil && objInfo.Size != int64(3*len("abcd")) {
+			t.Errorf("Test %d: Expected size %d, got %d", i+1, 3*len("abcd"), objInfo.Size)
+		}
+	}
+}
+
+func TestCountMultipartUploads(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+
+	// No uploads yet.
+	count, err := filesystem.CountMultipartUploads("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected 0 uploads, got %d", count)
+	}
+
+	objects := []string{"object", "object", "Asia/photo.jpg", "Asia/India/photo.jpg", "Asia/India/photo.jpg"}
+	var uploadIDs []string
+	for _, object := range objects {
+		uploadID, err := fs.NewMultipartUpload("test-multipart", object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uploadIDs = append(uploadIDs, uploadID)
+	}
+	// Parts do not count as uploads.
+	_, err = fs.PutObjectPart("test-multipart", "object", uploadIDs[0], 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err = filesystem.CountMultipartUploads("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(objects) {
+		t.Fatalf("Expected %d uploads, got %d", len(objects), count)
+	}
+
+	err = fs.AbortMultipartUpload("test-multipart", "object", uploadIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err = filesystem.CountMultipartUploads("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(objects)-1 {
+		t.Fatalf("Expected %d uploads, got %d", len(objects)-1, count)
+	}
+
+	// Missing bucket.
+	_, err = filesystem.CountMultipartUploads("missing-bucket")
+	if err == nil {
+		t.Fatal("Expected CountMultipartUploads on a missing bucket to fail")
+	}
+	if _, ok := err.ToGoError().(BucketNotFound); !ok {
+		t.Fatalf("Expected BucketNotFound, got %s", err.Cause.Error())
+	}
+}
+
+func TestCustomModes(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-modes-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Use a server config with custom modes.
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{DirMode: "0750", FileMode: "0640"},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-modes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("test-modes", "photos/object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-modes", "uploads/multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadIDFile := filepath.Join(directory, configDir, "test-modes", multipartsDir, "uploads", "multipart", uploadID+uploadIDSuffix)
+	if st, e := os.Stat(uploadIDFile); e != nil {
+		t.Fatal(e)
+	} else if st.Mode().Perm() != 0640 {
+		t.Errorf("Expected upload id file mode 0640, got %o", st.Mode().Perm())
+	}
+	etag, err := fs.PutObjectPart("test-modes", "uploads/multipart", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.CompleteMultipartUpload("test-modes", "uploads/multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		path string
+		mode os.FileMode
+	}{
+		{filepath.Join(directory, "test-modes"), 0750},
+		{filepath.Join(directory, "test-modes", "photos"), 0750},
+		{filepath.Join(directory, "test-modes", "photos", "object"), 0640},
+		{filepath.Join(directory, "test-modes", "uploads"), 0750},
+		{filepath.Join(directory, "test-modes", "uploads", "multipart"), 0640},
+	}
+	for i, testCase := range testCases {
+		st, e := os.Stat(testCase.path)
+		if e != nil {
+			t.Fatalf("Test %d: %s", i+1, e)
+		}
+		if st.Mode().Perm() != testCase.mode {
+			t.Errorf("Test %d: Expected mode %o for %s, got %o", i+1, testCase.mode, testCase.path, st.Mode().Perm())
+		}
+	}
+
+	// Invalid modes are rejected.
+	serverConfig.Storage = storageConfig{FileMode: "0999"}
+	if _, err = newFS(directory); err == nil {
+		t.Fatal("Expected invalid file mode to be rejected")
+	}
+}
+
+func TestListMultipartUploadsCommonPrefixes(t *testing.T) {
+	execObjectLayerTest(t, testListMultipartUploadsCommonPrefixes)
+}
+
+func testListMultipartUploadsCommonPrefixes(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := []string{"photos/2016/a.jpg", "photos/2016/b.jpg", "photos/c.jpg", "photos/c.jpg", "photos/d.jpg", "top"}
+	for _, object := range objects {
+		if _, err = fs.NewMultipartUpload("test-multipart", object); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		prefix         string
+		commonPrefixes []string
+		uploads        []string
+	}{
+		{"", []string{"photos/"}, []string{"top"}},
+		{"photos/", []string{"photos/2016/"}, []string{"photos/c.jpg", "photos/c.jpg", "photos/d.jpg"}},
+	}
+	for i, testCase := range testCases {
+		result, err := fs.ListMultipartUploads("test-multipart", testCase.prefix, "", "", "/", 1000)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		if !reflect.DeepEqual(result.CommonPrefixes, testCase.commonPrefixes) {
+			t.Errorf("Test %d: Expected common prefixes %v, got %v", i+1, testCase.commonPrefixes, result.CommonPrefixes)
+		}
+		var uploads []string
+		for _, upload := range result.Uploads {
+			uploads = append(uploads, upload.Object)
+		}
+		if !reflect.DeepEqual(uploads, testCase.uploads) {
+			t.Errorf("Test %d: Expected uploads %v, got %v", i+1, testCase.uploads, uploads)
+		}
+	}
+}
+
+// Testing object sidecars and directories without uploads are not
+// listed as uploads.
+func TestListMultipartUploadsObjectSidecars(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-sidecars-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("test-multipart"); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"photos/a.jpg", "x"} {
+		_, err = fs.PutObject("test-multipart", object, int64(len("abcd")), bytes.NewBufferString("abcd"), map[string]string{"contentType": "image/jpeg"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Directory left behind without uploads.
+	if e = os.MkdirAll(fs.multipartDir("test-multipart", "empty/dir"), 0700); e != nil {
+		t.Fatal(e)
+	}
+
+	result, err := fs.ListMultipartUploads("test-multipart", "", "", "", "/", 1000)
+	if err != nil {
+		t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if len(result.CommonPrefixes) != 0 || len(result.Uploads) != 0 {
+		t.Fatalf("Expected no uploads, but instead found %v %v", result.CommonPrefixes, result.Uploads)
+	}
+
+	// Uploads of keys named like sidecars of existing objects.
+	for _, object := range []string{"x" + objectMetaSuffix, "photos/a.jpg" + objectTagsSuffix} {
+		if _, err = fs.NewMultipartUpload("test-multipart", object); err != nil {
+			t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+		}
+	}
+	result, err = fs.ListMultipartUploads("test-multipart", "", "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	var uploads []string
+	for _, upload := range result.Uploads {
+		uploads = append(uploads, upload.Object)
+	}
+	expected := []string{"photos/a.jpg" + objectTagsSuffix, "x" + objectMetaSuffix}
+	if !reflect.DeepEqual(uploads, expected) {
+		t.Fatalf("Expected uploads %v, got %v", expected, uploads)
+	}
+}
+
+// Testing multipart uploads of keys named like bucket metadata files.
+func TestMultipartUploadBucketMetaNames(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-bucket-meta-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("test-multipart"); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.PutBucketEncryption("test-multipart", "AES256"); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.SetBucketQuota("test-multipart", 1024*1024); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = fs.RecomputeUsage("test-multipart"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, object := range []string{bucketMetaFile, bucketEncryptionFile, bucketQuotaFile, bucketUsageFile} {
+		uploadID, err := fs.NewMultipartUpload("test-multipart", object)
+		if err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		etag, err := fs.PutObjectPart("test-multipart", object, uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+		if err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		_, err = fs.CompleteMultipartUpload("test-multipart", object, uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+		if err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+	}
+
+	// Bucket metadata is left intact.
+	algorithm, err := fs.GetBucketEncryption("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algorithm != "AES256" {
+		t.Errorf("Expected encryption \"AES256\", but instead found \"%s\"", algorithm)
+	}
+	quota, err := fs.GetBucketQuota("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quota != 1024*1024 {
+		t.Errorf("Expected quota %d, but instead found %d", 1024*1024, quota)
+	}
+	objectCount, _, err := fs.BucketUsage("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objectCount != 4 {
+		t.Errorf("Expected 4 objects, but instead found %d", objectCount)
+	}
+}
+
+func TestListMultipartUploadsInternalNames(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := []string{"my$multiparts-report", "reports/my$tmpobject-copy"}
+	for _, object := range objects {
+		if _, err = fs.NewMultipartUpload("test-multipart", object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Internal directory left behind by older servers.
+	internalDir := filepath.Join(directory, configDir, "test-multipart", multipartsDir, "$multiparts", "object")
+	if e = os.MkdirAll(internalDir, 0700); e != nil {
+		t.Fatal(e)
+	}
+	if e = ioutil.WriteFile(filepath.Join(internalDir, "1fbd117a-268a-4ed0-85c9-8cc3888cbf20"+uploadIDSuffix), nil, 0600); e != nil {
+		t.Fatal(e)
+	}
+
+	result, err := fs.ListMultipartUploads("test-multipart", "", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uploads []string
+	for _, upload := range result.Uploads {
+		uploads = append(uploads, upload.Object)
+	}
+	if !reflect.DeepEqual(uploads, objects) {
+		t.Fatalf("Expected uploads %v, got %v", objects, uploads)
+	}
+
+	testCases := []struct {
+		name     string
+		internal bool
+	}{
+		{"$multiparts/object", true},
+		{"photos/$tmpobject1234", true},
+		{"my$multiparts-report", false},
+		{"reports/my$tmpobject-copy", false},
+		{"$multiparts-report/object", false},
+		{"photo.jpg" + objectMetaSuffix, false},
+		{"photo.jpg" + uploadIDSuffix, false},
+	}
+	for i, testCase := range testCases {
+		if internal := isInternalName(testCase.name); internal != testCase.internal {
+			t.Errorf("Test %d: Expected %s internal to be %v, got %v", i+1, testCase.name, testCase.internal, internal)
+		}
+	}
+}
+
+func TestCopyObjectPart(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	data := "0123456789abcdefghij"
+	_, err = fs.PutObject("test-multipart", "source", int64(len(data)), bytes.NewBufferString(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "destination")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		partNumber  int
+		startOffset int64
+		length      int64
+		shouldPass  bool
+	}{
+		// Range past the end of the source.
+		{1, 15, 10, false},
+		// Negative offset.
+		{1, -1, 5, false},
+		// Part number out of range.
+		{0, 0, 5, false},
+		{maxPartID + 1, 0, 5, false},
+		// Mid object range.
+		{1, 5, 10, true},
+		// Until the end of the source.
+		{2, 15, 0, true},
+	}
+	var parts []completePart
+	for i, testCase := range testCases {
+		etag, err := filesystem.CopyObjectPart("test-multipart", "destination", uploadID, testCase.partNumber, "test-multipart", "source", testCase.startOffset, testCase.length)
+		if err != nil && testCase.shouldPass {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Fatalf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if err == nil {
+			parts = append(parts, completePart{PartNumber: testCase.partNumber, ETag: etag})
+		}
+	}
+	if parts[0].ETag != "d5f8745e16fc384dd522830ec45ea071" {
+		t.Fatalf("Expected md5sum of the copied range, got %s", parts[0].ETag)
+	}
+
+	_, err = fs.CompleteMultipartUpload("test-multipart", "destination", uploadID, parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	reader, err := fs.GetObject("test-multipart", "destination", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, e = io.Copy(&buffer, reader); e != nil {
+		t.Fatal(e)
+	}
+	reader.Close()
+	if buffer.String() != data[5:15]+data[15:] {
+		t.Fatalf("Expected destination \"%s\", got \"%s\"", data[5:15]+data[15:], buffer.String())
+	}
+
+	// Missing source.
+	if _, err = filesystem.CopyObjectPart("test-multipart", "destination", uploadID, 1, "test-multipart", "missing", 0, 5); err == nil {
+		t.Fatal("Expected copying from a missing source to fail")
+	}
+}
+
+func TestCompleteMultipartUploadEmptyParts(t *testing.T) {
+	execObjectLayerTest(t, testCompleteMultipartUploadEmptyParts)
+}
+
+func testCompleteMultipartUploadEmptyParts(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("test-multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emptyMD5 := "d41d8cd98f00b204e9800998ecf8427e"
+	testCases := []struct {
+		parts      []string
+		shouldPass bool
+	}{
+		// Empty middle part.
+		{[]string{"abcd", "", "abcd"}, false},
+		// Empty first part.
+		{[]string{"", "abcd"}, false},
+		// Empty last part.
+		{[]string{"abcd", "abcd", ""}, true},
+		// Single empty part.
+		{[]string{""}, true},
+	}
+	for i, testCase := range testCases {
+		uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parts []completePart
+		for j, data := range testCase.parts {
+			md5Hex := "e2fc714c4727ee9395f324cd2e7f331f"
+			if data == "" {
+				md5Hex = emptyMD5
+			}
+			etag, err := fs.PutObjectPart("test-multipart", "object", uploadID, j+1, int64(len(data)), bytes.NewBufferString(data), md5Hex)
+			if err != nil {
+				t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+			}
+			parts = append(parts, completePart{PartNumber: j + 1, ETag: etag})
+		}
+		_, err = fs.CompleteMultipartUpload("test-multipart", "object", uploadID, parts)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if err != nil && !testCase.shouldPass {
+			if _, ok := err.ToGoError().(EntityTooSmall); !ok {
+				t.Errorf("Test %d: Expected EntityTooSmall, got %s", i+1, err.Cause.Error())
+			}
+		}
+	}
+}
+
+// TestCompleteMultipartUploadPlainETag - tests the ETag of a single part upload.
+func TestCompleteMultipartUploadPlainETag(t *testing.T) {
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+
+	md5Hex := "e2fc714c4727ee9395f324cd2e7f331f"
+	testCases := []struct {
+		plainSinglePartETag bool
+		expectedETag        string
+	}{
+		// md5sum of the part md5sums with the part count.
+		{false, "1243e2c5302cae4b559ce80dd1cefa6e-1"},
+		// md5sum of the object.
+		{true, md5Hex},
+	}
+	for i, testCase := range testCases {
+		directory, e := ioutil.TempDir("", "minio-multipart-test")
+		if e != nil {
+			t.Fatal(e)
+		}
+		defer os.RemoveAll(directory)
+
+		serverConfig = &serverConfigV4{
+			Credential: mustGenAccessKeys(),
+			Storage:    storageConfig{PlainSinglePartETag: testCase.plainSinglePartETag},
+			rwMutex:    &sync.RWMutex{},
+		}
+
+		// Create the fs.
+		fs, err := newFS(directory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = fs.MakeBucket("test-multipart")
+		if err != nil {
+			t.Fatal(err)
+		}
+		uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+		if err != nil {
+			t.Fatal(err)
+		}
+		etag, err := fs.PutObjectPart("test-multipart", "object", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), md5Hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objInfo, err := fs.CompleteMultipartUpload("test-multipart", "object", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+		if err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if objInfo.MD5Sum != testCase.expectedETag {
+			t.Errorf("Test %d: Expected ETag \"%s\", got \"%s\"", i+1, testCase.expectedETag, objInfo.MD5Sum)
+		}
+		objInfo, err = fs.GetObjectInfo("test-multipart", "object")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if objInfo.MD5Sum != testCase.expectedETag {
+			t.Errorf("Test %d: Expected stored ETag \"%s\", got \"%s\"", i+1, testCase.expectedETag, objInfo.MD5Sum)
+		}
+	}
+}
+
+// TestRenameRetry - tests renames failing with transient errors are retried.
+func TestRenameRetry(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-retry-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	defer func(delay time.Duration) { retryBaseDelay = delay }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+
+	// Fail renames with the given errors before succeeding.
+	var renameErrs []error
+	var renames int
+	defer func(rename func(string, string) error) { renameFile = rename }(renameFile)
+	renameFile = func(oldPath, newPath string) error {
+		renames++
+		if len(renameErrs) > 0 {
+			e := renameErrs[0]
+			renameErrs = renameErrs[1:]
+			return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: e}
+		}
+		return os.Rename(oldPath, newPath)
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		renameErrs      []error
+		expectedRenames int
+		shouldPass      bool
+	}{
+		// Transient errors succeed on the third attempt.
+		{[]error{syscall.EBUSY, syscall.EAGAIN}, 3, true},
+		// Attempts are exhausted.
+		{[]error{syscall.EBUSY, syscall.EBUSY, syscall.EBUSY}, 3, false},
+		// Permanent errors are not retried.
+		{[]error{syscall.EACCES}, 1, false},
+		{[]error{syscall.ENOENT}, 1, false},
+	}
+	for i, testCase := range testCases {
+		object := fmt.Sprintf("object%d", i)
+		renameErrs, renames = testCase.renameErrs, 0
+		_, err = fs.PutObject("bucket", object, int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if renames != testCase.expectedRenames {
+			t.Errorf("Test %d: Expected %d renames, but instead found %d", i+1, testCase.expectedRenames, renames)
+		}
+	}
+
+	// Completing an upload retries renaming the object into place.
+	uploadID, err := fs.NewMultipartUpload("bucket", "multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("bucket", "multipart", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	renameErrs, renames = []error{syscall.EBUSY, syscall.EBUSY}, 0
+	_, err = fs.CompleteMultipartUpload("bucket", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+	if err != nil {
+		t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if renames < 3 {
+		t.Errorf("Expected the object rename to be retried, but instead found %d renames", renames)
+	}
+	if _, err = fs.GetObjectInfo("bucket", "multipart"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// inFlightReader - counts the readers being read concurrently, a
+// reader is in flight until size bytes were read.
+type inFlightReader struct {
+	io.Reader
+	size     int
+	started  bool
+	mutex    *sync.Mutex
+	inFlight *int
+	maximum  *int
+}
+
+func (r *inFlightReader) Read(p []byte) (int, error) {
+	r.mutex.Lock()
+	if !r.started {
+		r.started = true
+		*r.inFlight++
+		if *r.inFlight > *r.maximum {
+			*r.maximum = *r.inFlight
+		}
+	}
+	r.mutex.Unlock()
+	// Hold the write open to overlap with other writers.
+	time.Sleep(time.Millisecond)
+	n, e := r.Reader.Read(p)
+	if r.size -= n; r.size == 0 {
+		r.mutex.Lock()
+		*r.inFlight--
+		r.mutex.Unlock()
+	}
+	return n, e
+}
+
+// Tests that concurrent part writes are limited to the configured
+// maximum, including after failed writes.
+func TestPutObjectPartConcurrencyLimit(t *testing.T) {
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{MaxPartWrites: 4},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Failed writes release their slots, more failures than slots
+	// would otherwise block all further writes.
+	for i := 0; i < 8; i++ {
+		_, err = fs.PutObjectPart("bucket", "object", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "900150983cd24fb0d6963f7d28e17f72")
+		if err == nil {
+			t.Fatal("Expected a part with a mismatching MD5 to fail, but passed instead")
+		}
+	}
+
+	var mutex sync.Mutex
+	var inFlight, maximum int
+	var wg sync.WaitGroup
+	for i := 1; i <= 32; i++ {
+		wg.Add(1)
+		go func(partNumber int) {
+			defer wg.Done()
+			data := &inFlightReader{
+				Reader:   bytes.NewBufferString(strings.Repeat("a", 64)),
+				size:     64,
+				mutex:    &mutex,
+				inFlight: &inFlight,
+				maximum:  &maximum,
+			}
+			if _, err := fs.PutObjectPart("bucket", "object", uploadID, partNumber, 64, data, ""); err != nil {
+				t.Errorf("Part %d: Expected to pass, but failed with: <ERROR> %s", partNumber, err.Cause.Error())
+			}
+		}(i)
+	}
+	wg.Wait()
+	if maximum == 0 || maximum > 4 {
+		t.Errorf("Expected at most 4 concurrent part writes, but instead found %d", maximum)
+	}
+}
+
+// Tests that uploads older than the expiry are reclaimed periodically.
+func TestMultipartCleanupScheduler(t *testing.T) {
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{MultipartCleanupInterval: "10ms", MultipartExpiry: "1h"},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	defer filesystem.Close()
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	staleID, err := fs.NewMultipartUpload("bucket", "stale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.PutObjectPart("bucket", "stale", staleID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), ""); err != nil {
+		t.Fatal(err)
+	}
+	freshID, err := fs.NewMultipartUpload("bucket", "fresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Age the stale upload past the expiry.
+	initiated := time.Now().Add(-2 * time.Hour)
+	uploadIDFile := filepath.Join(filesystem.metaPath, "bucket", multipartsDir, "stale", staleID+uploadIDSuffix)
+	if e = os.Chtimes(uploadIDFile, initiated, initiated); e != nil {
+		t.Fatal(e)
+	}
+
+	for i := 0; ; i++ {
+		if i == 500 {
+			t.Fatal("Expected the stale upload to be reclaimed, but it still exists")
+		}
+		if status, e := filesystem.isUploadIDExist("bucket", "stale", staleID); e != nil {
+			t.Fatal(e)
+		} else if !status {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, e = os.Stat(filepath.Join(filesystem.metaPath, "bucket", multipartsDir, "stale")); !os.IsNotExist(e) {
+		t.Errorf("Expected the parts of the stale upload to be removed, but instead found %v", e)
+	}
+	result, err := fs.ListMultipartUploads("bucket", "", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Uploads) != 1 || result.Uploads[0].UploadID != freshID {
+		t.Errorf("Expected only the fresh upload %s to remain, but instead found %v", freshID, result.Uploads)
+	}
+}
+
+// Tests completing uploads with the metadata path on a different
+// filesystem than the data path.
+func TestCompleteMultipartUploadCrossDevice(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Renames across directories fail like renames across devices.
+	var crossDeviceRenames int
+	defer func(rename func(string, string) error) { renameFile = rename }(renameFile)
+	renameFile = func(oldPath, newPath string) error {
+		if filepath.Dir(oldPath) != filepath.Dir(newPath) {
+			crossDeviceRenames++
+			return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: syscall.EXDEV}
+		}
+		return os.Rename(oldPath, newPath)
+	}
+
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("bucket", "dir/multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("bucket", "dir/multipart", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.CompleteMultipartUpload("bucket", "dir/multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if crossDeviceRenames == 0 {
+		t.Fatal("Expected the object to be renamed across devices")
+	}
+
+	content, e := ioutil.ReadFile(filepath.Join(directory, "bucket", "dir", "multipart"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if string(content) != "abcd" {
+		t.Fatalf("Expected \"abcd\", but instead found \"%s\"", content)
+	}
+	// Neither the staged nor the temporary copy is left behind.
+	names, e := ioutil.ReadDir(filepath.Join(directory, "bucket", "dir"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(names) != 1 {
+		t.Errorf("Expected only the object in its directory, but instead found %d entries", len(names))
+	}
+	if _, e = os.Stat(filepath.Join(directory, configDir, "bucket", multipartsDir, "dir", "multipart")); !os.IsNotExist(e) {
+		t.Errorf("Expected the upload to be removed from the metadata path")
+	}
+}
+
+// Testing retries of CompleteMultipartUpload return the completed object.
+func TestCompleteMultipartUploadRetry(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-complete-retry-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.MakeBucket("test-multipart"); err != nil {
+		t.Fatal(err)
+	}
+
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("test-multipart", "object", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := []completePart{{PartNumber: 1, ETag: etag}}
+	objInfo, err := fs.CompleteMultipartUpload("test-multipart", "object", uploadID, parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retried, err := fs.CompleteMultipartUpload("test-multipart", "object", uploadID, parts)
+	if err != nil {
+		t.Fatalf("Expected the retry to pass, but failed with: <ERROR> %s", err)
+	}
+	if retried.MD5Sum != objInfo.MD5Sum || retried.Size != objInfo.Size {
+		t.Fatalf("Expected the retry to return ETag %s, but instead found %s", objInfo.MD5Sum, retried.MD5Sum)
+	}
+
+	// Retries with different parts are unknown uploads.
+	parts[0].ETag = "\"" + parts[0].ETag + "\""
+	if _, err = fs.CompleteMultipartUpload("test-multipart", "object", uploadID, parts); err != nil {
+		t.Fatalf("Expected quoted ETags to match, but failed with: <ERROR> %s", err)
+	}
+	parts = append(parts, completePart{PartNumber: 2, ETag: parts[0].ETag})
+	if _, err = fs.CompleteMultipartUpload("test-multipart", "object", uploadID, parts); err == nil {
+		t.Fatal("Expected a retry with different parts to fail, but it passed instead")
+	} else if _, ok := err.ToGoError().(InvalidUploadID); !ok {
+		t.Fatalf("Expected InvalidUploadID error, but instead found \"%s\"", err.Cause.Error())
+	}
+
+	// Completed uploads are only kept for a while.
+	defer func(ttl time.Duration) { completedUploadTTL = ttl }(completedUploadTTL)
+	completedUploadTTL = -time.Second
+	uploadID, err = fs.NewMultipartUpload("test-multipart", "expired")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err = fs.PutObjectPart("test-multipart", "expired", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts = []completePart{{PartNumber: 1, ETag: etag}}
+	if _, err = fs.CompleteMultipartUpload("test-multipart", "expired", uploadID, parts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.CompleteMultipartUpload("test-multipart", "expired", uploadID, parts); err == nil {
+		t.Fatal("Expected a retry after expiry to fail, but it passed instead")
+	}
+}
+
+// Testing ListObjectPartsVerified() flags tampered parts.
+func TestListObjectPartsVerified(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-verified-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.MakeBucket("test-multipart"); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etags := make(map[int]string)
+	for i := 1; i <= 3; i++ {
+		etags[i], err = fs.PutObjectPart("test-multipart", "object", uploadID, i, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Overwrite the data of the second part.
+	partFile := filepath.Join(directory, configDir, "test-multipart", multipartsDir, "object", fmt.Sprintf("%s.%d.%s", uploadID, 2, etags[2]))
+	if e = ioutil.WriteFile(partFile, []byte("abce"), 0600); e != nil {
+		t.Fatal(e)
+	}
+
+	result, err := fs.(*Filesystem).ListObjectPartsVerified("test-multipart", "object", uploadID, 0, 1000)
+	if err != nil {
+		t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err)
+	}
+	if len(result.Parts) != 3 {
+		t.Fatalf("Expected 3 parts, but instead found %d", len(result.Parts))
+	}
+	for _, part := range result.Parts {
+		if part.Corrupt != (part.PartNumber == 2) {
+			t.Errorf("Part %d: Expected corrupt %v, but instead found %v", part.PartNumber, part.PartNumber == 2, part.Corrupt)
+		}
+	}
+
+	// Plain listings do not verify parts.
+	result, err = fs.ListObjectParts("test-multipart", "object", uploadID, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range result.Parts {
+		if part.Corrupt {
+			t.Errorf("Part %d: Expected not to be verified", part.PartNumber)
+		}
+	}
+}
+
+// Testing CompleteMultipartUpload() records the layout of the parts.
+func TestObjectPartsLayout(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-parts-layout-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("test-multipart"); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []completePart
+	for i, data := range []string{"abcd", "efghij", "klm"} {
+		etag, err := fs.PutObjectPart("test-multipart", "object", uploadID, i+1, int64(len(data)), bytes.NewBufferString(data), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, completePart{PartNumber: i + 1, ETag: etag})
+	}
+	objInfo, err := fs.CompleteMultipartUpload("test-multipart", "object", uploadID, parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layout, err := fs.GetObjectPartsLayout("test-multipart", "object")
+	if err != nil {
+		t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	if len(layout) != 3 {
+		t.Fatalf("Expected 3 parts, but instead found %d", len(layout))
+	}
+	var offset int64
+	for i, part := range layout {
+		if part.PartNumber != i+1 || part.ETag != parts[i].ETag {
+			t.Errorf("Part %d: Expected part number %d and ETag %s, but instead found %d and %s", i+1, i+1, parts[i].ETag, part.PartNumber, part.ETag)
+		}
+		if part.Offset != offset {
+			t.Errorf("Part %d: Expected offset %d, but instead found %d", i+1, offset, part.Offset)
+		}
+		offset += part.Size
+	}
+	if offset != objInfo.Size {
+		t.Errorf("Expected part sizes to sum to %d, but instead found %d", objInfo.Size, offset)
+	}
+
+	// Objects replaced by a single put have no layout.
+	if _, err = fs.PutObject("test-multipart", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if layout, err = fs.GetObjectPartsLayout("test-multipart", "object"); err != nil || len(layout) != 0 {
+		t.Fatalf("Expected an empty layout, but instead found %v, %v", layout, err)
+	}
+	if _, err = fs.GetObjectPartsLayout("test-multipart", "missing"); err == nil {
+		t.Fatal("Expected the layout of a missing object to fail, but it passed instead")
+	}
+}
+
+// Testing CompleteMultipartUpload() reports which part is invalid.
+func TestCompleteMultipartUploadInvalidPart(t *testing.T) {
+	execObjectLayerTest(t, testCompleteMultipartUploadInvalidPart)
+}
+
+func testCompleteMultipartUploadInvalidPart(t *testing.T, fs ObjectLayer) {
+	if err := fs.MakeBucket("test-multipart"); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-multipart", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 2; i++ {
+		if _, err = fs.PutObjectPart("test-multipart", "object", uploadID, i, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	otherETag := "2a7d4c35b5ab0cd8a2c2e8efb8b2c5d1"
+	testCases := []struct {
+		parts       []completePart
+		expectedErr InvalidPart
+	}{
+		// Part never uploaded.
+		{
+			[]completePart{{PartNumber: 1, ETag: "e2fc714c4727ee9395f324cd2e7f331f"}, {PartNumber: 3, ETag: otherETag}},
+			InvalidPart{PartNumber: 3, ETag: otherETag},
+		},
+		// Part uploaded with another ETag.
+		{
+			[]completePart{{PartNumber: 1, ETag: "e2fc714c4727ee9395f324cd2e7f331f"}, {PartNumber: 2, ETag: "\"" + otherETag + "\""}},
+			InvalidPart{PartNumber: 2, ETag: otherETag, Uploaded: true, UploadedETag: "e2fc714c4727ee9395f324cd2e7f331f"},
+		},
+	}
+	for i, testCase := range testCases {
+		_, err = fs.CompleteMultipartUpload("test-multipart", "object", uploadID, testCase.parts)
+		if err == nil {
+			t.Fatalf("Test %d: Expected to fail with \"%s\", but passed instead", i+1, testCase.expectedErr)
+		}
+		invalidPartErr, ok := err.ToGoError().(InvalidPart)
+		if !ok {
+			t.Fatalf("Test %d: Expected InvalidPart error, but instead found \"%s\"", i+1, err.Cause.Error())
+		}
+		if invalidPartErr != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %#v, but instead found %#v", i+1, testCase.expectedErr, invalidPartErr)
+		}
+	}
+}
+
+// Testing ListMultipartUploadsByInitiated() lists the oldest uploads first.
+func TestListMultipartUploadsByInitiated(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-multipart-initiated-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("test-multipart"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Uploads initiated at staggered times, out of name order.
+	now := time.Now().Truncate(time.Second)
+	uploads := []struct {
+		object    string
+		initiated time.Time
+	}{
+		{"a", now.Add(-time.Hour)},
+		{"b/c", now.Add(-3 * time.Hour)},
+		{"b/d", now.Add(-2 * time.Hour)},
+		{"e", now.Add(-4 * time.Hour)},
+		{"f", now.Add(-2 * time.Hour)},
+	}
+	for _, upload := range uploads {
+		uploadID, err := fs.NewMultipartUpload("test-multipart", upload.object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uploadIDFile := filepath.Join(directory, configDir, "test-multipart", multipartsDir, upload.object, uploadID+uploadIDSuffix)
+		if e = os.Chtimes(uploadIDFile, upload.initiated, upload.initiated); e != nil {
+			t.Fatal(e)
+		}
+	}
+	// Uploads initiated at the same time are ordered by object name.
+	expected := []string{"e", "b/c", "b/d", "f", "a"}
+
+	// Page through, aborting the listed uploads as cleanup would.
+	var listed []string
+	var initiatedMarker time.Time
+	var keyMarker, uploadIDMarker string
+	for {
+		result, err := fs.ListMultipartUploadsByInitiated("test-multipart", "", initiatedMarker, keyMarker, uploadIDMarker, 2)
+		if err != nil {
+			t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+		}
+		for _, upload := range result.Uploads {
+			listed = append(listed, upload.Object)
+			if err = fs.AbortMultipartUpload("test-multipart", upload.Object, upload.UploadID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		last := result.Uploads[len(result.Uploads)-1]
+		if result.NextKeyMarker != last.Object || result.NextUploadIDMarker != last.UploadID {
+			t.Fatalf("Expected next markers %s %s, but instead found %s %s", last.Object, last.UploadID, result.NextKeyMarker, result.NextUploadIDMarker)
+		}
+		initiatedMarker, keyMarker, uploadIDMarker = last.Initiated, last.Object, last.UploadID
+	}
+	if fmt.Sprint(listed) != fmt.Sprint(expected) {
+		t.Fatalf("Expected uploads %v oldest first, but instead found %v", expected, listed)
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// Suffix of the per object retention file kept under the metadata path.
+const objectRetentionSuffix = ".retention.json"
+
+// Suffix of the per object legal hold file kept under the metadata path.
+const objectLegalHoldSuffix = ".legalhold.json"
+
+// lockTimeNow - current time retention dates are compared against,
+// replaced in tests.
+var lockTimeNow = time.Now
+
+// objectRetention - retention persisted for an object.
+type objectRetention struct {
+	// Object can not be deleted or overwritten before this date.
+	RetainUntil time.Time `json:"retainUntil"`
+}
+
+// objectLegalHold - legal hold persisted for an object.
+type objectLegalHold struct {
+	// Object can not be deleted or overwritten while on.
+	On bool `json:"on"`
+}
+
+// objectRetentionPath - path of the retention file for an object.
+func (fs Filesystem) objectRetentionPath(bucket, object string) string {
+	return fs.sidecarPath(bucket, object, objectRetentionSuffix)
+}
+
+// objectLegalHoldPath - path of the legal hold file for an object.
+func (fs Filesystem) objectLegalHoldPath(bucket, object string) string {
+	return fs.sidecarPath(bucket, object, objectLegalHoldSuffix)
+}
+
+// readObjectRetention - read retention of an object, objects without
+// retention return a zero retention.
+func (fs Filesystem) readObjectRetention(bucket, object string) (objectRetention, error) {
+	retention := objectRetention{}
+	if e := readMetaFile(fs.objectRetentionPath(bucket, object), &retention); e != nil && !os.IsNotExist(e) {
+		return objectRetention{}, e
+	}
+	return retention, nil
+}
+
+// PutObjectRetention - retain an existing object until a date, the
+// object can not be deleted or overwritten before it. An active
+// retention can only be extended.
+func (fs Filesystem) PutObjectRetention(bucket, object string, until time.Time) *probe.Error {
+	// Verify the object exists, validates input as well.
+	if _, err := fs.GetObjectInfo(bucket, object); err != nil {
+		return err.Trace(bucket, object)
+	}
+
+	bucket = getActualBucketname(fs.path, bucket)
+	fs.nsLock.lock(bucket, object)
+	defer fs.nsLock.unlock(bucket, object)
+
+	retention, e := fs.readObjectRetention(bucket, object)
+	if e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	if lockTimeNow().Before(retention.RetainUntil) && until.Before(retention.RetainUntil) {
+		return probe.NewError(ObjectLocked{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+	retention.RetainUntil = until.UTC()
+	if e = fs.writeMetaFile(fs.objectRetentionPath(bucket, object), retention); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	return nil
+}
+
+// GetObjectRetention - get the retention date of an existing object,
+// objects without retention return a zero time.
+func (fs Filesystem) GetObjectRetention(bucket, object string) (time.Time, *probe.Error) {
+	// Verify the object exists, validates input as well.
+	if _, err := fs.GetObjectInfo(bucket, object); err != nil {
+		return time.Time{}, err.Trace(bucket, object)
+	}
+
+	bucket = getActualBucketname(fs.path, bucket)
+	retention, e := fs.readObjectRetention(bucket, object)
+	if e != nil {
+		return time.Time{}, probe.NewError(e).Trace(bucket, object)
+	}
+	return retention.RetainUntil, nil
+}
+
+// readObjectLegalHold - read legal hold of an object, objects without
+// legal hold return a legal hold which is off.
+func (fs Filesystem) readObjectLegalHold(bucket, object string) (objectLegalHold, error) {
+	legalHold := objectLegalHold{}
+	if e := readMetaFile(fs.objectLegalHoldPath(bucket, object), &legalHold); e != nil && !os.IsNotExist(e) {
+		return objectLegalHold{}, e
+	}
+	return legalHold, nil
+}
+
+// PutObjectLegalHold - place or clear a legal hold on an existing
+// object, the object can not be deleted or overwritten while on
+// regardless of its retention.
+func (fs Filesystem) PutObjectLegalHold(bucket, object string, on bool) *probe.Error {
+	// Verify the object exists, validates input as well.
+	if _, err := fs.GetObjectInfo(bucket, object); err != nil {
+		return err.Trace(bucket, object)
+	}
+
+	bucket = getActualBucketname(fs.path, bucket)
+	fs.nsLock.lock(bucket, object)
+	defer fs.nsLock.unlock(bucket, object)
+
+	if e := fs.writeMetaFile(fs.objectLegalHoldPath(bucket, object), objectLegalHold{On: on}); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	return nil
+}
+
+// GetObjectLegalHold - returns true if an existing object is under
+// legal hold.
+func (fs Filesystem) GetObjectLegalHold(bucket, object string) (bool, *probe.Error) {
+	// Verify the object exists, validates input as well.
+	if _, err := fs.GetObjectInfo(bucket, object); err != nil {
+		return false, err.Trace(bucket, object)
+	}
+
+	bucket = getActualBucketname(fs.path, bucket)
+	legalHold, e := fs.readObjectLegalHold(bucket, object)
+	if e != nil {
+		return false, probe.NewError(e).Trace(bucket, object)
+	}
+	return legalHold.On, nil
+}
+
+// checkObjectLock - returns ObjectLocked if the object is under legal
+// hold or retention and can not be deleted or overwritten, callers
+// hold the namespace lock.
+func (fs Filesystem) checkObjectLock(bucket, object string) error {
+	legalHold, e := fs.readObjectLegalHold(bucket, object)
+	if e != nil {
+		return e
+	}
+	if legalHold.On {
+		return ObjectLocked{Bucket: bucket, Object: object}
+	}
+	retention, e := fs.readObjectRetention(bucket, object)
+	if e != nil {
+		return e
+	}
+	if lockTimeNow().Before(retention.RetainUntil) {
+		return ObjectLocked{Bucket: bucket, Object: object}
+	}
+	return nil
+}
+
+// removeObjectLock - remove retention and legal hold of an object
+// along with any of its parent directories left empty.
+func (fs Filesystem) removeObjectLock(bucket, object string) error {
+	for _, lockPath := range []string{fs.objectRetentionPath(bucket, object), fs.objectLegalHoldPath(bucket, object)} {
+		e := removeFileTree(lockPath, filepath.Join(fs.metaPath, bucket))
+		if e != nil && !os.IsNotExist(e) {
+			return e
+		}
+	}
+	return nil
+}
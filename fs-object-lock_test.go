@@ -0,0 +1,255 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestObjectRetention(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-retention-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-retention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	_, err = fs.PutObject("test-retention", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No retention by default.
+	until, err := filesystem.GetObjectRetention("test-retention", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !until.IsZero() {
+		t.Fatalf("Expected no retention, got %s", until)
+	}
+
+	now := time.Now().UTC()
+	defer func(timeNow func() time.Time) { lockTimeNow = timeNow }(lockTimeNow)
+	lockTimeNow = func() time.Time { return now }
+
+	retainUntil := now.Add(time.Hour)
+	if err = filesystem.PutObjectRetention("test-retention", "object", retainUntil); err != nil {
+		t.Fatal(err)
+	}
+	until, err = filesystem.GetObjectRetention("test-retention", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !until.Equal(retainUntil) {
+		t.Fatalf("Expected retention until %s, got %s", retainUntil, until)
+	}
+
+	// Active retention can not be shortened.
+	err = filesystem.PutObjectRetention("test-retention", "object", now.Add(time.Minute))
+	if err == nil {
+		t.Fatal("Expected shortening an active retention to fail")
+	}
+	if _, ok := err.ToGoError().(ObjectLocked); !ok {
+		t.Fatalf("Expected ObjectLocked, got %s", err.Cause.Error())
+	}
+
+	// Retained object can not be deleted or overwritten.
+	err = fs.DeleteObject("test-retention", "object")
+	if err == nil {
+		t.Fatal("Expected deleting a retained object to fail")
+	}
+	if _, ok := err.ToGoError().(ObjectLocked); !ok {
+		t.Fatalf("Expected ObjectLocked, got %s", err.Cause.Error())
+	}
+	_, err = fs.PutObject("test-retention", "object", int64(len("efgh")), bytes.NewBufferString("efgh"), nil)
+	if err == nil {
+		t.Fatal("Expected overwriting a retained object to fail")
+	}
+	if _, ok := err.ToGoError().(ObjectLocked); !ok {
+		t.Fatalf("Expected ObjectLocked, got %s", err.Cause.Error())
+	}
+	uploadID, err := fs.NewMultipartUpload("test-retention", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("test-retention", "object", uploadID, 1, int64(len("efgh")), bytes.NewBufferString("efgh"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.CompleteMultipartUpload("test-retention", "object", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+	if err == nil {
+		t.Fatal("Expected completing an upload over a retained object to fail")
+	}
+	if _, ok := err.ToGoError().(ObjectLocked); !ok {
+		t.Fatalf("Expected ObjectLocked, got %s", err.Cause.Error())
+	}
+	_, err = filesystem.PutObjectMetadata("test-retention", "object", map[string]string{"contentType": "text/plain"})
+	if err == nil {
+		t.Fatal("Expected replacing metadata of a retained object to fail")
+	}
+	if _, ok := err.ToGoError().(ObjectLocked); !ok {
+		t.Fatalf("Expected ObjectLocked, got %s", err.Cause.Error())
+	}
+	objInfo, err := fs.GetObjectInfo("test-retention", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.MD5Sum != "e2fc714c4727ee9395f324cd2e7f331f" {
+		t.Fatalf("Expected retained object to be unchanged, got md5sum %s", objInfo.MD5Sum)
+	}
+
+	// Once retention expires the object can be overwritten and deleted.
+	lockTimeNow = func() time.Time { return retainUntil.Add(time.Second) }
+	_, err = fs.PutObject("test-retention", "object", int64(len("efgh")), bytes.NewBufferString("efgh"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.DeleteObject("test-retention", "object"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Retention does not outlive the object.
+	_, err = fs.PutObject("test-retention", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	until, err = filesystem.GetObjectRetention("test-retention", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !until.IsZero() {
+		t.Fatalf("Expected no retention on a new object, got %s", until)
+	}
+
+	// Missing object.
+	if err = filesystem.PutObjectRetention("test-retention", "missing", retainUntil); err == nil {
+		t.Fatal("Expected retention of a missing object to fail")
+	}
+}
+
+func TestObjectLegalHold(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-legalhold-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-legalhold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	for _, object := range []string{"object", "retained"} {
+		_, err = fs.PutObject("test-legalhold", object, int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = filesystem.PutObjectLegalHold("test-legalhold", object, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	on, err := filesystem.GetObjectLegalHold("test-legalhold", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !on {
+		t.Fatal("Expected legal hold to be on")
+	}
+
+	// Legal hold blocks deletion and overwrites.
+	err = fs.DeleteObject("test-legalhold", "object")
+	if err == nil {
+		t.Fatal("Expected deleting an object under legal hold to fail")
+	}
+	if _, ok := err.ToGoError().(ObjectLocked); !ok {
+		t.Fatalf("Expected ObjectLocked, got %s", err.Cause.Error())
+	}
+	_, err = fs.PutObject("test-legalhold", "object", int64(len("efgh")), bytes.NewBufferString("efgh"), nil)
+	if err == nil {
+		t.Fatal("Expected overwriting an object under legal hold to fail")
+	}
+	if _, ok := err.ToGoError().(ObjectLocked); !ok {
+		t.Fatalf("Expected ObjectLocked, got %s", err.Cause.Error())
+	}
+	_, err = filesystem.PutObjectMetadata("test-legalhold", "object", map[string]string{"contentType": "text/plain"})
+	if err == nil {
+		t.Fatal("Expected replacing metadata of an object under legal hold to fail")
+	}
+	if _, ok := err.ToGoError().(ObjectLocked); !ok {
+		t.Fatalf("Expected ObjectLocked, got %s", err.Cause.Error())
+	}
+
+	// Cleared legal hold allows deletion.
+	if err = filesystem.PutObjectLegalHold("test-legalhold", "object", false); err != nil {
+		t.Fatal(err)
+	}
+	on, err = filesystem.GetObjectLegalHold("test-legalhold", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if on {
+		t.Fatal("Expected legal hold to be cleared")
+	}
+	if err = fs.DeleteObject("test-legalhold", "object"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Legal hold and retention compose, either blocks deletion.
+	now := time.Now().UTC()
+	defer func(timeNow func() time.Time) { lockTimeNow = timeNow }(lockTimeNow)
+	lockTimeNow = func() time.Time { return now }
+	if err = filesystem.PutObjectRetention("test-legalhold", "retained", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err = filesystem.PutObjectLegalHold("test-legalhold", "retained", false); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.DeleteObject("test-legalhold", "retained"); err == nil {
+		t.Fatal("Expected deleting a retained object to fail with legal hold cleared")
+	}
+	lockTimeNow = func() time.Time { return now.Add(2 * time.Hour) }
+	if err = filesystem.PutObjectLegalHold("test-legalhold", "retained", true); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.DeleteObject("test-legalhold", "retained"); err == nil {
+		t.Fatal("Expected deleting an object under legal hold to fail with retention expired")
+	}
+	if err = filesystem.PutObjectLegalHold("test-legalhold", "retained", false); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.DeleteObject("test-legalhold", "retained"); err != nil {
+		t.Fatal(err)
+	}
+}
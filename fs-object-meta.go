@@ -0,0 +1,426 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio/pkg/probe"
+	"github.com/minio/minio/pkg/safe"
+)
+
+// Suffix of the per object metadata file kept under the metadata path.
+const objectMetaSuffix = ".meta.json"
+
+// Suffix of the per part checksum file kept next to the part.
+const partChecksumSuffix = ".checksum"
+
+// Supported checksum algorithms for internal integrity verification.
+const (
+	checksumMD5    = "md5"
+	checksumSHA256 = "sha256"
+)
+
+// Checksum algorithm clients may additionally declare for transferred
+// data, never used internally.
+const checksumCRC32C = "crc32c"
+
+// isValidChecksumAlgorithm - verify if the checksum algorithm is supported.
+func isValidChecksumAlgorithm(algorithm string) bool {
+	return algorithm == checksumMD5 || algorithm == checksumSHA256
+}
+
+// newChecksumHasher - returns a new hasher for a supported checksum algorithm.
+func newChecksumHasher(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case checksumMD5:
+		return md5.New(), nil
+	case checksumSHA256:
+		return sha256.New(), nil
+	case checksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	}
+	return nil, fmt.Errorf("Unsupported checksum algorithm %s", algorithm)
+}
+
+// checksumInfo - checksum of the data along with its algorithm.
+type checksumInfo struct {
+	Algorithm string `json:"algorithm"`
+	Hash      string `json:"hash"`
+}
+
+// dataCheck - hex encoded checksum of transferred data declared by the
+// client.
+type dataCheck struct {
+	algorithm string
+	expected  string
+}
+
+// transferChecks - returns the checksums declared in metadata, the hex
+// encoded "md5Sum", "crc32c" and "sha256".
+func transferChecks(metadata map[string]string) []dataCheck {
+	var checks []dataCheck
+	for _, check := range []dataCheck{
+		{checksumMD5, metadata["md5Sum"]},
+		{checksumCRC32C, metadata[checksumCRC32C]},
+		{checksumSHA256, metadata[checksumSHA256]},
+	} {
+		if check.expected != "" {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// dataVerifier - computes the md5sum of the data written to it along
+// with the checksum of every check.
+type dataVerifier struct {
+	md5Hasher hash.Hash
+	checks    []dataCheck
+	// Hashers of the checks, nil for md5 checks which use md5Hasher.
+	hashers []hash.Hash
+}
+
+// newDataVerifier - returns a verifier of checks.
+func newDataVerifier(checks []dataCheck) (*dataVerifier, error) {
+	v := &dataVerifier{
+		md5Hasher: md5.New(),
+		checks:    checks,
+		hashers:   make([]hash.Hash, len(checks)),
+	}
+	for i, check := range checks {
+		if check.algorithm == checksumMD5 {
+			continue
+		}
+		hasher, e := newChecksumHasher(check.algorithm)
+		if e != nil {
+			return nil, e
+		}
+		v.hashers[i] = hasher
+	}
+	return v, nil
+}
+
+func (v *dataVerifier) Write(p []byte) (int, error) {
+	v.md5Hasher.Write(p)
+	for _, hasher := range v.hashers {
+		if hasher != nil {
+			hasher.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// md5Hex - hex encoded md5sum of the data written.
+func (v *dataVerifier) md5Hex() string {
+	return hex.EncodeToString(v.md5Hasher.Sum(nil))
+}
+
+// verify - verifies the data written against every check, returns
+// BadDigest naming the first check failed.
+func (v *dataVerifier) verify() error {
+	for i, check := range v.checks {
+		hasher := v.hashers[i]
+		if hasher == nil {
+			hasher = v.md5Hasher
+		}
+		calculated := hex.EncodeToString(hasher.Sum(nil))
+		if !isMD5SumEqual(check.expected, calculated) {
+			return BadDigest{Algorithm: check.algorithm, Expected: check.expected, Calculated: calculated}
+		}
+	}
+	return nil
+}
+
+// parseContentHeaders - returns the content headers set in metadata as
+// "contentEncoding", "cacheControl", "contentDisposition",
+// "contentLanguage" and "expires". Values are sent back as headers and
+// must not contain line breaks.
+func parseContentHeaders(metadata map[string]string) (contentHeaders, error) {
+	headers := contentHeaders{
+		ContentEncoding:    metadata["contentEncoding"],
+		CacheControl:       metadata["cacheControl"],
+		ContentDisposition: metadata["contentDisposition"],
+		ContentLanguage:    metadata["contentLanguage"],
+		Expires:            metadata["expires"],
+	}
+	for header, value := range map[string]string{
+		"Content-Encoding":    headers.ContentEncoding,
+		"Cache-Control":       headers.CacheControl,
+		"Content-Disposition": headers.ContentDisposition,
+		"Content-Language":    headers.ContentLanguage,
+		"Expires":             headers.Expires,
+	} {
+		if strings.ContainsAny(value, "\r\n") {
+			return contentHeaders{}, InvalidHeaderValue{Header: header}
+		}
+	}
+	return headers, nil
+}
+
+// userMetadataPrefix - prefix of the names of user defined metadata.
+const userMetadataPrefix = "x-amz-meta-"
+
+// maxUserMetadataSize - maximum total size of the names and values of
+// the user defined metadata of an object, like S3.
+const maxUserMetadataSize = 2 * 1024
+
+// parseUserMetadata - returns the user defined metadata in metadata,
+// names starting with "x-amz-meta-" in any case, by lowercased name.
+func parseUserMetadata(metadata map[string]string) (map[string]string, error) {
+	var userDefined map[string]string
+	size := 0
+	for name, value := range metadata {
+		name = strings.ToLower(name)
+		if !strings.HasPrefix(name, userMetadataPrefix) {
+			continue
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, InvalidHeaderValue{Header: name}
+		}
+		if userDefined == nil {
+			userDefined = make(map[string]string)
+		}
+		userDefined[name] = value
+		size += len(name) - len(userMetadataPrefix) + len(value)
+	}
+	if size > maxUserMetadataSize {
+		return nil, MetadataTooLarge{Size: size, MaxSize: maxUserMetadataSize}
+	}
+	return userDefined, nil
+}
+
+// Supported storage classes, all objects are stored alike.
+const (
+	storageClassStandard          = "STANDARD"
+	storageClassReducedRedundancy = "REDUCED_REDUNDANCY"
+)
+
+// checkStorageClass - returns the storage class to persist for the
+// requested one, STANDARD when unset.
+func checkStorageClass(storageClass string) (string, error) {
+	switch storageClass {
+	case "":
+		return storageClassStandard, nil
+	case storageClassStandard, storageClassReducedRedundancy:
+		return storageClass, nil
+	}
+	return "", InvalidStorageClass{StorageClass: storageClass}
+}
+
+// fsObjectMetadata - metadata persisted for every object written.
+type fsObjectMetadata struct {
+	// S3 compatible md5sum, this is the ETag sent to clients.
+	MD5Sum string `json:"md5Sum"`
+	// Content type set by the client, detected from the object
+	// extension when empty.
+	ContentType string `json:"contentType,omitempty"`
+	// Content headers set by the client.
+	contentHeaders
+	// User defined metadata by lowercased "x-amz-meta-" header name.
+	UserDefined map[string]string `json:"userDefined,omitempty"`
+	// Storage class requested by the client, objects written before
+	// it was persisted are STANDARD.
+	StorageClass string `json:"storageClass,omitempty"`
+	// Checksum used for internal verification of the object data.
+	Checksum checksumInfo `json:"checksum"`
+	// Sha256sum of the blob deduplicated objects are linked to.
+	Blob string `json:"blob,omitempty"`
+}
+
+// objectMetaPath - path of the metadata file for an object.
+func (fs Filesystem) objectMetaPath(bucket, object string) string {
+	return fs.sidecarPath(bucket, object, objectMetaSuffix)
+}
+
+// writeMetaFile - safely writes v as json at metaPath, with fsync
+// enabled flushed to disk like object data.
+func (fs Filesystem) writeMetaFile(metaPath string, v interface{}) error {
+	metaBytes, e := json.Marshal(v)
+	if e != nil {
+		return e
+	}
+	safeFile, e := safe.CreateFileWithPrefix(metaPath, "$tmpobject")
+	if e != nil {
+		return e
+	}
+	if _, e = safeFile.Write(metaBytes); e != nil {
+		safeFile.CloseAndRemove()
+		return e
+	}
+	if fs.fsync {
+		if e = fsyncFile(safeFile.File); e != nil {
+			safeFile.CloseAndRemove()
+			return e
+		}
+	}
+	if e = safeFile.Close(); e != nil {
+		return e
+	}
+	if fs.fsync {
+		return fsyncDir(filepath.Dir(metaPath))
+	}
+	return nil
+}
+
+// readMetaFile - reads json at metaPath into v.
+func readMetaFile(metaPath string, v interface{}) error {
+	metaBytes, e := ioutil.ReadFile(metaPath)
+	if e != nil {
+		return e
+	}
+	return json.Unmarshal(metaBytes, v)
+}
+
+// writeObjectMetadata - persist metadata of an object.
+func (fs Filesystem) writeObjectMetadata(bucket, object string, objMeta fsObjectMetadata) error {
+	return fs.writeMetaFile(fs.objectMetaPath(bucket, object), objMeta)
+}
+
+// readObjectMetadata - read persisted metadata of an object, objects
+// written before metadata was persisted return os.ErrNotExist.
+func (fs Filesystem) readObjectMetadata(bucket, object string) (fsObjectMetadata, error) {
+	objMeta := fsObjectMetadata{}
+	if e := readMetaFile(fs.objectMetaPath(bucket, object), &objMeta); e != nil {
+		return fsObjectMetadata{}, e
+	}
+	return objMeta, nil
+}
+
+// readListedMetadata - fills in the persisted storage class and content
+// headers of a listed object, STANDARD for objects without a storage
+// class.
+func (fs Filesystem) readListedMetadata(bucket string, objInfo *ObjectInfo) error {
+	objMeta, e := fs.readObjectMetadata(bucket, objInfo.Name)
+	if e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	objInfo.StorageClass = storageClassStandard
+	if objMeta.StorageClass != "" {
+		objInfo.StorageClass = objMeta.StorageClass
+	}
+	objInfo.contentHeaders = objMeta.contentHeaders
+	return nil
+}
+
+// removeObjectMetadata - remove persisted metadata of an object along
+// with any of its parent directories left empty.
+func (fs Filesystem) removeObjectMetadata(bucket, object string) error {
+	e := removeFileTree(fs.objectMetaPath(bucket, object), filepath.Join(fs.metaPath, bucket))
+	if e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	return nil
+}
+
+// GetObjectVerified - GET object after verifying its data against the
+// checksum persisted while writing it. Objects without a persisted
+// checksum are returned unverified. With a mirror path configured,
+// corrupted objects are repaired from their copy in the mirror.
+func (fs Filesystem) GetObjectVerified(bucket, object string) (io.ReadCloser, *probe.Error) {
+	file, _, err := fs.getObject(bucket, object, 0)
+	fs.metrics.record(&fs.metrics.getObject, err)
+	if err != nil {
+		return nil, err.Trace(bucket, object)
+	}
+	bucket = getActualBucketname(fs.path, bucket)
+	objMeta, e := fs.readObjectMetadata(bucket, object)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return fs.limitReadCloser(file), nil
+		}
+		file.Close()
+		return nil, probe.NewError(e).Trace(bucket, object)
+	}
+	if objMeta.Checksum.Algorithm == "" {
+		return fs.limitReadCloser(file), nil
+	}
+	hasher, e := newChecksumHasher(objMeta.Checksum.Algorithm)
+	if e != nil {
+		file.Close()
+		return nil, probe.NewError(e).Trace(bucket, object)
+	}
+	if _, e = io.Copy(hasher, file); e != nil {
+		file.Close()
+		return nil, probe.NewError(e).Trace(bucket, object)
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != objMeta.Checksum.Hash {
+		file.Close()
+		if fs.mirrorPath == "" {
+			return nil, probe.NewError(ObjectCorrupted{Object: object}).Trace(bucket, object)
+		}
+		repaired, e := fs.repairFromMirror(bucket, object, objMeta.Checksum)
+		if e != nil {
+			errorIf(probe.NewError(e).Trace(bucket, object), "Unable to repair corrupted object from mirror.", nil)
+			return nil, probe.NewError(ObjectCorrupted{Object: object}).Trace(bucket, object)
+		}
+		return fs.limitReadCloser(repaired), nil
+	}
+	// Rewind for the caller.
+	if _, e = file.Seek(0, os.SEEK_SET); e != nil {
+		file.Close()
+		return nil, probe.NewError(e).Trace(bucket, object)
+	}
+	return fs.limitReadCloser(file), nil
+}
+
+// PutObjectMetadata - replace metadata of an existing object without
+// rewriting its data, md5sum and checksum of the object are preserved.
+// Replaces the content type and the user defined metadata.
+func (fs Filesystem) PutObjectMetadata(bucket, object string, metadata map[string]string) (ObjectInfo, *probe.Error) {
+	defer fs.listCache.invalidate(bucket)
+	userDefined, e := parseUserMetadata(metadata)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+
+	// Serialize with writers of the same object, which write fresh
+	// metadata of their own.
+	bucket = getActualBucketname(fs.path, bucket)
+	fs.nsLock.lock(bucket, object)
+	defer fs.nsLock.unlock(bucket, object)
+
+	// Verify the object exists, validates input as well.
+	if _, err := fs.GetObjectInfo(bucket, object); err != nil {
+		return ObjectInfo{}, err.Trace(bucket, object)
+	}
+	// Metadata of locked objects can not be replaced.
+	if e = fs.checkObjectLock(bucket, object); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	objMeta, e := fs.readObjectMetadata(bucket, object)
+	if e != nil && !os.IsNotExist(e) {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	objMeta.ContentType = metadata["contentType"]
+	objMeta.UserDefined = userDefined
+	if e = fs.writeObjectMetadata(bucket, object, objMeta); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	return fs.GetObjectInfo(bucket, object)
+}
@@ -0,0 +1,69 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// Suffix of the per object parts layout file kept under the metadata
+// path.
+const objectPartsSuffix = ".parts.json"
+
+// PartLayout - a part of an object completed by a multipart upload,
+// its range of the object data.
+type PartLayout struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Offset     int64  `json:"offset"`
+	Size       int64  `json:"size"`
+}
+
+// objectPartsPath - path of the parts layout file for an object.
+func (fs Filesystem) objectPartsPath(bucket, object string) string {
+	return fs.sidecarPath(bucket, object, objectPartsSuffix)
+}
+
+// GetObjectPartsLayout - get the layout of the parts an object was
+// completed from, objects written by a single put return an empty
+// layout.
+func (fs Filesystem) GetObjectPartsLayout(bucket, object string) ([]PartLayout, *probe.Error) {
+	// Verify the object exists, validates input as well.
+	if _, err := fs.GetObjectInfo(bucket, object); err != nil {
+		return nil, err.Trace(bucket, object)
+	}
+
+	bucket = getActualBucketname(fs.path, bucket)
+	layout := []PartLayout{}
+	if e := readMetaFile(fs.objectPartsPath(bucket, object), &layout); e != nil && !os.IsNotExist(e) {
+		return nil, probe.NewError(e).Trace(bucket, object)
+	}
+	return layout, nil
+}
+
+// removeObjectPartsLayout - remove the parts layout of an object along
+// with any of its parent directories left empty.
+func (fs Filesystem) removeObjectPartsLayout(bucket, object string) error {
+	e := removeFileTree(fs.objectPartsPath(bucket, object), filepath.Join(fs.metaPath, bucket))
+	if e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	return nil
+}
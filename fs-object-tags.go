@@ -0,0 +1,117 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// Suffix of the per object tags file kept under the metadata path.
+const objectTagsSuffix = ".tags.json"
+
+// S3 object tagging limits.
+const (
+	maxObjectTags     = 10
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
+
+// objectTagsPath - path of the tags file for an object.
+func (fs Filesystem) objectTagsPath(bucket, object string) string {
+	return fs.sidecarPath(bucket, object, objectTagsSuffix)
+}
+
+// validateObjectTags - verify tags are within S3 tagging limits.
+func validateObjectTags(tags map[string]string) error {
+	if len(tags) > maxObjectTags {
+		return InvalidTag{Reason: fmt.Sprintf("more than %d tags", maxObjectTags)}
+	}
+	for key, value := range tags {
+		if key == "" {
+			return InvalidTag{Reason: "empty tag key"}
+		}
+		if utf8.RuneCountInString(key) > maxTagKeyLength {
+			return InvalidTag{Reason: fmt.Sprintf("tag key %s longer than %d characters", key, maxTagKeyLength)}
+		}
+		if utf8.RuneCountInString(value) > maxTagValueLength {
+			return InvalidTag{Reason: fmt.Sprintf("tag value of %s longer than %d characters", key, maxTagValueLength)}
+		}
+	}
+	return nil
+}
+
+// PutObjectTags - replace tags of an existing object.
+func (fs Filesystem) PutObjectTags(bucket, object string, tags map[string]string) *probe.Error {
+	// Verify the object exists, validates input as well.
+	if _, err := fs.GetObjectInfo(bucket, object); err != nil {
+		return err.Trace(bucket, object)
+	}
+	if e := validateObjectTags(tags); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+
+	bucket = getActualBucketname(fs.path, bucket)
+	if e := fs.writeMetaFile(fs.objectTagsPath(bucket, object), tags); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	return nil
+}
+
+// GetObjectTags - get tags of an existing object, objects without
+// tags return an empty set.
+func (fs Filesystem) GetObjectTags(bucket, object string) (map[string]string, *probe.Error) {
+	// Verify the object exists, validates input as well.
+	if _, err := fs.GetObjectInfo(bucket, object); err != nil {
+		return nil, err.Trace(bucket, object)
+	}
+
+	bucket = getActualBucketname(fs.path, bucket)
+	tags := make(map[string]string)
+	if e := readMetaFile(fs.objectTagsPath(bucket, object), &tags); e != nil && !os.IsNotExist(e) {
+		return nil, probe.NewError(e).Trace(bucket, object)
+	}
+	return tags, nil
+}
+
+// DeleteObjectTags - remove all tags of an existing object.
+func (fs Filesystem) DeleteObjectTags(bucket, object string) *probe.Error {
+	// Verify the object exists, validates input as well.
+	if _, err := fs.GetObjectInfo(bucket, object); err != nil {
+		return err.Trace(bucket, object)
+	}
+
+	bucket = getActualBucketname(fs.path, bucket)
+	if e := fs.removeObjectTags(bucket, object); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	return nil
+}
+
+// removeObjectTags - remove tags of an object along with any of its
+// parent directories left empty.
+func (fs Filesystem) removeObjectTags(bucket, object string) error {
+	e := removeFileTree(fs.objectTagsPath(bucket, object), filepath.Join(fs.metaPath, bucket))
+	if e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	return nil
+}
@@ -17,12 +17,17 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
-	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"encoding/hex"
 	"runtime"
@@ -30,94 +35,216 @@ import (
 	"github.com/minio/minio/pkg/disk"
 	"github.com/minio/minio/pkg/mimedb"
 	"github.com/minio/minio/pkg/probe"
-	"github.com/minio/minio/pkg/safe"
 )
 
 /// Object Operations
 
+// Name of the marker file holding the data of a directory object, an
+// object whose name ends in a slash such as "folder/".
+const dirObjectMarker = "$dirobject"
+
+// objectStorageName - returns the name the object data is stored
+// under, directory objects are kept in a marker file inside the
+// directory so that objects under the same prefix can coexist.
+func objectStorageName(object string) string {
+	if strings.HasSuffix(object, "/") {
+		return object + dirObjectMarker
+	}
+	return object
+}
+
 // GetObject - GET object
 func (fs Filesystem) GetObject(bucket, object string, startOffset int64) (io.ReadCloser, *probe.Error) {
+	file, _, err := fs.getObject(bucket, object, startOffset)
+	fs.metrics.record(&fs.metrics.getObject, err)
+	if err != nil {
+		return nil, err
+	}
+	return fs.limitReadCloser(file), nil
+}
+
+// objectReader - reads a range of an object file.
+type objectReader struct {
+	io.Reader
+	io.Closer
+}
+
+// GetObjectReader - GET object, returns a reader of length bytes from
+// startOffset along with the number of bytes it reads. A length of
+// zero or less reads until the end of the object.
+func (fs Filesystem) GetObjectReader(bucket, object string, startOffset, length int64) (io.ReadCloser, int64, *probe.Error) {
+	file, objectSize, err := fs.getObject(bucket, object, startOffset)
+	fs.metrics.record(&fs.metrics.getObject, err)
+	if err != nil {
+		return nil, 0, err
+	}
+	if startOffset < 0 || startOffset > objectSize {
+		file.Close()
+		return nil, 0, probe.NewError(InvalidRange{Start: startOffset, Length: length})
+	}
+	size := objectSize - startOffset
+	if length <= 0 || length >= size {
+		return fs.limitReadCloser(file), size, nil
+	}
+	return objectReader{Reader: fs.limitReader(io.LimitReader(file, length)), Closer: file}, length, nil
+}
+
+// GetObjectRequest - GET object following conditions and the range of
+// the request.
+func (fs Filesystem) GetObjectRequest(bucket, object string, req GetObjectRequest) (GetObjectResponse, *probe.Error) {
+	return getObjectRequest(fs, bucket, object, req)
+}
+
+// getObject - see GetObject, additionally returns the object size.
+func (fs Filesystem) getObject(bucket, object string, startOffset int64) (*os.File, int64, *probe.Error) {
 	// Input validation.
 	if !IsValidBucketName(bucket) {
-		return nil, probe.NewError(BucketNameInvalid{Bucket: bucket})
+		return nil, 0, probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket, object)
 	}
 	if !IsValidObjectName(object) {
-		return nil, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+		return nil, 0, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
 	}
 
+	if e := fs.checkRootPath(); e != nil {
+		return nil, 0, probe.NewError(e).Trace(bucket, object)
+	}
 	// normalize buckets.
 	bucket = getActualBucketname(fs.path, bucket)
-	objectPath := filepath.Join(fs.path, bucket, object)
+	objectPath := filepath.Join(fs.path, bucket, objectStorageName(object))
 
-	file, e := os.Open(objectPath)
+	// Files which are not objects are never opened, opening a fifo
+	// would block.
+	_, e := lstatObject(objectPath)
+	var file *os.File
+	if e == nil {
+		file, e = os.Open(objectPath)
+	}
 	if e != nil {
 		// If the object doesn't exist, the bucket might not exist either. Stat for
 		// the bucket and give a better error message if that is true.
 		if os.IsNotExist(e) {
 			_, e = os.Stat(filepath.Join(fs.path, bucket))
 			if os.IsNotExist(e) {
-				return nil, probe.NewError(BucketNotFound{Bucket: bucket})
+				return nil, 0, probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket, object)
 			}
-			return nil, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+			return nil, 0, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object}).Trace(bucket, object)
 		}
-		return nil, probe.NewError(e)
+		return nil, 0, probe.NewError(e).Trace(bucket, object)
 	}
 	// Initiate a cached stat operation on the file handler.
 	st, e := file.Stat()
 	if e != nil {
-		return nil, probe.NewError(e)
+		file.Close()
+		return nil, 0, probe.NewError(e).Trace(bucket, object)
 	}
 	// Object path is a directory prefix, return object not found error.
 	if st.IsDir() {
-		return nil, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+		file.Close()
+		return nil, 0, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object}).Trace(bucket, object)
 	}
 
 	// Seek to a starting offset.
 	_, e = file.Seek(startOffset, os.SEEK_SET)
 	if e != nil {
+		file.Close()
 		// When the "handle is invalid", the file might be a directory on Windows.
 		if runtime.GOOS == "windows" && strings.Contains(e.Error(), "handle is invalid") {
-			return nil, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+			return nil, 0, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object}).Trace(bucket, object)
 		}
-		return nil, probe.NewError(e)
+		return nil, 0, probe.NewError(e).Trace(bucket, object)
 	}
-	return file, nil
+	return file, st.Size(), nil
 }
 
 // GetObjectInfo - get object info.
 func (fs Filesystem) GetObjectInfo(bucket, object string) (ObjectInfo, *probe.Error) {
 	// Input validation.
 	if !IsValidBucketName(bucket) {
-		return ObjectInfo{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+		return ObjectInfo{}, probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket, object)
 	}
 
 	if !IsValidObjectName(object) {
-		return ObjectInfo{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+		return ObjectInfo{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
 	}
 
+	if e := fs.checkRootPath(); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
 	// Normalize buckets.
 	bucket = getActualBucketname(fs.path, bucket)
 	bucketPath := filepath.Join(fs.path, bucket)
 	if _, e := os.Stat(bucketPath); e != nil {
 		if os.IsNotExist(e) {
-			return ObjectInfo{}, probe.NewError(BucketNotFound{Bucket: bucket})
+			return ObjectInfo{}, probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket, object)
 		}
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
 	}
 
 	info, err := getObjectInfo(fs.path, bucket, object)
 	if err != nil {
 		if os.IsNotExist(err.ToGoError()) {
-			return ObjectInfo{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+			return ObjectInfo{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object}).Trace(bucket, object)
+		}
+		// A parent of the object being a file is a missing object too.
+		if pathErr, ok := err.ToGoError().(*os.PathError); ok && pathErr.Err == syscall.ENOTDIR {
+			return ObjectInfo{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object}).Trace(bucket, object)
 		}
 		return ObjectInfo{}, err.Trace(bucket, object)
 	}
 	if info.IsDir {
-		return ObjectInfo{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+		return ObjectInfo{}, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object}).Trace(bucket, object)
 	}
+	info.Owner = getOwner()
+	info.StorageClass = storageClassStandard
+
+	// Fill in persisted object metadata, if any.
+	objMeta, e := fs.readObjectMetadata(bucket, object)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return info, nil
+		}
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	info.MD5Sum = objMeta.MD5Sum
+	if objMeta.ContentType != "" {
+		info.ContentType = objMeta.ContentType
+	}
+	if objMeta.StorageClass != "" {
+		info.StorageClass = objMeta.StorageClass
+	}
+	info.contentHeaders = objMeta.contentHeaders
+	info.UserDefined = objMeta.UserDefined
 	return info, nil
 }
 
+// ObjectExists - reports whether an object exists without opening it or
+// reading its metadata, only regular files are objects. A missing
+// bucket is an error, a missing object is not.
+func (fs Filesystem) ObjectExists(bucket, object string) (bool, *probe.Error) {
+	bucket, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return false, probe.NewError(e).Trace(bucket, object)
+	}
+	if !IsValidObjectName(object) {
+		return false, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+	if isInternalName(object) {
+		return false, nil
+	}
+	st, e := os.Lstat(filepath.Join(fs.path, bucket, objectStorageName(object)))
+	if e != nil {
+		// A parent of the object being a file is a missing object too.
+		if os.IsNotExist(e) {
+			return false, nil
+		}
+		if pathErr, ok := e.(*os.PathError); ok && pathErr.Err == syscall.ENOTDIR {
+			return false, nil
+		}
+		return false, probe.NewError(e).Trace(bucket, object)
+	}
+	return isObjectFileMode(st.Mode()), nil
+}
+
 // getObjectInfo - get object stat info.
 func getObjectInfo(rootPath, bucket, object string) (ObjectInfo, *probe.Error) {
 	// Do not use filepath.Join() since filepath.Join strips off any
@@ -126,11 +253,11 @@ func getObjectInfo(rootPath, bucket, object string) (ObjectInfo, *probe.Error) {
 	var objectPath string
 	// For windows use its special os.PathSeparator == "\\"
 	if runtime.GOOS == "windows" {
-		objectPath = rootPath + string(os.PathSeparator) + bucket + string(os.PathSeparator) + object
+		objectPath = rootPath + string(os.PathSeparator) + bucket + string(os.PathSeparator) + objectStorageName(object)
 	} else {
-		objectPath = rootPath + string(os.PathSeparator) + bucket + string(os.PathSeparator) + object
+		objectPath = rootPath + string(os.PathSeparator) + bucket + string(os.PathSeparator) + objectStorageName(object)
 	}
-	stat, e := os.Stat(objectPath)
+	stat, e := lstatObject(objectPath)
 	if e != nil {
 		return ObjectInfo{}, probe.NewError(e)
 	}
@@ -148,7 +275,7 @@ func getObjectInfo(rootPath, bucket, object string) (ObjectInfo, *probe.Error) {
 	metadata := ObjectInfo{
 		Bucket:       bucket,
 		Name:         object,
-		ModifiedTime: stat.ModTime(),
+		ModifiedTime: stat.ModTime().Truncate(time.Second),
 		Size:         stat.Size(),
 		ContentType:  contentType,
 		IsDir:        stat.Mode().IsDir(),
@@ -179,115 +306,301 @@ func isMD5SumEqual(expectedMD5Sum, actualMD5Sum string) bool {
 	return false
 }
 
+// checkObjectCollision - verify object can be created in bucketPath,
+// none of its parent prefixes may be an existing object and the object
+// name must not be an existing prefix.
+func checkObjectCollision(bucketPath, bucket, object string) *probe.Error {
+	dirPath := bucketPath
+	elements := strings.Split(filepath.FromSlash(object), string(os.PathSeparator))
+	for i, element := range elements {
+		dirPath = filepath.Join(dirPath, element)
+		st, e := os.Stat(dirPath)
+		if e != nil {
+			if os.IsNotExist(e) {
+				// Nothing exists further down the path.
+				return nil
+			}
+			return probe.NewError(e)
+		}
+		if i == len(elements)-1 {
+			if st.IsDir() {
+				return probe.NewError(PrefixExistsAsObject{Bucket: bucket, Object: object})
+			}
+		} else if !st.IsDir() {
+			return probe.NewError(ObjectExistsAsPrefix{Bucket: bucket, Prefix: object})
+		}
+	}
+	return nil
+}
+
+// Number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// extensionContentType - returns the content type of an object based
+// on its extension, empty if unknown.
+func extensionContentType(object string) string {
+	if objectExt := filepath.Ext(object); objectExt != "" {
+		if content, ok := mimedb.DB[strings.ToLower(strings.TrimPrefix(objectExt, "."))]; ok {
+			return content.ContentType
+		}
+	}
+	return ""
+}
+
+// sniffContentType - detects the content type of data, empty when
+// only the generic text or binary fallbacks match.
+func sniffContentType(head []byte) string {
+	switch contentType := http.DetectContentType(head); contentType {
+	case "application/octet-stream", "text/plain; charset=utf-8":
+		return ""
+	default:
+		return contentType
+	}
+}
+
+// PrecheckPut - verifies a put of size bytes can succeed before its
+// data is read, so clients waiting on "Expect: 100-continue" are
+// rejected without sending the body. Checks the free disk space, the
+// bucket, the object name, the maximum object size and the bucket
+// quota, the same checks are repeated while writing.
+func (fs Filesystem) PrecheckPut(bucket, object string, size int64) *probe.Error {
+	if e := fs.checkDiskFree(); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	bucket, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	if !IsValidObjectName(object) || isInternalName(object) {
+		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+
+	// Size of streaming writes is not known in advance, their size and
+	// quota are verified while writing.
+	if size <= 0 {
+		return nil
+	}
+	if fs.maxObjectSize > 0 && size > fs.maxObjectSize {
+		return probe.NewError(EntityTooLarge{Size: size, MaxSize: fs.maxObjectSize}).Trace(bucket, object)
+	}
+	oldSize, _ := existingObjectSize(filepath.Join(fs.path, bucket, objectStorageName(object)))
+	if e = fs.checkBucketQuota(bucket, oldSize, size); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	return nil
+}
+
 // PutObject - create an object.
 func (fs Filesystem) PutObject(bucket string, object string, size int64, data io.Reader, metadata map[string]string) (ObjectInfo, *probe.Error) {
+	objInfo, err := fs.putObject(bucket, object, size, fs.limitReader(data), metadata)
+	fs.metrics.record(&fs.metrics.putObject, err)
+	// The object may have changed even if the put failed part way.
+	fs.listCache.invalidate(bucket)
+	if err == nil {
+		fs.events.notify(Event{Type: ObjectCreatedPut, Bucket: bucket, Object: object, Size: objInfo.Size, ETag: objInfo.MD5Sum})
+	}
+	return objInfo, err
+}
+
+// putObject - see PutObject.
+func (fs Filesystem) putObject(bucket string, object string, size int64, data io.Reader, metadata map[string]string) (ObjectInfo, *probe.Error) {
+	if e := fs.checkRootPath(); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
 	di, e := disk.GetInfo(fs.path)
 	if e != nil {
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
 	}
 
 	// Remove 5% from total space for cumulative disk space used for
 	// journalling, inodes etc.
 	availableDiskSpace := (float64(di.Free) / (float64(di.Total) - (0.05 * float64(di.Total)))) * 100
 	if int64(availableDiskSpace) <= fs.minFreeDisk {
-		return ObjectInfo{}, probe.NewError(RootPathFull{Path: fs.path})
+		return ObjectInfo{}, probe.NewError(RootPathFull{Path: fs.path}).Trace(bucket, object)
 	}
 
 	// Check bucket name valid.
 	if !IsValidBucketName(bucket) {
-		return ObjectInfo{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+		return ObjectInfo{}, probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket, object)
 	}
 
 	bucket = getActualBucketname(fs.path, bucket)
 	bucketPath := filepath.Join(fs.path, bucket)
 	if _, e = os.Stat(bucketPath); e != nil {
 		if os.IsNotExist(e) {
-			return ObjectInfo{}, probe.NewError(BucketNotFound{Bucket: bucket})
+			return ObjectInfo{}, probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket, object)
 		}
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
 	}
 
-	// Verify object path legal.
-	if !IsValidObjectName(object) {
-		return ObjectInfo{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+	// Verify object path legal, internal names would not be listed.
+	if !IsValidObjectName(object) || isInternalName(object) {
+		return ObjectInfo{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+
+	// Verify storage class is supported.
+	storageClass, e := checkStorageClass(metadata["storageClass"])
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	headers, e := parseContentHeaders(metadata)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	userDefined, e := parseUserMetadata(metadata)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+
+	// Serialize writers of the same object.
+	fs.nsLock.lock(bucket, object)
+	defer fs.nsLock.unlock(bucket, object)
+
+	// Locked objects can not be overwritten.
+	if e := fs.checkObjectLock(bucket, object); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	oldBlob := fs.objectBlob(bucket, object)
+
+	// Verify object name does not collide with existing prefixes.
+	if err := checkObjectCollision(bucketPath, bucket, objectStorageName(object)); err != nil {
+		return ObjectInfo{}, err.Trace(bucket, object)
 	}
 
 	// Get object path.
-	objectPath := filepath.Join(bucketPath, object)
+	objectPath := filepath.Join(bucketPath, objectStorageName(object))
+
+	contentType := metadata["contentType"]
+
+	// Verify object size is within the configured maximum, the size of
+	// streaming writes is verified while writing.
+	if fs.maxObjectSize > 0 {
+		if size > fs.maxObjectSize {
+			return ObjectInfo{}, probe.NewError(EntityTooLarge{Size: size, MaxSize: fs.maxObjectSize}).Trace(bucket, object)
+		}
+		data = &maxSizeReader{reader: data, maxSize: fs.maxObjectSize}
+	}
 
-	// md5Hex representation.
-	var md5Hex string
-	if len(metadata) != 0 {
-		md5Hex = metadata["md5Sum"]
+	// Verify the bucket quota, the size of streaming writes is not
+	// known in advance and their quota is reserved while writing.
+	oldSize, replaced := existingObjectSize(objectPath)
+	if size > 0 {
+		release, e := fs.reserveBucketQuota(bucket, oldSize, size)
+		if e != nil {
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+		}
+		// Released once the usage of the bucket includes the write.
+		defer release()
+	} else {
+		quota, e := fs.readBucketQuota(bucket)
+		if e != nil {
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+		}
+		if quota > 0 {
+			quotaData := &quotaReader{reader: data, fs: fs, bucket: bucket, oldSize: oldSize, release: func() {}}
+			// Released once the usage of the bucket includes the write.
+			defer func() { quotaData.release() }()
+			data = quotaData
+		}
+	}
+
+	// Without an explicit or extension based content type, detect it
+	// from the beginning of the data without consuming it.
+	if contentType == "" && extensionContentType(object) == "" {
+		bufReader := bufio.NewReaderSize(data, sniffLen)
+		// Read errors are returned again while writing.
+		if head, _ := bufReader.Peek(sniffLen); len(head) > 0 {
+			contentType = sniffContentType(head)
+		}
+		data = bufReader
+	}
+
+	// Checksum for internal integrity verification.
+	checksumHasher, e := newChecksumHasher(fs.checksumAlgo)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+
+	// Content sha256sum of deduplicated objects.
+	hashWriter := io.Writer(checksumHasher)
+	dedupHasher := sha256.New()
+	if fs.dedup {
+		hashWriter = io.MultiWriter(checksumHasher, dedupHasher)
 	}
 
 	// Write object.
-	safeFile, e := safe.CreateFileWithPrefix(objectPath, md5Hex+"$tmpobject")
+	written, newMD5Hex, e := fs.safeWriteFile(objectPath, io.TeeReader(data, hashWriter), size, transferChecks(metadata))
 	if e != nil {
 		switch e := e.(type) {
 		case *os.PathError:
 			if e.Op == "mkdir" {
 				if strings.Contains(e.Error(), "not a directory") {
-					return ObjectInfo{}, probe.NewError(ObjectExistsAsPrefix{Bucket: bucket, Prefix: object})
+					return ObjectInfo{}, probe.NewError(ObjectExistsAsPrefix{Bucket: bucket, Prefix: object}).Trace(bucket, object)
 				}
 			}
-			return ObjectInfo{}, probe.NewError(e)
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
 		default:
-			return ObjectInfo{}, probe.NewError(e)
+			// Client sent fewer bytes than it declared.
+			if e == io.ErrUnexpectedEOF {
+				return ObjectInfo{}, probe.NewError(IncompleteBody{Bucket: bucket, Object: object}).Trace(bucket, object)
+			}
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
 		}
 	}
 
-	// Initialize md5 writer.
-	md5Writer := md5.New()
-
-	// Instantiate a new multi writer.
-	multiWriter := io.MultiWriter(md5Writer, safeFile)
-
-	// Instantiate checksum hashers and create a multiwriter.
-	if size > 0 {
-		if _, e = io.CopyN(multiWriter, data, size); e != nil {
-			safeFile.CloseAndRemove()
-			return ObjectInfo{}, probe.NewError(e)
-		}
+	if replaced {
+		fs.addBucketUsage(bucket, object, 0, written-oldSize)
 	} else {
-		if _, e = io.Copy(multiWriter, data); e != nil {
-			safeFile.CloseAndRemove()
-			return ObjectInfo{}, probe.NewError(e)
-		}
+		fs.addBucketUsage(bucket, object, 1, written)
 	}
+	blob := fs.dedupWrittenObject(objectPath, dedupHasher, oldBlob)
 
-	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
-	if md5Hex != "" {
-		if newMD5Hex != md5Hex {
-			return ObjectInfo{}, probe.NewError(BadDigest{md5Hex, newMD5Hex})
-		}
+	// Persist object metadata.
+	objMeta := fsObjectMetadata{
+		MD5Sum:         newMD5Hex,
+		ContentType:    contentType,
+		contentHeaders: headers,
+		UserDefined:    userDefined,
+		StorageClass:   storageClass,
+		Checksum: checksumInfo{
+			Algorithm: fs.checksumAlgo,
+			Hash:      hex.EncodeToString(checksumHasher.Sum(nil)),
+		},
+		Blob: blob,
+	}
+	if e = fs.writeObjectMetadata(bucket, object, objMeta); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	// A replaced object completed by multipart leaves its parts.
+	if e = fs.removeObjectPartsLayout(bucket, object); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
 	}
 
 	// Set stat again to get the latest metadata.
-	st, e := os.Stat(safeFile.Name())
+	st, e := os.Stat(objectPath)
 	if e != nil {
-		return ObjectInfo{}, probe.NewError(e)
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
 	}
 
-	contentType := "application/octet-stream"
-	if objectExt := filepath.Ext(objectPath); objectExt != "" {
-		content, ok := mimedb.DB[strings.ToLower(strings.TrimPrefix(objectExt, "."))]
-		if ok {
-			contentType = content.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+		if extContentType := extensionContentType(object); extContentType != "" {
+			contentType = extContentType
 		}
 	}
 	newObject := ObjectInfo{
-		Bucket:       bucket,
-		Name:         object,
-		ModifiedTime: st.ModTime(),
-		Size:         st.Size(),
-		MD5Sum:       newMD5Hex,
-		ContentType:  contentType,
+		Bucket:         bucket,
+		Name:           object,
+		ModifiedTime:   st.ModTime().Truncate(time.Second),
+		Size:           written,
+		MD5Sum:         newMD5Hex,
+		ContentType:    contentType,
+		Owner:          getOwner(),
+		StorageClass:   storageClass,
+		contentHeaders: headers,
+		UserDefined:    userDefined,
 	}
 
-	// Safely close and atomically rename the file.
-	safeFile.Close()
-
 	return newObject, nil
 }
 
@@ -327,24 +640,47 @@ func deleteObjectPath(basePath, deletePath, bucket, object string) *probe.Error
 
 // DeleteObject - delete object.
 func (fs Filesystem) DeleteObject(bucket, object string) *probe.Error {
+	err := fs.deleteObject(bucket, object)
+	// The object may have changed even if the delete failed part way.
+	fs.listCache.invalidate(bucket)
+	if err == nil {
+		fs.events.notify(Event{Type: ObjectRemovedDelete, Bucket: bucket, Object: object})
+	}
+	return err
+}
+
+// deleteObject - see DeleteObject.
+func (fs Filesystem) deleteObject(bucket, object string) *probe.Error {
 	// Check bucket name valid
 	if !IsValidBucketName(bucket) {
-		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+		return probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket, object)
 	}
 
+	if e := fs.checkRootPath(); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
 	bucket = getActualBucketname(fs.path, bucket)
 	bucketPath := filepath.Join(fs.path, bucket)
 	// Check bucket exists
 	if _, e := os.Stat(bucketPath); e != nil {
 		if os.IsNotExist(e) {
-			return probe.NewError(BucketNotFound{Bucket: bucket})
+			return probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket, object)
 		}
-		return probe.NewError(e)
+		return probe.NewError(e).Trace(bucket, object)
 	}
 
 	// Verify object path legal
 	if !IsValidObjectName(object) {
-		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object})
+		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+
+	// Serialize with writers of the same object.
+	fs.nsLock.lock(bucket, object)
+	defer fs.nsLock.unlock(bucket, object)
+
+	// Locked objects can not be deleted.
+	if e := fs.checkObjectLock(bucket, object); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
 	}
 
 	// Do not use filepath.Join() since filepath.Join strips off any
@@ -353,17 +689,164 @@ func (fs Filesystem) DeleteObject(bucket, object string) *probe.Error {
 	// os.Stat().
 	var objectPath string
 	if runtime.GOOS == "windows" {
-		objectPath = fs.path + string(os.PathSeparator) + bucket + string(os.PathSeparator) + object
+		objectPath = fs.path + string(os.PathSeparator) + bucket + string(os.PathSeparator) + objectStorageName(object)
 	} else {
-		objectPath = fs.path + string(os.PathSeparator) + bucket + string(os.PathSeparator) + object
+		objectPath = fs.path + string(os.PathSeparator) + bucket + string(os.PathSeparator) + objectStorageName(object)
 	}
 	// Delete object path if its empty.
+	oldSize, removed := existingObjectSize(objectPath)
+	blob := fs.objectBlob(bucket, object)
 	err := deleteObjectPath(bucketPath, objectPath, bucket, object)
 	if err != nil {
 		if os.IsNotExist(err.ToGoError()) {
-			return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object})
+			return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object}).Trace(bucket, object)
 		}
 		return err.Trace(bucketPath, objectPath, bucket, object)
 	}
+	if removed {
+		fs.addBucketUsage(bucket, object, -1, -oldSize)
+	}
+	if blob != "" {
+		fs.releaseObjectBlob(blob)
+	}
+	// Remove object metadata.
+	if e := fs.removeObjectMetadata(bucket, object); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	// Remove object tags.
+	if e := fs.removeObjectTags(bucket, object); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	// Remove object parts layout.
+	if e := fs.removeObjectPartsLayout(bucket, object); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	// Remove expired object lock.
+	if e := fs.removeObjectLock(bucket, object); e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
 	return nil
 }
+
+// DeleteObjectIfExists - delete an object, returns false without an
+// error when the object does not exist.
+func (fs Filesystem) DeleteObjectIfExists(bucket, object string) (bool, *probe.Error) {
+	if err := fs.DeleteObject(bucket, object); err != nil {
+		if _, ok := err.ToGoError().(ObjectNotFound); ok {
+			return false, nil
+		}
+		return false, err.Trace(bucket, object)
+	}
+	return true, nil
+}
+
+// DeleteObjects - delete multiple objects, objects which could not be
+// deleted are reported as delete errors without failing the rest. In
+// quiet mode objects which do not exist are not reported.
+func (fs Filesystem) DeleteObjects(bucket string, objects []string, quiet bool) ([]DeleteError, *probe.Error) {
+	// Check bucket name valid
+	if !IsValidBucketName(bucket) {
+		return nil, probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket)
+	}
+
+	if e := fs.checkRootPath(); e != nil {
+		return nil, probe.NewError(e).Trace(bucket)
+	}
+	bucket = getActualBucketname(fs.path, bucket)
+	// Check bucket exists
+	if _, e := os.Stat(filepath.Join(fs.path, bucket)); e != nil {
+		if os.IsNotExist(e) {
+			return nil, probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket)
+		}
+		return nil, probe.NewError(e).Trace(bucket)
+	}
+
+	if len(objects) > maxObjectList {
+		return nil, probe.NewError(fmt.Errorf("Cannot delete more than %d objects in a single request", maxObjectList)).Trace(bucket)
+	}
+
+	var deleteErrors []DeleteError
+	for _, object := range objects {
+		err := fs.DeleteObject(bucket, object)
+		if err == nil {
+			continue
+		}
+		if _, ok := err.ToGoError().(ObjectNotFound); ok && quiet {
+			continue
+		}
+		deleteErrors = append(deleteErrors, generateDeleteError(object, err))
+	}
+	return deleteErrors, nil
+}
+
+// DeletePrefix - delete all objects under prefix, returns the number
+// of objects deleted. An empty prefix deletes every object in the
+// bucket and is refused unless force is set.
+func (fs Filesystem) DeletePrefix(bucket, prefix string, force bool) (int, *probe.Error) {
+	// Check bucket name valid
+	if !IsValidBucketName(bucket) {
+		return 0, probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket, prefix)
+	}
+
+	if e := fs.checkRootPath(); e != nil {
+		return 0, probe.NewError(e).Trace(bucket, prefix)
+	}
+	bucket = getActualBucketname(fs.path, bucket)
+	bucketDir := filepath.Join(fs.path, bucket)
+	// Check bucket exists
+	if _, e := os.Stat(bucketDir); e != nil {
+		if os.IsNotExist(e) {
+			return 0, probe.NewError(BucketNotFound{Bucket: bucket}).Trace(bucket, prefix)
+		}
+		return 0, probe.NewError(e).Trace(bucket, prefix)
+	}
+
+	if !IsValidObjectPrefix(prefix) {
+		return 0, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: prefix}).Trace(bucket, prefix)
+	}
+	if prefix == "" && !force {
+		return 0, probe.NewError(OperationNotPermitted{Op: "DeletePrefix", Reason: "empty prefix deletes all objects in " + bucket}).Trace(bucket, prefix)
+	}
+
+	// Prefix does not exist, nothing to delete.
+	prefixDir := filepath.Dir(filepath.FromSlash(prefix))
+	if status, e := isDirExist(filepath.Join(bucketDir, prefixDir)); !status {
+		if e == nil {
+			return 0, nil
+		}
+		return 0, probe.NewError(e).Trace(bucket, prefix)
+	}
+
+	// Gather all objects before deleting, the walk reads directories
+	// which are removed as they become empty.
+	var objects []string
+	walker := startTreeWalk(fs.path, bucket, filepath.FromSlash(prefix), "", true, fs.closed)
+	for walkResult := range walker.ch {
+		if walkResult.err != nil {
+			return 0, probe.NewError(walkResult.err).Trace(bucket, prefix)
+		}
+		objInfo := walkResult.objectInfo
+		objInfo.Name = filepath.ToSlash(objInfo.Name)
+		// Skip temporary and sidecar files.
+		if isInternalName(objInfo.Name) {
+			continue
+		}
+		if objInfo.IsDir {
+			continue
+		}
+		objects = append(objects, objInfo.Name)
+	}
+
+	deleted := 0
+	for _, object := range objects {
+		if err := fs.DeleteObject(bucket, object); err != nil {
+			// Deleted concurrently.
+			if _, ok := err.ToGoError().(ObjectNotFound); ok {
+				continue
+			}
+			return deleted, err.Trace(bucket, object)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -19,15 +19,26 @@ package main
 import (
 	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/disk"
+	"github.com/minio/minio/pkg/probe"
 )
 
 // Testing GetObjectInfo().
@@ -205,51 +216,2549 @@ func TestGetObjectInfoCore(t *testing.T) {
 	}
 }
 
-func BenchmarkGetObject(b *testing.B) {
-	// Make a temporary directory to use as the fs.
-	directory, e := ioutil.TempDir("", "minio-benchmark-getobject")
+// Testing PutObject() with a body shorter than the declared size.
+func TestPutObjectIncompleteBody(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-put-object-test")
 	if e != nil {
-		b.Fatal(e)
+		t.Fatal(e)
 	}
 	defer os.RemoveAll(directory)
 
 	// Create the fs.
 	fs, err := newFS(directory)
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-putobject")
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	// Make a bucket and put in a few objects.
-	err = fs.MakeBucket("bucket")
+	// Declared size is larger than the data actually sent.
+	_, err = fs.PutObject("test-putobject", "Asia/asiapics.jpg", int64(len("asiapics"))+10, bytes.NewBufferString("asiapics"), nil)
+	if err == nil {
+		t.Fatal("Expected PutObject to fail with a short body, but it passed instead")
+	}
+	if _, ok := err.ToGoError().(IncompleteBody); !ok {
+		t.Fatalf("Expected IncompleteBody error, but instead found \"%s\"", err.Cause.Error())
+	}
+
+	// Nothing should have been committed, neither the object nor a temporary file.
+	if _, e = os.Stat(filepath.Join(directory, "test-putobject", "Asia", "asiapics.jpg")); !os.IsNotExist(e) {
+		t.Fatalf("Expected object not to be committed, but stat returned: %v", e)
+	}
+	entries, e := ioutil.ReadDir(filepath.Join(directory, "test-putobject", "Asia"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Expected no leftover temporary files, found %d entries", len(entries))
+	}
+
+	// Object size is populated from the bytes actually written.
+	objInfo, err := fs.PutObject("test-putobject", "Asia/asiapics.jpg", 0, bytes.NewBufferString("asiapics"), nil)
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal(err)
 	}
+	if objInfo.Size != int64(len("asiapics")) {
+		t.Fatalf("Expected object size to be %d, but instead found %d", len("asiapics"), objInfo.Size)
+	}
+}
 
-	text := "Jack and Jill went up the hill / To fetch a pail of water."
-	hasher := md5.New()
-	hasher.Write([]byte(text))
-	metadata := make(map[string]string)
-	for i := 0; i < 10; i++ {
-		metadata["md5Sum"] = hex.EncodeToString(hasher.Sum(nil))
-		_, err = fs.PutObject("bucket", "object"+strconv.Itoa(i), int64(len(text)), bytes.NewBufferString(text), metadata)
+// Testing zero byte PutObject() returns the md5sum of empty content.
+func TestPutObjectEmpty(t *testing.T) {
+	execObjectLayerTest(t, testPutObjectEmpty)
+}
+
+func testPutObjectEmpty(t *testing.T, fs ObjectLayer) {
+	if err := fs.MakeBucket("test-putobject"); err != nil {
+		t.Fatal(err)
+	}
+	emptyMD5 := "d41d8cd98f00b204e9800998ecf8427e"
+
+	objInfo, err := fs.PutObject("test-putobject", "empty", 0, bytes.NewReader(nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.MD5Sum != emptyMD5 {
+		t.Errorf("Expected ETag %s, but instead found %s", emptyMD5, objInfo.MD5Sum)
+	}
+	objInfo, err = fs.GetObjectInfo("test-putobject", "empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != 0 || objInfo.MD5Sum != emptyMD5 {
+		t.Errorf("Expected size 0 and ETag %s, but instead found size %d and ETag %s", emptyMD5, objInfo.Size, objInfo.MD5Sum)
+	}
+
+	// The fs stores an empty file.
+	if filesystem, ok := fs.(*Filesystem); ok {
+		st, e := os.Stat(filepath.Join(filesystem.path, "test-putobject", "empty"))
+		if e != nil {
+			t.Fatal(e)
+		}
+		if st.Size() != 0 {
+			t.Errorf("Expected an empty file, but instead found %d bytes", st.Size())
+		}
+	}
+}
+
+// Testing GetObjectVerified() with all supported checksum algorithms.
+func TestGetObjectVerified(t *testing.T) {
+	for _, algorithm := range []string{checksumMD5, checksumSHA256} {
+		directory, e := ioutil.TempDir("", "minio-get-object-verified-test")
+		if e != nil {
+			t.Fatal(e)
+		}
+		defer os.RemoveAll(directory)
+
+		// Create the fs.
+		fs, err := newFS(directory)
 		if err != nil {
-			b.Fatal(err)
+			t.Fatal(err)
+		}
+		fs.(*Filesystem).checksumAlgo = algorithm
+
+		err = fs.MakeBucket("test-verified")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := "Jack and Jill went up the hill"
+		_, err = fs.PutObject("test-verified", "Asia/jack.txt", int64(len(data)), bytes.NewBufferString(data), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Object data is intact.
+		reader, err := fs.(*Filesystem).GetObjectVerified("test-verified", "Asia/jack.txt")
+		if err != nil {
+			t.Fatalf("%s: %s", algorithm, err.Cause.Error())
+		}
+		readData, e := ioutil.ReadAll(reader)
+		reader.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if string(readData) != data {
+			t.Fatalf("%s: Expected object data \"%s\", but instead found \"%s\"", algorithm, data, string(readData))
+		}
+
+		// Corrupt object data of the same size on disk.
+		corrupted := strings.ToUpper(data)
+		if e = ioutil.WriteFile(filepath.Join(directory, "test-verified", "Asia", "jack.txt"), []byte(corrupted), 0600); e != nil {
+			t.Fatal(e)
+		}
+		_, err = fs.(*Filesystem).GetObjectVerified("test-verified", "Asia/jack.txt")
+		if err == nil {
+			t.Fatalf("%s: Expected GetObjectVerified to fail on corrupted data, but it passed instead", algorithm)
+		}
+		if _, ok := err.ToGoError().(ObjectCorrupted); !ok {
+			t.Fatalf("%s: Expected ObjectCorrupted error, but instead found \"%s\"", algorithm, err.Cause.Error())
+		}
+
+		// Object metadata is removed along with the object.
+		err = fs.DeleteObject("test-verified", "Asia/jack.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, e = os.Stat(filepath.Join(directory, configDir, "test-verified", "Asia")); !os.IsNotExist(e) {
+			t.Fatalf("%s: Expected object metadata to be removed", algorithm)
 		}
 	}
+}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		var buffer = new(bytes.Buffer)
-		r, err := fs.GetObject("bucket", "object"+strconv.Itoa(i%10), 0)
+// Testing GetObjectVerified() repairs corrupted objects from the mirror.
+func TestGetObjectVerifiedMirror(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-get-object-verified-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+	mirror, e := ioutil.TempDir("", "minio-mirror-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(mirror)
+
+	// Create the fs.
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	fs.mirrorPath = mirror
+
+	err = fs.MakeBucket("test-verified")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := "Jack and Jill went up the hill"
+	_, err = fs.PutObject("test-verified", "Asia/jack.txt", int64(len(data)), bytes.NewBufferString(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectPath := filepath.Join(directory, "test-verified", "Asia", "jack.txt")
+	mirrorPath := filepath.Join(mirror, "test-verified", "Asia", "jack.txt")
+	if e = os.MkdirAll(filepath.Dir(mirrorPath), 0700); e != nil {
+		t.Fatal(e)
+	}
+
+	testCases := []struct {
+		mirrored   string
+		shouldPass bool
+	}{
+		// Missing mirror copy.
+		{"", false},
+		// Corrupted mirror copy.
+		{strings.ToLower(data), false},
+		{data, true},
+	}
+	for i, testCase := range testCases {
+		if testCase.mirrored != "" {
+			if e = ioutil.WriteFile(mirrorPath, []byte(testCase.mirrored), 0600); e != nil {
+				t.Fatal(e)
+			}
+		}
+		if e = ioutil.WriteFile(objectPath, []byte(strings.ToUpper(data)), 0600); e != nil {
+			t.Fatal(e)
+		}
+		reader, err := fs.GetObjectVerified("test-verified", "Asia/jack.txt")
 		if err != nil {
-			b.Error(err)
+			if testCase.shouldPass {
+				t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+			} else if _, ok := err.ToGoError().(ObjectCorrupted); !ok {
+				t.Errorf("Test %d: Expected ObjectCorrupted error, but instead found \"%s\"", i+1, err.Cause.Error())
+			}
+			continue
 		}
-		if _, e := io.Copy(buffer, r); e != nil {
-			b.Error(e)
+		if !testCase.shouldPass {
+			reader.Close()
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+			continue
 		}
-		if buffer.Len() != len(text) {
-			b.Errorf("GetObject returned incorrect length %d (should be %d)\n", buffer.Len(), len(text))
+		readData, e := ioutil.ReadAll(reader)
+		reader.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if string(readData) != data {
+			t.Errorf("Test %d: Expected \"%s\", but instead found \"%s\"", i+1, data, string(readData))
+		}
+		// The local copy is repaired.
+		localData, e := ioutil.ReadFile(objectPath)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if string(localData) != data {
+			t.Errorf("Test %d: Expected the local copy to be repaired, but instead found \"%s\"", i+1, string(localData))
+		}
+	}
+}
+
+// Testing DeleteObjects() with existing and missing objects.
+func TestDeleteObjects(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-delete-objects-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-delete")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		quiet     bool
+		objects   []string
+		errorKeys []string
+	}{
+		// Missing objects are reported in verbose mode.
+		{false, []string{"obj1", "missing1", "Asia/obj2", "missing2"}, []string{"missing1", "missing2"}},
+		// Missing objects are not reported in quiet mode.
+		{true, []string{"obj1", "missing1", "Asia/obj2"}, nil},
+		// Invalid object names are reported in quiet mode as well.
+		{true, []string{"obj1", "", "Asia/obj2"}, []string{""}},
+	}
+	for i, testCase := range testCases {
+		for _, object := range []string{"obj1", "Asia/obj2"} {
+			_, err = fs.PutObject("test-delete", object, int64(len("hello")), bytes.NewBufferString("hello"), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		deleteErrors, err := fs.(*Filesystem).DeleteObjects("test-delete", testCase.objects, testCase.quiet)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		if len(deleteErrors) != len(testCase.errorKeys) {
+			t.Fatalf("Test %d: Expected %d delete errors, but instead found %d", i+1, len(testCase.errorKeys), len(deleteErrors))
+		}
+		for j, deleteError := range deleteErrors {
+			if deleteError.Key != testCase.errorKeys[j] {
+				t.Errorf("Test %d: Expected delete error for \"%s\", but instead found \"%s\"", i+1, testCase.errorKeys[j], deleteError.Key)
+			}
+			if deleteError.Code != "NoSuchKey" {
+				t.Errorf("Test %d: Expected delete error code NoSuchKey, but instead found %s", i+1, deleteError.Code)
+			}
+		}
+		// Existing objects are deleted regardless of failures.
+		for _, object := range []string{"obj1", "Asia/obj2"} {
+			if _, err = fs.GetObjectInfo("test-delete", object); err == nil {
+				t.Errorf("Test %d: Expected object \"%s\" to be deleted", i+1, object)
+			}
+		}
+	}
+
+	// Missing bucket fails the whole batch.
+	_, err = fs.(*Filesystem).DeleteObjects("missing-bucket", []string{"obj1"}, false)
+	if err == nil {
+		t.Fatal("Expected DeleteObjects to fail on a missing bucket, but it passed instead")
+	}
+	if _, ok := err.ToGoError().(BucketNotFound); !ok {
+		t.Fatalf("Expected BucketNotFound error, but instead found \"%s\"", err.Cause.Error())
+	}
+}
+
+// Testing DeleteObjectIfExists() with existing and missing objects.
+func TestDeleteObjectIfExists(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-delete-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("bucket", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		bucket          string
+		object          string
+		expectedDeleted bool
+		shouldPass      bool
+	}{
+		// Existing object is deleted.
+		{"bucket", "object", true, true},
+		// Object no longer exists.
+		{"bucket", "object", false, true},
+		// Object never existed.
+		{"bucket", "missing", false, true},
+		// Missing bucket is still an error.
+		{"missing-bucket", "object", false, false},
+	}
+	for i, testCase := range testCases {
+		deleted, err := filesystem.DeleteObjectIfExists(testCase.bucket, testCase.object)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if deleted != testCase.expectedDeleted {
+			t.Errorf("Test %d: Expected deleted to be %v, but instead found %v", i+1, testCase.expectedDeleted, deleted)
+		}
+	}
+	if _, err = fs.GetObjectInfo("bucket", "object"); err == nil {
+		t.Fatal("Expected object to be deleted")
+	}
+}
+
+// Testing PutObjectMetadata().
+func TestPutObjectMetadata(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-put-object-metadata-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := "Jack and Jill went up the hill"
+	objInfo, err := fs.PutObject("test-metadata", "Asia/jack.txt", int64(len(data)), bytes.NewBufferString(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.ContentType != "text/plain" {
+		t.Fatalf("Expected content type \"text/plain\", but instead found \"%s\"", objInfo.ContentType)
+	}
+
+	newInfo, err := fs.(*Filesystem).PutObjectMetadata("test-metadata", "Asia/jack.txt", map[string]string{"contentType": "application/json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newInfo.ContentType != "application/json" {
+		t.Fatalf("Expected content type \"application/json\", but instead found \"%s\"", newInfo.ContentType)
+	}
+	if newInfo.MD5Sum != objInfo.MD5Sum {
+		t.Fatalf("Expected md5sum \"%s\" to be preserved, but instead found \"%s\"", objInfo.MD5Sum, newInfo.MD5Sum)
+	}
+
+	// Data and metadata are persisted.
+	getInfo, err := fs.GetObjectInfo("test-metadata", "Asia/jack.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if getInfo.ContentType != "application/json" || getInfo.MD5Sum != objInfo.MD5Sum {
+		t.Fatalf("Expected persisted metadata, but instead found content type \"%s\" md5sum \"%s\"", getInfo.ContentType, getInfo.MD5Sum)
+	}
+	reader, err := fs.(*Filesystem).GetObjectVerified("test-metadata", "Asia/jack.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readData, e := ioutil.ReadAll(reader)
+	reader.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+	if string(readData) != data {
+		t.Fatalf("Expected object data \"%s\", but instead found \"%s\"", data, string(readData))
+	}
+
+	// Missing objects.
+	_, err = fs.(*Filesystem).PutObjectMetadata("test-metadata", "Asia/jill.txt", map[string]string{"contentType": "application/json"})
+	if err == nil {
+		t.Fatal("Expected PutObjectMetadata to fail on a missing object, but it passed instead")
+	}
+	if _, ok := err.ToGoError().(ObjectNotFound); !ok {
+		t.Fatalf("Expected ObjectNotFound error, but instead found \"%s\"", err.Cause.Error())
+	}
+}
+
+// Testing PutObject() with object names colliding with prefixes.
+func TestPutObjectPrefixCollision(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-put-object-collision-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-collision")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Prefix then object.
+	_, err = fs.PutObject("test-collision", "Asia/India/India-summer-photos-1", int64(len("photos")), bytes.NewBufferString("photos"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("test-collision", "Asia/India", int64(len("india")), bytes.NewBufferString("india"), nil)
+	if err == nil {
+		t.Fatal("Expected PutObject to fail on an existing prefix, but it passed instead")
+	}
+	if _, ok := err.ToGoError().(PrefixExistsAsObject); !ok {
+		t.Fatalf("Expected PrefixExistsAsObject error, but instead found \"%s\"", err.Cause.Error())
+	}
+
+	// Object then prefix.
+	_, err = fs.PutObject("test-collision", "Europe", int64(len("europe")), bytes.NewBufferString("europe"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("test-collision", "Europe/France/Paris", int64(len("paris")), bytes.NewBufferString("paris"), nil)
+	if err == nil {
+		t.Fatal("Expected PutObject to fail on an existing object, but it passed instead")
+	}
+	if _, ok := err.ToGoError().(ObjectExistsAsPrefix); !ok {
+		t.Fatalf("Expected ObjectExistsAsPrefix error, but instead found \"%s\"", err.Cause.Error())
+	}
+
+	// Existing objects are left untouched.
+	for _, object := range []string{"Asia/India/India-summer-photos-1", "Europe"} {
+		if _, err = fs.GetObjectInfo("test-collision", object); err != nil {
+			t.Fatalf("Expected object \"%s\" to exist, %s", object, err.Cause.Error())
+		}
+	}
+}
+
+// Testing concurrent PutObject() calls to the same object.
+func TestPutObjectConcurrent(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-concurrent-put-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-concurrent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every writer sends a distinct version of the same size.
+	writers := 10
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := strings.Repeat(strconv.Itoa(i%10), 1024*1024)
+			if _, err := fs.PutObject("test-concurrent", "object", int64(len(data)), bytes.NewBufferString(data), nil); err != nil {
+				errs[i] = err.ToGoError()
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, e := range errs {
+		if e != nil {
+			t.Fatalf("Writer %d: %s", i+1, e)
+		}
+	}
+
+	// Final object is one complete version with matching metadata.
+	reader, err := fs.GetObject("test-concurrent", "object", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	data, e := ioutil.ReadAll(reader)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(data) != 1024*1024 || strings.Count(string(data), string(data[0])) != len(data) {
+		t.Fatal("Expected object to be one complete version, but found a mix of versions")
+	}
+	objInfo, err := fs.GetObjectInfo("test-concurrent", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md5Sum := md5.Sum(data)
+	if objInfo.MD5Sum != hex.EncodeToString(md5Sum[:]) {
+		t.Fatalf("Expected md5sum %s of the final version, but instead found %s", hex.EncodeToString(md5Sum[:]), objInfo.MD5Sum)
+	}
+}
+
+// Testing PutObjectTags(), GetObjectTags() and DeleteObjectTags().
+func TestObjectTags(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-object-tags-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	err = fs.MakeBucket("test-tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("test-tags", "Asia/object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Object without tags.
+	tags, err := filesystem.GetObjectTags("test-tags", "Asia/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 0 {
+		t.Fatalf("Expected no tags, but instead found %v", tags)
+	}
+
+	// Set and get tags.
+	err = filesystem.PutObjectTags("test-tags", "Asia/object", map[string]string{"project": "minio", "stage": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err = filesystem.GetObjectTags("test-tags", "Asia/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 2 || tags["project"] != "minio" || tags["stage"] != "" {
+		t.Fatalf("Expected tags to be set, but instead found %v", tags)
+	}
+
+	// Tags sidecar is not listed.
+	result, err := fs.ListObjects("test-tags", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Name != "Asia/object" {
+		t.Fatalf("Expected only the object to be listed, but instead found %v", result.Objects)
+	}
+
+	// Delete tags.
+	err = filesystem.DeleteObjectTags("test-tags", "Asia/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err = filesystem.GetObjectTags("test-tags", "Asia/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 0 {
+		t.Fatalf("Expected tags to be removed, but instead found %v", tags)
+	}
+
+	// Tags of a missing object.
+	err = filesystem.PutObjectTags("test-tags", "missing", map[string]string{"project": "minio"})
+	if _, ok := err.ToGoError().(ObjectNotFound); !ok {
+		t.Fatalf("Expected ObjectNotFound error, but instead found \"%v\"", err)
+	}
+
+	// Tags are removed along with the object.
+	err = filesystem.PutObjectTags("test-tags", "Asia/object", map[string]string{"project": "minio"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.DeleteObject("test-tags", "Asia/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, e = os.Stat(filepath.Join(directory, configDir, "test-tags", "Asia")); !os.IsNotExist(e) {
+		t.Fatal("Expected object tags to be removed along with the object")
+	}
+}
+
+// Testing PutObjectTags() limits.
+func TestObjectTagsLimits(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-object-tags-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("test-tags", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= maxObjectTags; i++ {
+		tooMany["key"+strconv.Itoa(i)] = "value"
+	}
+	testCases := []struct {
+		tags       map[string]string
+		shouldPass bool
+	}{
+		{map[string]string{strings.Repeat("k", 128): strings.Repeat("v", 256)}, true},
+		{tooMany, false},
+		{map[string]string{strings.Repeat("k", 129): "value"}, false},
+		{map[string]string{"key": strings.Repeat("v", 257)}, false},
+		{map[string]string{"": "value"}, false},
+	}
+	for i, testCase := range testCases {
+		err = fs.(*Filesystem).PutObjectTags("test-tags", "object", testCase.tags)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if err != nil && !testCase.shouldPass {
+			if _, ok := err.ToGoError().(InvalidTag); !ok {
+				t.Errorf("Test %d: Expected InvalidTag error, but instead found \"%s\"", i+1, err.Cause.Error())
+			}
+		}
+	}
+}
+
+// Testing modified times are returned with second precision.
+func TestObjectModifiedTimePrecision(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-modtime-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-modtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err := fs.PutObject("test-modtime", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.ModifiedTime.Nanosecond() != 0 {
+		t.Fatalf("Expected PutObject modified time in whole seconds, but instead found %s", objInfo.ModifiedTime)
+	}
+
+	// Force a sub-second modification time on disk.
+	modTime := time.Date(2016, time.April, 1, 10, 20, 30, 123456789, time.UTC)
+	if e = os.Chtimes(filepath.Join(directory, "test-modtime", "object"), modTime, modTime); e != nil {
+		t.Fatal(e)
+	}
+	objInfo, err = fs.GetObjectInfo("test-modtime", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !objInfo.ModifiedTime.Equal(modTime.Truncate(time.Second)) {
+		t.Fatalf("Expected GetObjectInfo modified time %s, but instead found %s", modTime.Truncate(time.Second), objInfo.ModifiedTime)
+	}
+	result, err := fs.ListObjects("test-modtime", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].ModifiedTime.Nanosecond() != 0 {
+		t.Fatalf("Expected ListObjects modified time in whole seconds, but instead found %v", result.Objects)
+	}
+}
+
+// Testing DeletePrefix().
+func TestDeletePrefix(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-delete-prefix-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	err = fs.MakeBucket("test-delete-prefix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := []string{
+		"photos/2016/january/sample1.jpg",
+		"photos/2016/january/sample2.jpg",
+		"photos/2016/february/sample3.jpg",
+		"photos/2017/january/sample4.jpg",
+		"photos/2016.txt",
+		"readme.txt",
+	}
+	for _, object := range objects {
+		_, err = fs.PutObject("test-delete-prefix", object, int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Delete a populated prefix.
+	deleted, err := filesystem.DeletePrefix("test-delete-prefix", "photos/2016/", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 3 {
+		t.Fatalf("Expected 3 objects to be deleted, but instead found %d", deleted)
+	}
+	if _, e = os.Stat(filepath.Join(directory, "test-delete-prefix", "photos", "2016")); !os.IsNotExist(e) {
+		t.Fatal("Expected empty directories under the prefix to be removed")
+	}
+
+	// Sibling prefixes are untouched.
+	result, err := fs.ListObjects("test-delete-prefix", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, objInfo := range result.Objects {
+		names = append(names, objInfo.Name)
+	}
+	expected := []string{"photos/2016.txt", "photos/2017/january/sample4.jpg", "readme.txt"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected remaining objects %v, but instead found %v", expected, names)
+	}
+
+	// Missing prefix deletes nothing.
+	deleted, err = filesystem.DeletePrefix("test-delete-prefix", "videos/", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 0 {
+		t.Fatalf("Expected no objects to be deleted, but instead found %d", deleted)
+	}
+
+	// Empty prefix is refused without force.
+	_, err = filesystem.DeletePrefix("test-delete-prefix", "", false)
+	if _, ok := err.ToGoError().(OperationNotPermitted); !ok {
+		t.Fatalf("Expected OperationNotPermitted error, but instead found \"%v\"", err)
+	}
+	deleted, err = filesystem.DeletePrefix("test-delete-prefix", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 3 {
+		t.Fatalf("Expected 3 objects to be deleted, but instead found %d", deleted)
+	}
+}
+
+// Testing directory objects, zero byte objects named with a trailing slash.
+func TestDirectoryObject(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-dir-object-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-dir-object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("test-dir-object", "emptyfolder/", 0, bytes.NewBufferString(""), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err := fs.GetObjectInfo("test-dir-object", "emptyfolder/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Name != "emptyfolder/" || objInfo.Size != 0 || objInfo.IsDir {
+		t.Fatalf("Expected zero byte object emptyfolder/, but instead found %v", objInfo)
+	}
+	// Directory itself is not an object.
+	if _, err = fs.GetObjectInfo("test-dir-object", "emptyfolder"); err == nil {
+		t.Fatal("Expected emptyfolder not to be an object")
+	}
+
+	// Directory object is listed as an object.
+	result, err := fs.ListObjects("test-dir-object", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Name != "emptyfolder/" {
+		t.Fatalf("Expected emptyfolder/ to be listed, but instead found %v", result.Objects)
+	}
+	result, err = fs.ListObjects("test-dir-object", "emptyfolder/", "", "/", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Name != "emptyfolder/" || len(result.Prefixes) != 0 {
+		t.Fatalf("Expected emptyfolder/ to be listed under its prefix, but instead found %v %v", result.Objects, result.Prefixes)
+	}
+
+	// Objects under the directory object, paginated with a fresh walk.
+	_, err = fs.PutObject("test-dir-object", "emptyfolder/object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, delimiter := range []string{"", "/"} {
+		var names []string
+		marker := ""
+		for {
+			fs, err = newFS(directory)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err = fs.ListObjects("test-dir-object", "emptyfolder/", marker, delimiter, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, objInfo := range result.Objects {
+				names = append(names, objInfo.Name)
+			}
+			if !result.IsTruncated {
+				break
+			}
+			marker = result.NextMarker
+		}
+		if strings.Join(names, ",") != "emptyfolder/,emptyfolder/object" {
+			t.Fatalf("Expected each object listed once with delimiter %q, but instead found %v", delimiter, names)
+		}
+	}
+
+	// Delete the directory object.
+	err = fs.DeleteObject("test-dir-object", "emptyfolder/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.DeleteObject("test-dir-object", "emptyfolder/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = fs.ListObjects("test-dir-object", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 0 {
+		t.Fatalf("Expected no objects after delete, but instead found %v", result.Objects)
+	}
+	if _, e = os.Stat(filepath.Join(directory, "test-dir-object", "emptyfolder")); !os.IsNotExist(e) {
+		t.Fatal("Expected directory of the deleted directory object to be removed")
+	}
+
+	// Directory object completed from a multipart upload.
+	uploadID, err := fs.NewMultipartUpload("test-dir-object", "folder/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("test-dir-object", "folder/", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.CompleteMultipartUpload("test-dir-object", "folder/", uploadID, []completePart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err = fs.GetObjectInfo("test-dir-object", "folder/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Name != "folder/" || objInfo.Size != int64(len("abcd")) {
+		t.Fatalf("Expected object folder/ of 4 bytes, but instead found %v", objInfo)
+	}
+	result, err = fs.ListObjects("test-dir-object", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Name != "folder/" {
+		t.Fatalf("Expected folder/ to be listed, but instead found %v", result.Objects)
+	}
+	// A file in place of the directory is no object under it.
+	if _, err = fs.PutObject("test-dir-object", "file", 0, bytes.NewBufferString(""), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.GetObjectInfo("test-dir-object", "file/"); err == nil {
+		t.Fatal("Expected file/ not to be an object")
+	} else if _, ok := err.ToGoError().(ObjectNotFound); !ok {
+		t.Fatalf("Expected ObjectNotFound for file/, but instead found %s", err.Cause)
+	}
+
+	// The stored name of a directory object can not address it.
+	if _, err = fs.PutObject("test-dir-object", "folder/"+dirObjectMarker, 0, bytes.NewBufferString(""), nil); err == nil {
+		t.Fatal("Expected the directory object marker to be an invalid object name")
+	}
+	if err = fs.DeleteObject("test-dir-object", "folder/"+dirObjectMarker); err == nil {
+		t.Fatal("Expected the directory object marker to be an invalid object name")
+	}
+	if _, err = fs.GetObjectInfo("test-dir-object", "folder/"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Testing Metrics().
+func TestMetrics(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-metrics-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err = fs.PutObject("test-metrics", "object"+strconv.Itoa(i), int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	reader, err := fs.GetObject("test-metrics", "object0", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.Close()
+	// Failed operations.
+	if _, err = fs.GetObject("test-metrics", "missing", 0); err == nil {
+		t.Fatal("Expected GetObject of a missing object to fail")
+	}
+	if _, err = fs.ListObjects("missing-bucket", "", "", "", 1000); err == nil {
+		t.Fatal("Expected ListObjects of a missing bucket to fail")
+	}
+	if _, err = fs.ListObjects("test-metrics", "", "", "", 1000); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("test-metrics", "multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("test-metrics", "multipart", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "e2fc714c4727ee9395f324cd2e7f331f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.CompleteMultipartUpload("test-metrics", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := OpMetrics{
+		PutObject:               3,
+		GetObject:               2,
+		ListObjects:             2,
+		CompleteMultipartUpload: 1,
+		Errors:                  2,
+	}
+	if metrics := fs.(*Filesystem).Metrics(); metrics != expected {
+		t.Fatalf("Expected metrics %+v, but instead found %+v", expected, metrics)
+	}
+}
+
+func TestPutObjectContentSniffing(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-sniffing-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-sniffing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pngData := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 1024)
+	testCases := []struct {
+		object      string
+		data        string
+		metadata    map[string]string
+		contentType string
+	}{
+		// Extensionless object is sniffed.
+		{"image", pngData, nil, "image/png"},
+		// Extension takes precedence over sniffing.
+		{"image.jpg", pngData, nil, "image/jpeg"},
+		// Explicit content type takes precedence over sniffing.
+		{"image-typed", pngData, map[string]string{"contentType": "application/x-custom"}, "application/x-custom"},
+		// Generic data keeps the default content type.
+		{"text", "hello world", nil, "application/octet-stream"},
+		// Data shorter than the sniff length.
+		{"short-image", "\x89PNG\r\n\x1a\n", nil, "image/png"},
+	}
+	for i, testCase := range testCases {
+		objInfo, err := fs.PutObject("test-sniffing", testCase.object, int64(len(testCase.data)), bytes.NewBufferString(testCase.data), testCase.metadata)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		if objInfo.ContentType != testCase.contentType {
+			t.Errorf("Test %d: expected content type %s, got %s", i+1, testCase.contentType, objInfo.ContentType)
+		}
+		if objInfo.Size != int64(len(testCase.data)) {
+			t.Errorf("Test %d: expected size %d, got %d", i+1, len(testCase.data), objInfo.Size)
+		}
+		objInfo, err = fs.GetObjectInfo("test-sniffing", testCase.object)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		if objInfo.ContentType != testCase.contentType {
+			t.Errorf("Test %d: expected stored content type %s, got %s", i+1, testCase.contentType, objInfo.ContentType)
+		}
+		// Sniffing must not consume the written data.
+		var buffer bytes.Buffer
+		reader, err := fs.GetObject("test-sniffing", testCase.object, 0)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err.Cause.Error())
+		}
+		if _, e = io.Copy(&buffer, reader); e != nil {
+			t.Fatalf("Test %d: %s", i+1, e)
+		}
+		reader.Close()
+		if buffer.String() != testCase.data {
+			t.Errorf("Test %d: object data mismatch after sniffing", i+1)
+		}
+	}
+}
+
+func TestRootPathMissing(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-rootpath-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+	rootPath := filepath.Join(directory, "data")
+	if e = os.Mkdir(rootPath, 0700); e != nil {
+		t.Fatal(e)
+	}
+
+	// Create the fs.
+	fs, err := newFS(rootPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-rootpath")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Data path removed underneath the server.
+	if e = os.RemoveAll(rootPath); e != nil {
+		t.Fatal(e)
+	}
+	_, err = fs.PutObject("test-rootpath", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err == nil {
+		t.Fatal("Expected PutObject to fail without a data path")
+	}
+	if _, ok := err.ToGoError().(RootPathNotFound); !ok {
+		t.Fatalf("Expected RootPathNotFound from PutObject, got %s", err.Cause.Error())
+	}
+	_, err = fs.ListObjects("test-rootpath", "", "", "", 1000)
+	if err == nil {
+		t.Fatal("Expected ListObjects to fail without a data path")
+	}
+	if _, ok := err.ToGoError().(RootPathNotFound); !ok {
+		t.Fatalf("Expected RootPathNotFound from ListObjects, got %s", err.Cause.Error())
+	}
+
+	// Data path replaced by a file.
+	if e = ioutil.WriteFile(rootPath, []byte("abcd"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	_, err = fs.PutObject("test-rootpath", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err == nil {
+		t.Fatal("Expected PutObject to fail with a file as data path")
+	}
+	if _, ok := err.ToGoError().(RootPathNotDir); !ok {
+		t.Fatalf("Expected RootPathNotDir from PutObject, got %s", err.Cause.Error())
+	}
+	_, err = fs.ListObjects("test-rootpath", "", "", "", 1000)
+	if err == nil {
+		t.Fatal("Expected ListObjects to fail with a file as data path")
+	}
+	if _, ok := err.ToGoError().(RootPathNotDir); !ok {
+		t.Fatalf("Expected RootPathNotDir from ListObjects, got %s", err.Cause.Error())
+	}
+}
+
+func TestGetObjectReader(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-getobjectreader-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-reader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := "0123456789abcdefghij"
+	_, err = fs.PutObject("test-reader", "object", int64(len(data)), bytes.NewBufferString(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		startOffset int64
+		length      int64
+		expected    string
+		shouldPass  bool
+	}{
+		// Full object.
+		{0, 0, data, true},
+		// Ranged reads.
+		{0, 5, data[:5], true},
+		{5, 10, data[5:15], true},
+		{15, 0, data[15:], true},
+		// Range past the end reads until the end.
+		{15, 100, data[15:], true},
+		{int64(len(data)), 0, "", true},
+		// Offset out of range.
+		{int64(len(data)) + 1, 0, "", false},
+		{-1, 0, "", false},
+	}
+	for i, testCase := range testCases {
+		reader, size, err := fs.GetObjectReader("test-reader", "object", testCase.startOffset, testCase.length)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+			continue
+		}
+		if err == nil && !testCase.shouldPass {
+			reader.Close()
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		readData, e := ioutil.ReadAll(reader)
+		reader.Close()
+		if e != nil {
+			t.Fatalf("Test %d: %s", i+1, e)
+		}
+		if size != int64(len(readData)) {
+			t.Errorf("Test %d: Expected size %d to match the bytes read %d", i+1, size, len(readData))
+		}
+		if string(readData) != testCase.expected {
+			t.Errorf("Test %d: Expected data \"%s\", got \"%s\"", i+1, testCase.expected, string(readData))
+		}
+	}
+
+	// Missing object.
+	_, _, err = fs.GetObjectReader("test-reader", "missing", 0, 0)
+	if err == nil {
+		t.Fatal("Expected GetObjectReader of a missing object to fail")
+	}
+	if _, ok := err.ToGoError().(ObjectNotFound); !ok {
+		t.Fatalf("Expected ObjectNotFound, got %s", err.Cause.Error())
+	}
+}
+
+// TestObjectOwner - tests buckets and objects are owned by the configured access key.
+func TestObjectOwner(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-owner-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		rwMutex:    &sync.RWMutex{},
+	}
+	owner := serverConfig.GetCredential().AccessKeyID
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err := fs.PutObject("bucket", "object", int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Owner != owner {
+		t.Errorf("Expected PutObject owner \"%s\", but instead found \"%s\"", owner, objInfo.Owner)
+	}
+
+	objInfo, err = fs.GetObjectInfo("bucket", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Owner != owner {
+		t.Errorf("Expected GetObjectInfo owner \"%s\", but instead found \"%s\"", owner, objInfo.Owner)
+	}
+
+	result, err := fs.ListObjects("bucket", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Owner != owner {
+		t.Errorf("Expected ListObjects owner \"%s\", but instead found %v", owner, result.Objects)
+	}
+
+	buckets, err := fs.ListBuckets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 1 || buckets[0].Owner != owner {
+		t.Errorf("Expected ListBuckets owner \"%s\", but instead found %v", owner, buckets)
+	}
+
+	bucketInfo, err := fs.GetBucketInfo("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bucketInfo.Owner != owner {
+		t.Errorf("Expected GetBucketInfo owner \"%s\", but instead found \"%s\"", owner, bucketInfo.Owner)
+	}
+
+	// The listing responses carry the owner.
+	listObjectsResp := generateListObjectsResponse("bucket", "", "", "", 1000, result)
+	if listObjectsResp.Contents[0].Owner.ID != owner {
+		t.Errorf("Expected ListObjects response owner \"%s\", but instead found \"%s\"", owner, listObjectsResp.Contents[0].Owner.ID)
+	}
+	listBucketsResp := generateListBucketsResponse(buckets)
+	if listBucketsResp.Owner.ID != owner {
+		t.Errorf("Expected ListBuckets response owner \"%s\", but instead found \"%s\"", owner, listBucketsResp.Owner.ID)
+	}
+}
+
+// TestObjectStorageClass - tests storage class is persisted with objects.
+func TestObjectStorageClass(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-storage-class-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		storageClass         string
+		expectedStorageClass string
+		shouldPass           bool
+	}{
+		{"", "STANDARD", true},
+		{"STANDARD", "STANDARD", true},
+		{"REDUCED_REDUNDANCY", "REDUCED_REDUNDANCY", true},
+		// Unsupported storage class.
+		{"GLACIER", "", false},
+	}
+	for i, testCase := range testCases {
+		object := fmt.Sprintf("object%d", i)
+		metadata := map[string]string{"storageClass": testCase.storageClass}
+		objInfo, err := fs.PutObject("bucket", object, int64(len("abcd")), bytes.NewBufferString("abcd"), metadata)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if !testCase.shouldPass {
+			if _, ok := err.ToGoError().(InvalidStorageClass); !ok {
+				t.Errorf("Test %d: Expected InvalidStorageClass, got %s", i+1, err.Cause.Error())
+			}
+			if _, err = fs.GetObjectInfo("bucket", object); err == nil {
+				t.Errorf("Test %d: Expected object not to be created", i+1)
+			}
+
+			// Multipart uploads reject it as well.
+			_, err = filesystem.NewMultipartUploadWithMetadata("bucket", object, metadata)
+			if err == nil {
+				t.Errorf("Test %d: Expected NewMultipartUpload to fail, but passed instead", i+1)
+			} else if _, ok := err.ToGoError().(InvalidStorageClass); !ok {
+				t.Errorf("Test %d: Expected InvalidStorageClass, got %s", i+1, err.Cause.Error())
+			}
+			continue
+		}
+		if objInfo.StorageClass != testCase.expectedStorageClass {
+			t.Errorf("Test %d: Expected PutObject storage class \"%s\", got \"%s\"", i+1, testCase.expectedStorageClass, objInfo.StorageClass)
+		}
+		objInfo, err = fs.GetObjectInfo("bucket", object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if objInfo.StorageClass != testCase.expectedStorageClass {
+			t.Errorf("Test %d: Expected GetObjectInfo storage class \"%s\", got \"%s\"", i+1, testCase.expectedStorageClass, objInfo.StorageClass)
+		}
+		result, err := fs.ListObjects("bucket", object, "", "", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Objects) != 1 || result.Objects[0].StorageClass != testCase.expectedStorageClass {
+			t.Errorf("Test %d: Expected ListObjects storage class \"%s\", got %v", i+1, testCase.expectedStorageClass, result.Objects)
+		}
+
+		// Multipart uploads carry it to the completed object.
+		uploadID, err := filesystem.NewMultipartUploadWithMetadata("bucket", object+"-multipart", metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		etag, err := fs.PutObjectPart("bucket", object+"-multipart", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		objInfo, err = fs.CompleteMultipartUpload("bucket", object+"-multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if objInfo.StorageClass != testCase.expectedStorageClass {
+			t.Errorf("Test %d: Expected CompleteMultipartUpload storage class \"%s\", got \"%s\"", i+1, testCase.expectedStorageClass, objInfo.StorageClass)
+		}
+		objInfo, err = fs.GetObjectInfo("bucket", object+"-multipart")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if objInfo.StorageClass != testCase.expectedStorageClass {
+			t.Errorf("Test %d: Expected multipart storage class \"%s\", got \"%s\"", i+1, testCase.expectedStorageClass, objInfo.StorageClass)
+		}
+	}
+}
+
+// TestMaxObjectSize - tests objects larger than the maximum object size are rejected.
+func TestMaxObjectSize(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-max-size-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{MaxObjectSize: 8},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		data       string
+		size       int64
+		shouldPass bool
+	}{
+		// Object of the maximum size.
+		{"abcdefgh", 8, true},
+		// Declared size over the maximum.
+		{"abcdefghi", 9, false},
+		// Streaming write of the maximum size.
+		{"abcdefgh", -1, true},
+		// Streaming write growing over the maximum.
+		{"abcdefghi", -1, false},
+	}
+	for i, testCase := range testCases {
+		object := fmt.Sprintf("object%d", i)
+		_, err = fs.PutObject("bucket", object, testCase.size, bytes.NewBufferString(testCase.data), nil)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if err != nil && !testCase.shouldPass {
+			if _, ok := err.ToGoError().(EntityTooLarge); !ok {
+				t.Errorf("Test %d: Expected EntityTooLarge, got %s", i+1, err.Cause.Error())
+			}
+			if _, err = fs.GetObjectInfo("bucket", object); err == nil {
+				t.Errorf("Test %d: Expected object not to be created", i+1)
+			}
+		}
+	}
+
+	// Parts together over the maximum fail completion.
+	uploadID, err := fs.NewMultipartUpload("bucket", "multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []completePart
+	for i, data := range []string{"abcde", "fghij"} {
+		etag, err := fs.PutObjectPart("bucket", "multipart", uploadID, i+1, int64(len(data)), bytes.NewBufferString(data), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, completePart{PartNumber: i + 1, ETag: etag})
+	}
+	_, err = fs.CompleteMultipartUpload("bucket", "multipart", uploadID, parts)
+	if err == nil {
+		t.Fatal("Expected CompleteMultipartUpload to fail, but passed instead")
+	}
+	if _, ok := err.ToGoError().(EntityTooLarge); !ok {
+		t.Fatalf("Expected EntityTooLarge, got %s", err.Cause.Error())
+	}
+
+	// Parts within the maximum complete.
+	_, err = fs.CompleteMultipartUpload("bucket", "multipart", uploadID, parts[:1])
+	if err != nil {
+		t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+}
+
+// hasTraceTags - returns true if a trace point of err is tagged with all tags.
+func hasTraceTags(err *probe.Error, tags ...string) bool {
+	for _, tracePoint := range err.CallTrace {
+		traced := strings.Join(tracePoint.Env["Tags"], ",")
+		found := true
+		for _, tag := range tags {
+			if !strings.Contains(traced, tag) {
+				found = false
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// TestErrorTrace - tests errors are traced with the bucket and object.
+func TestErrorTrace(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-trace-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		call func() *probe.Error
+		tags []string
+	}{
+		{func() *probe.Error {
+			_, err := fs.GetObjectInfo("bucket", "missing-object")
+			return err
+		}, []string{"bucket", "missing-object"}},
+		{func() *probe.Error {
+			_, err := fs.ListObjects("bucket", "traced-prefix", "", "*", 10)
+			return err
+		}, []string{"bucket", "traced-prefix"}},
+		{func() *probe.Error {
+			_, err := fs.PutObjectPart("bucket", "object", "missing-upload-id", 1, 4, bytes.NewBufferString("abcd"), "")
+			return err
+		}, []string{"bucket", "object", "missing-upload-id"}},
+		{func() *probe.Error {
+			return fs.DeleteObject("missing-bucket", "object")
+		}, []string{"missing-bucket", "object"}},
+	}
+	for i, testCase := range testCases {
+		err := testCase.call()
+		if err == nil {
+			t.Fatalf("Test %d: Expected to fail, but passed instead", i+1)
+		}
+		if !hasTraceTags(err, testCase.tags...) {
+			t.Errorf("Test %d: Expected trace tagged with %v, but instead found %s", i+1, testCase.tags, err)
+		}
+	}
+}
+
+// Testing events of successful object writes and deletes.
+func TestEventHandler(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-events-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	fs.(*Filesystem).RegisterEventHandler(EventFilter{}, func(event Event) {
+		events = append(events, event)
+	})
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PUT of an object.
+	objInfo, err := fs.PutObject("bucket", "object", int64(len("hello")), bytes.NewBufferString("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Failed PUT.
+	if _, err = fs.PutObject("bucket", "object", int64(len("hello")), bytes.NewBufferString("hello"), map[string]string{"md5Sum": "d41d8cd98f00b204e9800998ecf8427e"}); err == nil {
+		t.Fatal("Expected PutObject with a bad digest to fail, but passed instead")
+	}
+	// Completed multipart upload.
+	uploadID, err := fs.NewMultipartUpload("bucket", "multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("bucket", "multipart", uploadID, 1, int64(len("abcd")), bytes.NewBufferString("abcd"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	multipartInfo, err := fs.CompleteMultipartUpload("bucket", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Deleted object and failed delete of a missing object.
+	if err = fs.DeleteObject("bucket", "object"); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.DeleteObject("bucket", "object"); err == nil {
+		t.Fatal("Expected DeleteObject of a missing object to fail, but passed instead")
+	}
+
+	expected := []Event{
+		{Type: ObjectCreatedPut, Bucket: "bucket", Object: "object", Size: objInfo.Size, ETag: objInfo.MD5Sum},
+		{Type: ObjectCreatedCompleteMultipartUpload, Bucket: "bucket", Object: "multipart", Size: multipartInfo.Size, ETag: multipartInfo.MD5Sum},
+		{Type: ObjectRemovedDelete, Bucket: "bucket", Object: "object"},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Expected events %v, but instead found %v", expected, events)
+	}
+	if events[0].Size != 5 || events[0].ETag != "5d41402abc4b2a76b9719d911017c592" {
+		t.Fatalf("Expected event of 5 bytes with ETag \"5d41402abc4b2a76b9719d911017c592\", but instead found %v", events[0])
+	}
+}
+
+// Testing events dispatched to handlers with matching filters only.
+func TestEventHandlerFilter(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-events-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	// Overlapping filters, photos/a.jpg matches both.
+	var jpgObjects, photoObjects []string
+	filesystem.RegisterEventHandler(EventFilter{Suffix: ".jpg"}, func(event Event) {
+		jpgObjects = append(jpgObjects, event.Object)
+	})
+	filesystem.RegisterEventHandler(EventFilter{Prefix: "photos/"}, func(event Event) {
+		photoObjects = append(photoObjects, event.Object)
+	})
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"a.jpg", "a.png", "photos/a.jpg", "photos/b.png", "jpg"} {
+		if _, err = fs.PutObject("bucket", object, int64(len(object)), bytes.NewBufferString(object), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = fs.DeleteObject("bucket", "a.jpg"); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"a.jpg", "photos/a.jpg", "a.jpg"}; !reflect.DeepEqual(jpgObjects, expected) {
+		t.Errorf("Expected events of %v, but instead found %v", expected, jpgObjects)
+	}
+	if expected := []string{"photos/a.jpg", "photos/b.png"}; !reflect.DeepEqual(photoObjects, expected) {
+		t.Errorf("Expected events of %v, but instead found %v", expected, photoObjects)
+	}
+}
+
+// Tests that Close delivers pending events, stops the background
+// workers and saved listings, and fails later operations.
+func TestFilesystemClose(t *testing.T) {
+	var mutex sync.Mutex
+	delivered := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		delivered++
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Notify: notifyConfig{
+			Webhook: webhookConfig{Enable: true, Endpoint: server.URL},
+		},
+		rwMutex: &sync.RWMutex{},
+	}
+
+	directory, e := ioutil.TempDir("", "minio-close-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	goroutines := runtime.NumGoroutine()
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err = fs.PutObject("bucket", fmt.Sprintf("object%d", i), int64(len("data")), bytes.NewBufferString("data"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Truncated listings save their walks for continuation.
+	if _, err = fs.ListObjects("bucket", "", "", "", 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.ListMultipartUploads("bucket", "", "", "", "", 1); err != nil {
+		t.Fatal(err)
+	}
+	if runtime.NumGoroutine() <= goroutines {
+		t.Fatal("Expected background goroutines to be running")
+	}
+
+	if e = filesystem.Close(); e != nil {
+		t.Fatal(e)
+	}
+	mutex.Lock()
+	if delivered != 10 {
+		t.Errorf("Expected 10 events to be delivered on close, but instead found %d", delivered)
+	}
+	mutex.Unlock()
+	// Walks and connections wind down asynchronously.
+	for i := 0; runtime.NumGoroutine() > goroutines; i++ {
+		if i == 100 {
+			t.Fatalf("Expected %d goroutines after close, but instead found %d", goroutines, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Closing again does nothing.
+	if e = filesystem.Close(); e != nil {
+		t.Fatal(e)
+	}
+	if _, err = fs.PutObject("bucket", "object", int64(len("data")), bytes.NewBufferString("data"), nil); err == nil {
+		t.Fatal("Expected PutObject to fail after close, but passed instead")
+	} else if _, ok := err.ToGoError().(FilesystemClosed); !ok {
+		t.Errorf("Expected FilesystemClosed, but instead found %#v", err.ToGoError())
+	}
+	if _, err = fs.GetObjectInfo("bucket", "object0"); err == nil {
+		t.Error("Expected GetObjectInfo to fail after close, but passed instead")
+	}
+	if _, err = fs.ListObjects("bucket", "", "", "", 1); err == nil {
+		t.Error("Expected ListObjects to fail after close, but passed instead")
+	}
+	if _, err = fs.NewMultipartUpload("bucket", "object"); err == nil {
+		t.Error("Expected NewMultipartUpload to fail after close, but passed instead")
+	}
+	if _, err = fs.ListBuckets(); err == nil {
+		t.Error("Expected ListBuckets to fail after close, but passed instead")
+	}
+}
+
+// Tests that the content encoding of objects is persisted and returned
+// as is.
+func TestContentEncoding(t *testing.T) {
+	execObjectLayerTest(t, testContentEncoding)
+}
+
+func testContentEncoding(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gzipMeta := map[string]string{"contentEncoding": "gzip"}
+	objInfo, err := fs.PutObject("bucket", "object.gz", int64(len("data")), bytes.NewBufferString("data"), gzipMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.ContentEncoding != "gzip" {
+		t.Errorf("Expected PutObject to return content encoding \"gzip\", but instead found %q", objInfo.ContentEncoding)
+	}
+	if _, err = fs.PutObject("bucket", "plain", int64(len("data")), bytes.NewBufferString("data"), nil); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUploadWithMetadata("bucket", "multipart.gz", gzipMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("bucket", "multipart.gz", uploadID, 1, int64(len("data")), bytes.NewBufferString("data"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err = fs.CompleteMultipartUpload("bucket", "multipart.gz", uploadID, []completePart{{PartNumber: 1, ETag: etag}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.ContentEncoding != "gzip" {
+		t.Errorf("Expected CompleteMultipartUpload to return content encoding \"gzip\", but instead found %q", objInfo.ContentEncoding)
+	}
+
+	expected := map[string]string{"multipart.gz": "gzip", "object.gz": "gzip", "plain": ""}
+	for object, contentEncoding := range expected {
+		objInfo, err = fs.GetObjectInfo("bucket", object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if objInfo.ContentEncoding != contentEncoding {
+			t.Errorf("%s: Expected content encoding %q, but instead found %q", object, contentEncoding, objInfo.ContentEncoding)
+		}
+		// Sent back on GET and HEAD.
+		w := httptest.NewRecorder()
+		setObjectHeaders(w, objInfo, nil)
+		if header := w.Header().Get("Content-Encoding"); header != contentEncoding {
+			t.Errorf("%s: Expected Content-Encoding header %q, but instead found %q", object, contentEncoding, header)
+		}
+	}
+	result, err := fs.ListObjects("bucket", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != len(expected) {
+		t.Fatalf("Expected %d objects, but instead found %d", len(expected), len(result.Objects))
+	}
+	for _, objInfo := range result.Objects {
+		if objInfo.ContentEncoding != expected[objInfo.Name] {
+			t.Errorf("%s: Expected listed content encoding %q, but instead found %q", objInfo.Name, expected[objInfo.Name], objInfo.ContentEncoding)
+		}
+	}
+}
+
+// Tests that the cache and download headers of objects are persisted
+// and returned, and that values with line breaks are rejected.
+func TestContentHeaders(t *testing.T) {
+	execObjectLayerTest(t, testContentHeaders)
+}
+
+func testContentHeaders(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := map[string]string{
+		"cacheControl":       "max-age=3600",
+		"contentDisposition": `attachment; filename="report.pdf"`,
+		"contentLanguage":    "en-US",
+		"expires":            "Wed, 21 Oct 2026 07:28:00 GMT",
+	}
+	expectedHeaders := map[string]string{
+		"Cache-Control":       "max-age=3600",
+		"Content-Disposition": `attachment; filename="report.pdf"`,
+		"Content-Language":    "en-US",
+		"Expires":             "Wed, 21 Oct 2026 07:28:00 GMT",
+	}
+	if _, err = fs.PutObject("bucket", "object", int64(len("data")), bytes.NewBufferString("data"), metadata); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUploadWithMetadata("bucket", "multipart", metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("bucket", "multipart", uploadID, 1, int64(len("data")), bytes.NewBufferString("data"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.CompleteMultipartUpload("bucket", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"object", "multipart"} {
+		objInfo, err := fs.GetObjectInfo("bucket", object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		setObjectHeaders(w, objInfo, nil)
+		for header, value := range expectedHeaders {
+			if w.Header().Get(header) != value {
+				t.Errorf("%s: Expected %s header %q, but instead found %q", object, header, value, w.Header().Get(header))
+			}
+		}
+	}
+
+	// Values with line breaks would split the response headers.
+	for i, key := range []string{"contentEncoding", "cacheControl", "contentDisposition", "contentLanguage", "expires"} {
+		invalid := map[string]string{key: "value\r\nSet-Cookie: session=1"}
+		_, err = fs.PutObject("bucket", "invalid", int64(len("data")), bytes.NewBufferString("data"), invalid)
+		if err == nil {
+			t.Errorf("Test %d: Expected PutObject with an invalid %s to fail, but passed instead", i+1, key)
+		} else if _, ok := err.ToGoError().(InvalidHeaderValue); !ok {
+			t.Errorf("Test %d: Expected InvalidHeaderValue, but instead found %#v", i+1, err.ToGoError())
+		}
+		invalid[key] = "value\nSet-Cookie: session=1"
+		if _, err = fs.NewMultipartUploadWithMetadata("bucket", "invalid", invalid); err == nil {
+			t.Errorf("Test %d: Expected NewMultipartUpload with an invalid %s to fail, but passed instead", i+1, key)
+		} else if _, ok := err.ToGoError().(InvalidHeaderValue); !ok {
+			t.Errorf("Test %d: Expected InvalidHeaderValue, but instead found %#v", i+1, err.ToGoError())
+		}
+	}
+	if _, err = fs.GetObjectInfo("bucket", "invalid"); err == nil {
+		t.Error("Expected objects with invalid headers not to be written")
+	}
+}
+
+// Tests that user defined metadata is persisted by lowercased name and
+// that metadata over the size limit is rejected.
+func TestUserMetadata(t *testing.T) {
+	execObjectLayerTest(t, testUserMetadata)
+}
+
+func testUserMetadata(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := map[string]string{
+		"X-Amz-Meta-Color":   "blue",
+		"x-amz-meta-project": "minio",
+		"X-AMZ-META-Empty":   "",
+		"storageClass":       "STANDARD",
+	}
+	expected := map[string]string{
+		"x-amz-meta-color":   "blue",
+		"x-amz-meta-project": "minio",
+		"x-amz-meta-empty":   "",
+	}
+	if _, err = fs.PutObject("bucket", "object", int64(len("data")), bytes.NewBufferString("data"), metadata); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUploadWithMetadata("bucket", "multipart", metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("bucket", "multipart", uploadID, 1, int64(len("data")), bytes.NewBufferString("data"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.CompleteMultipartUpload("bucket", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"object", "multipart"} {
+		objInfo, err := fs.GetObjectInfo("bucket", object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(objInfo.UserDefined, expected) {
+			t.Errorf("%s: Expected user metadata %v, but instead found %v", object, expected, objInfo.UserDefined)
+		}
+		w := httptest.NewRecorder()
+		setObjectHeaders(w, objInfo, nil)
+		if header := w.Header().Get("X-Amz-Meta-Color"); header != "blue" {
+			t.Errorf("%s: Expected X-Amz-Meta-Color header \"blue\", but instead found %q", object, header)
+		}
+	}
+
+	// Names without the prefix count towards the limit, like S3.
+	atLimit := map[string]string{"x-amz-meta-a": strings.Repeat("a", 2047)}
+	if _, err = fs.PutObject("bucket", "limit", int64(len("data")), bytes.NewBufferString("data"), atLimit); err != nil {
+		t.Errorf("Expected metadata at the limit to pass, but failed with: <ERROR> %s", err.Cause.Error())
+	}
+	overLimit := map[string]string{
+		"x-amz-meta-first":  strings.Repeat("a", 1024),
+		"x-amz-meta-second": strings.Repeat("b", 1024),
+	}
+	_, err = fs.PutObject("bucket", "large", int64(len("data")), bytes.NewBufferString("data"), overLimit)
+	if err == nil {
+		t.Error("Expected PutObject with metadata over the limit to fail, but passed instead")
+	} else if _, ok := err.ToGoError().(MetadataTooLarge); !ok {
+		t.Errorf("Expected MetadataTooLarge, but instead found %#v", err.ToGoError())
+	}
+	if _, err = fs.NewMultipartUploadWithMetadata("bucket", "large", overLimit); err == nil {
+		t.Error("Expected NewMultipartUpload with metadata over the limit to fail, but passed instead")
+	} else if _, ok := err.ToGoError().(MetadataTooLarge); !ok {
+		t.Errorf("Expected MetadataTooLarge, but instead found %#v", err.ToGoError())
+	}
+}
+
+// Tests that declared CRC32C and SHA256 checksums of transferred data
+// are verified.
+func TestTransferChecksums(t *testing.T) {
+	execObjectLayerTest(t, testTransferChecksums)
+}
+
+func testTransferChecksums(t *testing.T, fs ObjectLayer) {
+	err := fs.MakeBucket("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := fs.NewMultipartUpload("bucket", "multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("hello world")
+	crc32cHex := fmt.Sprintf("%08x", crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)))
+	sha256Sum := sha256.Sum256(data)
+	sha256Hex := hex.EncodeToString(sha256Sum[:])
+	md5Sum := md5.Sum(data)
+	md5Hex := hex.EncodeToString(md5Sum[:])
+
+	testCases := []struct {
+		metadata          map[string]string
+		shouldPass        bool
+		expectedAlgorithm string
+	}{
+		// Test case - 1.
+		// Matching checksums.
+		{map[string]string{"crc32c": crc32cHex}, true, ""},
+		{map[string]string{"sha256": sha256Hex}, true, ""},
+		{map[string]string{"md5Sum": md5Hex, "crc32c": crc32cHex, "sha256": sha256Hex}, true, ""},
+		// Test case - 4.
+		// Mismatching checksums name the check failed.
+		{map[string]string{"crc32c": "00000000"}, false, "crc32c"},
+		{map[string]string{"sha256": strings.Repeat("0", 64)}, false, "sha256"},
+		{map[string]string{"md5Sum": md5Hex, "crc32c": crc32cHex, "sha256": strings.Repeat("0", 64)}, false, "sha256"},
+		{map[string]string{"md5Sum": strings.Repeat("0", 32), "sha256": sha256Hex}, false, "md5"},
+	}
+	for i, testCase := range testCases {
+		object := fmt.Sprintf("object%d", i)
+		_, err = fs.PutObject("bucket", object, int64(len(data)), bytes.NewReader(data), testCase.metadata)
+		_, partErr := fs.PutObjectPartWithMetadata("bucket", "multipart", uploadID, i+1, int64(len(data)), bytes.NewReader(data), testCase.metadata)
+		for _, err := range []*probe.Error{err, partErr} {
+			if err != nil && testCase.shouldPass {
+				t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+			}
+			if err == nil && !testCase.shouldPass {
+				t.Errorf("Test %d: Expected to fail, but passed instead", i+1)
+			}
+			if err != nil && !testCase.shouldPass {
+				badDigest, ok := err.ToGoError().(BadDigest)
+				if !ok {
+					t.Errorf("Test %d: Expected BadDigest, but instead found %#v", i+1, err.ToGoError())
+				} else if badDigest.Algorithm != testCase.expectedAlgorithm {
+					t.Errorf("Test %d: Expected the %s check to fail, but instead found %s", i+1, testCase.expectedAlgorithm, badDigest.Algorithm)
+				}
+			}
+		}
+		// Objects failing a check are not written.
+		_, err = fs.GetObjectInfo("bucket", object)
+		if testCase.shouldPass && err != nil {
+			t.Errorf("Test %d: Expected the object to exist, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if !testCase.shouldPass && err == nil {
+			t.Errorf("Test %d: Expected the object not to exist", i+1)
+		}
+	}
+}
+
+// Tests that sidecars are kept under the metadata path, apart from
+// objects named like them.
+func TestObjectSidecars(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-sidecar-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = fs.PutObject("bucket", "dir/object", int64(len("abcd")), bytes.NewBufferString("abcd"), map[string]string{"contentType": "text/plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.(*Filesystem).PutObjectTags("bucket", "dir/object", map[string]string{"key": "value"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.(*Filesystem).PutObjectLegalHold("bucket", "dir/object", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// The data directory only holds the object.
+	var names []string
+	e = filepath.Walk(filepath.Join(directory, "bucket"), func(path string, info os.FileInfo, e error) error {
+		if e == nil && info.Mode().IsRegular() {
+			names = append(names, filepath.ToSlash(strings.TrimPrefix(path, directory)))
+		}
+		return e
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(names) != 1 || names[0] != "/bucket/dir/object" {
+		t.Fatalf("Expected only the object in the data directory, but instead found %v", names)
+	}
+
+	// Objects named like sidecars are plain objects and survive a restart.
+	for _, suffix := range []string{objectMetaSuffix, objectTagsSuffix, objectLegalHoldSuffix} {
+		_, err = fs.PutObject("bucket", "dir/object"+suffix, int64(len("efgh")), bytes.NewBufferString("efgh"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	fs, err = newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, suffix := range []string{objectMetaSuffix, objectTagsSuffix, objectLegalHoldSuffix} {
+		reader, err := fs.GetObject("bucket", "dir/object"+suffix, 0)
+		if err != nil {
+			t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+		}
+		data, e := ioutil.ReadAll(reader)
+		reader.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if string(data) != "efgh" {
+			t.Errorf("Expected \"efgh\", but instead found \"%s\"", string(data))
+		}
+	}
+	objInfo, err := fs.GetObjectInfo("bucket", "dir/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.ContentType != "text/plain" {
+		t.Errorf("Expected content type \"text/plain\", but instead found \"%s\"", objInfo.ContentType)
+	}
+	tags, err := fs.(*Filesystem).GetObjectTags("bucket", "dir/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags["key"] != "value" {
+		t.Errorf("Expected tags to round trip, but instead found %v", tags)
+	}
+}
+
+func TestObjectDedup(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-dedup-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{Dedup: true},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+
+	text := "Jack and Jill went up the hill"
+	sum := sha256.Sum256([]byte(text))
+	hash := hex.EncodeToString(sum[:])
+	blobs := func() []string {
+		var names []string
+		entries, _ := ioutil.ReadDir(filepath.Join(directory, configDir, blobsDir))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+	expectRefs := func(refs int) {
+		found, e := fs.readBlobRefs(hash)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if found != refs {
+			t.Fatalf("Expected %d references of the blob, but instead found %d", refs, found)
+		}
+	}
+
+	// Identical content is stored once.
+	for _, object := range []string{"a", "dir/b"} {
+		if _, err = fs.PutObject("bucket", object, int64(len(text)), strings.NewReader(text), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if names := blobs(); !reflect.DeepEqual(names, []string{hash, hash + blobRefsSuffix}) {
+		t.Fatalf("Expected a single blob, but instead found %v", names)
+	}
+	expectRefs(2)
+	aSt, e := os.Stat(filepath.Join(directory, "bucket", "a"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	bSt, e := os.Stat(filepath.Join(directory, "bucket", "dir", "b"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if !os.SameFile(aSt, bSt) {
+		t.Fatal("Expected identical objects to share their data")
+	}
+
+	// Completed multipart uploads are deduplicated as well.
+	uploadID, err := fs.NewMultipartUpload("bucket", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag, err := fs.PutObjectPart("bucket", "c", uploadID, 1, int64(len(text)), strings.NewReader(text), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.CompleteMultipartUpload("bucket", "c", uploadID, []completePart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatal(err)
+	}
+	expectRefs(3)
+
+	// Deleting and replacing objects keeps the others intact.
+	if err = fs.DeleteObject("bucket", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.PutObject("bucket", "c", int64(len("other")), strings.NewReader("other"), nil); err != nil {
+		t.Fatal(err)
+	}
+	expectRefs(1)
+	reader, err := fs.GetObject("bucket", "dir/b", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, e := ioutil.ReadAll(reader)
+	reader.Close()
+	if e != nil {
+		t.Fatal(e)
+	}
+	if string(data) != text {
+		t.Fatalf("Expected \"%s\", but instead found \"%s\"", text, string(data))
+	}
+
+	// The blob is removed along with its last reference.
+	if err = fs.DeleteObject("bucket", "dir/b"); err != nil {
+		t.Fatal(err)
+	}
+	expectRefs(0)
+	if _, e = os.Stat(fs.blobPath(hash)); !os.IsNotExist(e) {
+		t.Fatalf("Expected the blob to be removed, but instead found %v", e)
+	}
+}
+
+// tempDirReader - reader recording the temporary files in a directory
+// once data is being written.
+type tempDirReader struct {
+	io.Reader
+	dir   string
+	names []string
+}
+
+func (r *tempDirReader) Read(p []byte) (int, error) {
+	if r.names == nil {
+		r.names = []string{}
+		entries, _ := ioutil.ReadDir(r.dir)
+		for _, entry := range entries {
+			r.names = append(r.names, entry.Name())
+		}
+	}
+	return r.Reader.Read(p)
+}
+
+func TestTempDir(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-temp-dir-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+	tempDir := filepath.Join(directory, configDir, "tmp")
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{TempDir: tempDir},
+		rwMutex:    &sync.RWMutex{},
+	}
+
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	expectTempFile := func(reader *tempDirReader) {
+		if len(reader.names) != 1 || !strings.HasPrefix(reader.names[0], "$tmpobject") {
+			t.Fatalf("Expected a temporary file in the temporary directory, but instead found %v", reader.names)
+		}
+	}
+	expectData := func(object, data string) {
+		readData, e := ioutil.ReadFile(filepath.Join(directory, "bucket", object))
+		if e != nil {
+			t.Fatal(e)
+		}
+		if string(readData) != data {
+			t.Fatalf("Expected \"%s\", but instead found \"%s\"", data, string(readData))
+		}
+		entries, e := ioutil.ReadDir(tempDir)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("Expected no leftover temporary files, found %d entries", len(entries))
+		}
+	}
+
+	// The extension avoids reading ahead to detect the content type.
+	reader := &tempDirReader{Reader: strings.NewReader("abcd"), dir: tempDir}
+	if _, err = fs.PutObject("bucket", "dir/object.txt", 4, reader, nil); err != nil {
+		t.Fatal(err)
+	}
+	expectTempFile(reader)
+	expectData("dir/object.txt", "abcd")
+
+	uploadID, err := fs.NewMultipartUpload("bucket", "multipart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader = &tempDirReader{Reader: strings.NewReader("efgh"), dir: tempDir}
+	etag, err := fs.PutObjectPart("bucket", "multipart", uploadID, 1, 4, reader, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectTempFile(reader)
+	if _, err = fs.CompleteMultipartUpload("bucket", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: etag}}); err != nil {
+		t.Fatal(err)
+	}
+	expectData("multipart", "efgh")
+}
+
+func BenchmarkGetObject(b *testing.B) {
+	// Make a temporary directory to use as the fs.
+	directory, e := ioutil.TempDir("", "minio-benchmark-getobject")
+	if e != nil {
+		b.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Make a bucket and put in a few objects.
+	err = fs.MakeBucket("bucket")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	text := "Jack and Jill went up the hill / To fetch a pail of water."
+	hasher := md5.New()
+	hasher.Write([]byte(text))
+	metadata := make(map[string]string)
+	for i := 0; i < 10; i++ {
+		metadata["md5Sum"] = hex.EncodeToString(hasher.Sum(nil))
+		_, err = fs.PutObject("bucket", "object"+strconv.Itoa(i), int64(len(text)), bytes.NewBufferString(text), metadata)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var buffer = new(bytes.Buffer)
+		r, err := fs.GetObject("bucket", "object"+strconv.Itoa(i%10), 0)
+		if err != nil {
+			b.Error(err)
+		}
+		if _, e := io.Copy(buffer, r); e != nil {
+			b.Error(e)
+		}
+		if buffer.Len() != len(text) {
+			b.Errorf("GetObject returned incorrect length %d (should be %d)\n", buffer.Len(), len(text))
+		}
+		r.Close()
+	}
+}
+
+// Testing PrecheckPut() rejects puts before their data is read.
+func TestPrecheckPut(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-precheck-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.SetBucketQuota("bucket", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		bucket      string
+		object      string
+		size        int64
+		expectedErr error
+	}{
+		{"bucket", "object", 10, nil},
+		// Streaming writes are verified while writing.
+		{"bucket", "object", -1, nil},
+		{"bucket", "object", 11, QuotaExceeded{Bucket: "bucket", Quota: 10}},
+		{"missing", "object", 1, BucketNotFound{Bucket: "missing"}},
+		{"bucket", "", 1, ObjectNameInvalid{Bucket: "bucket"}},
+	}
+	for i, testCase := range testCases {
+		err = fs.PrecheckPut(testCase.bucket, testCase.object, testCase.size)
+		if testCase.expectedErr == nil {
+			if err != nil {
+				t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("Test %d: Expected to fail with \"%s\", but passed instead", i+1, testCase.expectedErr)
+		} else if err.ToGoError().Error() != testCase.expectedErr.Error() {
+			t.Errorf("Test %d: Expected to fail with \"%s\", but instead found \"%s\"", i+1, testCase.expectedErr, err.Cause.Error())
+		}
+	}
+
+	// A full disk rejects the put.
+	defer func(fn func(string) (disk.Info, error)) { getDiskInfo = fn }(getDiskInfo)
+	getDiskInfo = func(path string) (disk.Info, error) {
+		return disk.Info{Total: 100, Free: 0}, nil
+	}
+	err = fs.PrecheckPut("bucket", "object", 1)
+	if err == nil {
+		t.Fatal("Expected PrecheckPut to fail on a full disk, but it passed instead")
+	}
+	if _, ok := err.ToGoError().(RootPathFull); !ok {
+		t.Fatalf("Expected RootPathFull error, but instead found \"%s\"", err.Cause.Error())
+	}
+}
+
+// Testing ObjectExists() for objects, prefixes and missing buckets.
+func TestObjectExists(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-exists-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	obj, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := obj.(*Filesystem)
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.PutObject("bucket", "Asia/jack.txt", int64(len("abcd")), bytes.NewBufferString("abcd"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		bucket         string
+		object         string
+		expectedExists bool
+		expectedErr    error
+	}{
+		// Existing object.
+		{"bucket", "Asia/jack.txt", true, nil},
+		// Directory prefix.
+		{"bucket", "Asia", false, nil},
+		// Missing objects.
+		{"bucket", "Asia/jill.txt", false, nil},
+		{"bucket", "Asia/jack.txt/jill.txt", false, nil},
+		// Missing bucket.
+		{"missing", "Asia/jack.txt", false, BucketNotFound{Bucket: "missing"}},
+	}
+	for i, testCase := range testCases {
+		exists, err := fs.ObjectExists(testCase.bucket, testCase.object)
+		if testCase.expectedErr != nil {
+			if err == nil {
+				t.Errorf("Test %d: Expected to fail with \"%s\", but passed instead", i+1, testCase.expectedErr)
+			} else if err.ToGoError().Error() != testCase.expectedErr.Error() {
+				t.Errorf("Test %d: Expected to fail with \"%s\", but instead found \"%s\"", i+1, testCase.expectedErr, err.Cause.Error())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+			continue
+		}
+		if exists != testCase.expectedExists {
+			t.Errorf("Test %d: Expected exists %v, but instead found %v", i+1, testCase.expectedExists, exists)
+		}
+	}
+}
+
+// Testing symlinks in the data tree are not objects.
+func TestObjectSymlinks(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-symlink-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fs.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = fs.PutObject("bucket", "Asia/jack.txt", int64(len("abcd")), bytes.NewBufferString("abcd"), nil); err != nil {
+		t.Fatal(err)
+	}
+	// A dangling symlink and one to an existing object.
+	if e = os.Symlink(filepath.Join(directory, "missing"), filepath.Join(directory, "bucket", "Asia", "broken")); e != nil {
+		t.Fatal(e)
+	}
+	if e = os.Symlink(filepath.Join(directory, "bucket", "Asia", "jack.txt"), filepath.Join(directory, "bucket", "Asia", "link")); e != nil {
+		t.Fatal(e)
+	}
+
+	for _, delimiter := range []string{"", "/"} {
+		result, err := fs.ListObjects("bucket", "Asia/", "", delimiter, 1000)
+		if err != nil {
+			t.Fatalf("Expected to pass, but failed with: <ERROR> %s", err.Cause.Error())
+		}
+		var names []string
+		for _, objInfo := range result.Objects {
+			names = append(names, objInfo.Name)
+		}
+		if fmt.Sprint(names) != fmt.Sprint([]string{"Asia/jack.txt"}) {
+			t.Errorf("Expected symlinks to be skipped with delimiter \"%s\", but instead found %v", delimiter, names)
+		}
+	}
+
+	for _, object := range []string{"Asia/broken", "Asia/link"} {
+		if _, err = fs.GetObject("bucket", object, 0); err == nil {
+			t.Errorf("Expected reading %s to fail, but it passed instead", object)
+		} else if _, ok := err.ToGoError().(ObjectNotFound); !ok {
+			t.Errorf("Expected ObjectNotFound reading %s, but instead found \"%s\"", object, err.Cause.Error())
+		}
+		if _, err = fs.GetObjectInfo("bucket", object); err == nil {
+			t.Errorf("Expected stat of %s to fail, but it passed instead", object)
+		} else if _, ok := err.ToGoError().(ObjectNotFound); !ok {
+			t.Errorf("Expected ObjectNotFound stat of %s, but instead found \"%s\"", object, err.Cause.Error())
 		}
-		r.Close()
 	}
 }
@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// rateLimiter - token bucket of bytes refilled at rate bytes per
+// second, holding at most a second worth of bytes. Starts empty so
+// that a transfer never exceeds the rate from its first byte.
+type rateLimiter struct {
+	mutex  *sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter - returns a limiter of rate bytes per second, nil
+// when the rate is zero and transfers are not limited.
+func newRateLimiter(rate int64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		mutex: &sync.Mutex{},
+		rate:  rate,
+		last:  time.Now(),
+	}
+}
+
+// wait - blocks until n bytes may be transferred. Waiting transfers
+// reserve their bytes in turn, sharing the rate between them.
+func (l *rateLimiter) wait(n int) {
+	l.mutex.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	deficit := -l.tokens
+	l.mutex.Unlock()
+
+	if deficit > 0 {
+		time.Sleep(time.Duration(deficit / float64(l.rate) * float64(time.Second)))
+	}
+}
+
+// rateLimitedReader - reads at most at the rate of each of its
+// limiters.
+type rateLimitedReader struct {
+	reader   io.Reader
+	limiters []*rateLimiter
+	maxRead  int64
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	// Bound reads to a second worth of bytes, so that waits stay
+	// short and the transfer smooth.
+	if int64(len(p)) > r.maxRead {
+		p = p[:r.maxRead]
+	}
+	n, e := r.reader.Read(p)
+	for _, limiter := range r.limiters {
+		limiter.wait(n)
+	}
+	return n, e
+}
+
+// rateLimitedReadCloser - rateLimitedReader closing the underlying
+// reader.
+type rateLimitedReadCloser struct {
+	*rateLimitedReader
+	io.Closer
+}
+
+// limitReader - limits a transfer of object data to the global rate
+// limit and to the rate limit of each request, returns the reader
+// itself without limits configured.
+func (fs Filesystem) limitReader(reader io.Reader) io.Reader {
+	var limiters []*rateLimiter
+	if fs.rateLimiter != nil {
+		limiters = append(limiters, fs.rateLimiter)
+	}
+	if limiter := newRateLimiter(fs.requestRateLimit); limiter != nil {
+		limiters = append(limiters, limiter)
+	}
+	if len(limiters) == 0 {
+		return reader
+	}
+	maxRead := limiters[0].rate
+	for _, limiter := range limiters {
+		if limiter.rate < maxRead {
+			maxRead = limiter.rate
+		}
+	}
+	return &rateLimitedReader{reader: reader, limiters: limiters, maxRead: maxRead}
+}
+
+// limitReadCloser - see limitReader.
+func (fs Filesystem) limitReadCloser(readCloser io.ReadCloser) io.ReadCloser {
+	reader, ok := fs.limitReader(readCloser).(*rateLimitedReader)
+	if !ok {
+		return readCloser
+	}
+	return rateLimitedReadCloser{rateLimitedReader: reader, Closer: readCloser}
+}
@@ -0,0 +1,114 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Testing transfers are limited to the configured rates.
+func TestRateLimit(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-rate-limit-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Transfers of dataSize bytes at rate take at least a quarter second.
+	rate := int64(20000)
+	dataSize := rate / 4
+	minDuration := time.Duration(float64(dataSize) / float64(rate) * float64(time.Second) * 0.9)
+	data := bytes.Repeat([]byte("a"), int(dataSize))
+
+	defer func(srvCfg *serverConfigV4) { serverConfig = srvCfg }(serverConfig)
+	testCases := []storageConfig{
+		{RequestRateLimit: rate},
+		{RateLimit: rate},
+		// The lower of both limits applies.
+		{RateLimit: rate * 100, RequestRateLimit: rate},
+	}
+	for i, storage := range testCases {
+		serverConfig = &serverConfigV4{
+			Credential: mustGenAccessKeys(),
+			Storage:    storage,
+			rwMutex:    &sync.RWMutex{},
+		}
+		fs, err := newFS(directory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if err = fs.MakeBucket("bucket"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		start := time.Now()
+		if _, err = fs.PutObject("bucket", "object", dataSize, bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed < minDuration {
+			t.Errorf("Test %d: Expected PutObject to take at least %s, but instead took %s", i+1, minDuration, elapsed)
+		}
+
+		uploadID, err := fs.NewMultipartUpload("bucket", "object")
+		if err != nil {
+			t.Fatal(err)
+		}
+		start = time.Now()
+		if _, err = fs.PutObjectPart("bucket", "object", uploadID, 1, dataSize, bytes.NewReader(data), ""); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed < minDuration {
+			t.Errorf("Test %d: Expected PutObjectPart to take at least %s, but instead took %s", i+1, minDuration, elapsed)
+		}
+		if err = fs.AbortMultipartUpload("bucket", "object", uploadID); err != nil {
+			t.Fatal(err)
+		}
+
+		start = time.Now()
+		reader, err := fs.GetObject("bucket", "object", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, e := io.Copy(ioutil.Discard, reader)
+		reader.Close()
+		if e != nil || n != dataSize {
+			t.Fatalf("Test %d: Expected to read %d bytes, but instead read %d, %v", i+1, dataSize, n, e)
+		}
+		if elapsed := time.Since(start); elapsed < minDuration {
+			t.Errorf("Test %d: Expected GetObject to take at least %s, but instead took %s", i+1, minDuration, elapsed)
+		}
+		fs.(*Filesystem).Close()
+	}
+
+	// Negative rates are invalid.
+	serverConfig = &serverConfigV4{
+		Credential: mustGenAccessKeys(),
+		Storage:    storageConfig{RateLimit: -1},
+		rwMutex:    &sync.RWMutex{},
+	}
+	if _, err := newFS(directory); err == nil {
+		t.Fatal("Expected a negative rate limit to fail, but it passed instead")
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/minio/minio/pkg/probe"
+	"github.com/skyrings/skyring-common/tools/uuid"
+)
+
+// Directory under the metadata path holding resumable puts, not a
+// valid bucket name.
+const resumableDir = "$resumable"
+
+// Suffix of the sidecar file recording the target of a resumable put.
+const resumableInfoSuffix = ".json"
+
+// resumableInfo - target object of a resumable put.
+type resumableInfo struct {
+	Bucket string `json:"bucket"`
+	Object string `json:"object"`
+}
+
+// resumablePath - returns the temp file path of a resumable put.
+func (fs Filesystem) resumablePath(token string) string {
+	return filepath.Join(fs.metaPath, resumableDir, token)
+}
+
+// readResumableInfo - validates token and returns the target of its
+// resumable put.
+func (fs Filesystem) readResumableInfo(token string) (resumableInfo, error) {
+	// Tokens are uuids, reject anything else before touching the disk.
+	if id, e := uuid.Parse(token); e != nil || id.IsZero() {
+		return resumableInfo{}, InvalidResumeToken{Token: token}
+	}
+	info := resumableInfo{}
+	if e := readMetaFile(fs.resumablePath(token)+resumableInfoSuffix, &info); e != nil {
+		if os.IsNotExist(e) {
+			return resumableInfo{}, InvalidResumeToken{Token: token}
+		}
+		return resumableInfo{}, e
+	}
+	return info, nil
+}
+
+// BeginResumablePut - starts a resumable put of an object, returns a
+// token to append data with.
+func (fs Filesystem) BeginResumablePut(bucket, object string) (string, *probe.Error) {
+	bucketDirName, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object)
+	}
+	bucket = bucketDirName
+	if !IsValidObjectName(object) {
+		return "", probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+
+	if e = os.MkdirAll(filepath.Join(fs.metaPath, resumableDir), fs.dirPerm(0755)); e != nil {
+		return "", probe.NewError(e).Trace(bucket, object)
+	}
+	for i := 0; i < maxUploadIDAttempts; i++ {
+		token, e := newUUID()
+		if e != nil {
+			return "", probe.NewError(e).Trace(bucket, object)
+		}
+
+		// Create empty temp file exclusively to reserve the token.
+		file, e := os.OpenFile(fs.resumablePath(token), os.O_WRONLY|os.O_CREATE|os.O_EXCL, fs.filePerm(0644))
+		if e != nil {
+			if os.IsExist(e) {
+				continue
+			}
+			return "", probe.NewError(e).Trace(bucket, object)
+		}
+		if e = file.Close(); e != nil {
+			return "", probe.NewError(e).Trace(bucket, object)
+		}
+		info := resumableInfo{Bucket: bucket, Object: object}
+		if e = fs.writeMetaFile(fs.resumablePath(token)+resumableInfoSuffix, info); e != nil {
+			os.Remove(fs.resumablePath(token))
+			return "", probe.NewError(e).Trace(bucket, object)
+		}
+		return token, nil
+	}
+	return "", probe.NewError(errUploadIDAttemptsExhausted).Trace(bucket, object)
+}
+
+// AppendResumablePut - appends data to a resumable put at offset, which
+// must equal the number of bytes received so far. Returns the number
+// of bytes received including data.
+func (fs Filesystem) AppendResumablePut(token string, offset int64, data io.Reader) (int64, *probe.Error) {
+	if _, e := fs.readResumableInfo(token); e != nil {
+		return 0, probe.NewError(e).Trace(token)
+	}
+	if e := fs.checkDiskFree(); e != nil {
+		return 0, probe.NewError(e).Trace(token)
+	}
+
+	// Serialize appends of the same token.
+	fs.nsLock.lock(resumableDir, token)
+	defer fs.nsLock.unlock(resumableDir, token)
+
+	file, e := os.OpenFile(fs.resumablePath(token), os.O_WRONLY|os.O_APPEND, 0644)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return 0, probe.NewError(InvalidResumeToken{Token: token}).Trace(token)
+		}
+		return 0, probe.NewError(e).Trace(token)
+	}
+	defer file.Close()
+
+	st, e := file.Stat()
+	if e != nil {
+		return 0, probe.NewError(e).Trace(token)
+	}
+	// Offsets other than the current size would leave a gap or
+	// overwrite data already received.
+	if offset != st.Size() {
+		return st.Size(), probe.NewError(InvalidResumeOffset{Token: token, Offset: offset, Expected: st.Size()}).Trace(token)
+	}
+
+	diskWriter := &diskCheckWriter{writer: file, fs: fs}
+	written, e := io.Copy(diskWriter, data)
+	if e != nil {
+		// Keep the data received so far, the client resumes at the
+		// returned size.
+		return st.Size() + written, probe.NewError(e).Trace(token)
+	}
+	if fs.fsync {
+		if e = fsyncFile(file); e != nil {
+			return st.Size() + written, probe.NewError(e).Trace(token)
+		}
+	}
+	return st.Size() + written, nil
+}
+
+// FinishResumablePut - verifies the data of a resumable put against
+// md5sum and atomically commits it as the target object. An empty
+// md5sum skips verification.
+func (fs Filesystem) FinishResumablePut(token, md5sum string) (ObjectInfo, *probe.Error) {
+	info, e := fs.readResumableInfo(token)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(token)
+	}
+
+	fs.nsLock.lock(resumableDir, token)
+	defer fs.nsLock.unlock(resumableDir, token)
+
+	file, e := os.Open(fs.resumablePath(token))
+	if e != nil {
+		if os.IsNotExist(e) {
+			return ObjectInfo{}, probe.NewError(InvalidResumeToken{Token: token}).Trace(token)
+		}
+		return ObjectInfo{}, probe.NewError(e).Trace(token)
+	}
+	defer file.Close()
+	st, e := file.Stat()
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(token)
+	}
+
+	// On failure the data is kept so the put can be resumed or aborted.
+	objInfo, err := fs.PutObject(info.Bucket, info.Object, st.Size(), file, map[string]string{"md5Sum": md5sum})
+	if err != nil {
+		return ObjectInfo{}, err.Trace(token)
+	}
+	fs.removeResumable(token)
+	return objInfo, nil
+}
+
+// AbortResumablePut - discards the data of a resumable put.
+func (fs Filesystem) AbortResumablePut(token string) *probe.Error {
+	if _, e := fs.readResumableInfo(token); e != nil {
+		return probe.NewError(e).Trace(token)
+	}
+
+	fs.nsLock.lock(resumableDir, token)
+	defer fs.nsLock.unlock(resumableDir, token)
+
+	if e := fs.removeResumable(token); e != nil {
+		return probe.NewError(e).Trace(token)
+	}
+	return nil
+}
+
+// removeResumable - removes the temp file and sidecar of a resumable put.
+func (fs Filesystem) removeResumable(token string) error {
+	if e := os.Remove(fs.resumablePath(token) + resumableInfoSuffix); e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	if e := os.Remove(fs.resumablePath(token)); e != nil && !os.IsNotExist(e) {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// interruptedReader - returns the data of reader followed by an error,
+// simulating a dropped connection.
+type interruptedReader struct {
+	reader io.Reader
+}
+
+func (r interruptedReader) Read(p []byte) (int, error) {
+	n, e := r.reader.Read(p)
+	if e == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, e
+}
+
+func TestResumablePut(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-resumable-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-resumable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+
+	data := strings.Repeat("abcdefgh", 1024)
+	md5Hasher := md5.New()
+	md5Hasher.Write([]byte(data))
+	md5Hex := hex.EncodeToString(md5Hasher.Sum(nil))
+
+	token, err := filesystem.BeginResumablePut("test-resumable", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Connection drops after the first 3000 bytes.
+	received, err := filesystem.AppendResumablePut(token, 0, interruptedReader{strings.NewReader(data[:3000])})
+	if err == nil {
+		t.Fatal("Expected interrupted append to fail")
+	}
+	if received != 3000 {
+		t.Fatalf("Expected 3000 bytes received, got %d", received)
+	}
+
+	// Object is not visible before the put is finished.
+	if _, err = fs.GetObjectInfo("test-resumable", "object"); err == nil {
+		t.Fatal("Expected object to be missing before finishing the put")
+	}
+
+	// Resume at the received offset.
+	received, err = filesystem.AppendResumablePut(token, received, strings.NewReader(data[received:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != int64(len(data)) {
+		t.Fatalf("Expected %d bytes received, got %d", len(data), received)
+	}
+
+	objInfo, err := filesystem.FinishResumablePut(token, md5Hex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != int64(len(data)) || objInfo.MD5Sum != md5Hex {
+		t.Fatalf("Unexpected object info %+v", objInfo)
+	}
+	var buffer bytes.Buffer
+	reader, err := fs.GetObject("test-resumable", "object", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, e = io.Copy(&buffer, reader); e != nil {
+		t.Fatal(e)
+	}
+	reader.Close()
+	if buffer.String() != data {
+		t.Fatal("Object data does not match the resumed data")
+	}
+
+	// Token is gone once the put is finished.
+	_, err = filesystem.AppendResumablePut(token, received, strings.NewReader("more"))
+	if err == nil {
+		t.Fatal("Expected append to a finished put to fail")
+	}
+	if _, ok := err.ToGoError().(InvalidResumeToken); !ok {
+		t.Fatalf("Expected InvalidResumeToken, got %s", err.Cause.Error())
+	}
+}
+
+func TestResumablePutInvalid(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-resumable-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-resumable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+
+	token, err := filesystem.BeginResumablePut("test-resumable", "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filesystem.AppendResumablePut(token, 0, strings.NewReader("abcd")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Gap and overlap offsets are rejected.
+	for i, offset := range []int64{5, 2, 0} {
+		received, err := filesystem.AppendResumablePut(token, offset, strings.NewReader("efgh"))
+		if err == nil {
+			t.Fatalf("Test %d: Expected offset %d to be rejected", i+1, offset)
+		}
+		if _, ok := err.ToGoError().(InvalidResumeOffset); !ok {
+			t.Fatalf("Test %d: Expected InvalidResumeOffset, got %s", i+1, err.Cause.Error())
+		}
+		if received != 4 {
+			t.Fatalf("Test %d: Expected 4 bytes received, got %d", i+1, received)
+		}
+	}
+
+	// Mismatching md5 keeps the data for another attempt.
+	_, err = filesystem.FinishResumablePut(token, "e2fc714c4727ee9395f324cd2e7f331e")
+	if err == nil {
+		t.Fatal("Expected mismatching md5 to fail")
+	}
+	if _, ok := err.ToGoError().(BadDigest); !ok {
+		t.Fatalf("Expected BadDigest, got %s", err.Cause.Error())
+	}
+	if _, err = filesystem.FinishResumablePut(token, "e2fc714c4727ee9395f324cd2e7f331f"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Malformed and unknown tokens.
+	for i, token := range []string{"../../test-resumable/object", "8b4ea76a-4b7e-4f21-a4a2-2a8e3a3b1d2c"} {
+		_, err = filesystem.AppendResumablePut(token, 0, strings.NewReader("abcd"))
+		if err == nil {
+			t.Fatalf("Test %d: Expected token %s to be rejected", i+1, token)
+		}
+		if _, ok := err.ToGoError().(InvalidResumeToken); !ok {
+			t.Fatalf("Test %d: Expected InvalidResumeToken, got %s", i+1, err.Cause.Error())
+		}
+	}
+
+	// Aborted puts leave nothing behind.
+	token, err = filesystem.BeginResumablePut("test-resumable", "aborted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = filesystem.AbortResumablePut(token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filesystem.FinishResumablePut(token, ""); err == nil {
+		t.Fatal("Expected finishing an aborted put to fail")
+	}
+	if _, err = fs.GetObjectInfo("test-resumable", "aborted"); err == nil {
+		t.Fatal("Expected aborted object to be missing")
+	}
+}
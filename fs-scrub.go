@@ -0,0 +1,159 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// ScrubReport - result of scrubbing the objects of a bucket.
+type ScrubReport struct {
+	// Objects matching their persisted checksums.
+	OK int
+	// Objects without persisted checksums, which were created.
+	Repaired int
+	// Objects not matching their persisted checksums.
+	Corrupt int
+	// Names of the corrupt objects.
+	CorruptObjects []string
+}
+
+// ScrubBucket - verifies every object of a bucket against its
+// persisted checksums, reporting mismatches. Objects written before
+// checksums were persisted get them created from their current data.
+func (fs Filesystem) ScrubBucket(bucket string) (ScrubReport, *probe.Error) {
+	bucketDirName, e := fs.checkBucketArg(bucket)
+	if e != nil {
+		return ScrubReport{}, probe.NewError(e).Trace(bucket)
+	}
+	bucket = bucketDirName
+
+	var objects []string
+	bucketDir := filepath.Join(fs.path, bucket)
+	walkFn := func(path string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+		// Skip directories.
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		object, e := filepath.Rel(bucketDir, path)
+		if e != nil {
+			return e
+		}
+		object = filepath.ToSlash(object)
+		// Skip temporary files of writes in progress and sidecar files.
+		if isInternalName(object) {
+			return nil
+		}
+		if strings.HasSuffix(object, "/"+dirObjectMarker) || object == dirObjectMarker {
+			object = strings.TrimSuffix(object, dirObjectMarker)
+		}
+		objects = append(objects, object)
+		return nil
+	}
+	if e = filepath.Walk(bucketDir, walkFn); e != nil {
+		return ScrubReport{}, probe.NewError(e).Trace(bucket)
+	}
+
+	report := ScrubReport{}
+	for _, object := range objects {
+		corrupt, repaired, e := fs.scrubObject(bucket, object)
+		if e != nil {
+			// Object removed while scrubbing.
+			if os.IsNotExist(e) {
+				continue
+			}
+			return report, probe.NewError(e).Trace(bucket)
+		}
+		switch {
+		case corrupt:
+			report.Corrupt++
+			report.CorruptObjects = append(report.CorruptObjects, object)
+		case repaired:
+			report.Repaired++
+		default:
+			report.OK++
+		}
+	}
+	return report, nil
+}
+
+// scrubObject - verifies an object against its persisted checksums,
+// persisting them if missing.
+func (fs Filesystem) scrubObject(bucket, object string) (corrupt bool, repaired bool, e error) {
+	// Serialize with writers of the same object.
+	fs.nsLock.lock(bucket, object)
+	defer fs.nsLock.unlock(bucket, object)
+
+	objMeta, e := fs.readObjectMetadata(bucket, object)
+	missing := os.IsNotExist(e)
+	if e != nil && !missing {
+		return false, false, e
+	}
+
+	file, e := os.Open(filepath.Join(fs.path, bucket, objectStorageName(object)))
+	if e != nil {
+		return false, false, e
+	}
+	defer file.Close()
+
+	algorithm := objMeta.Checksum.Algorithm
+	if algorithm == "" {
+		algorithm = fs.checksumAlgo
+	}
+	checksumHasher, e := newChecksumHasher(algorithm)
+	if e != nil {
+		return false, false, e
+	}
+	md5Hasher := md5.New()
+	if _, e = io.Copy(io.MultiWriter(md5Hasher, checksumHasher), file); e != nil {
+		return false, false, e
+	}
+	md5Hex := hex.EncodeToString(md5Hasher.Sum(nil))
+	checksum := hex.EncodeToString(checksumHasher.Sum(nil))
+
+	if !missing {
+		if objMeta.Checksum.Algorithm != "" {
+			return objMeta.Checksum.Hash != checksum, false, nil
+		}
+		// Multipart md5sums are not an md5sum of the data.
+		if objMeta.MD5Sum != "" && !isMultipartMD5(objMeta.MD5Sum) && objMeta.MD5Sum != md5Hex {
+			return true, false, nil
+		}
+	} else {
+		objMeta.MD5Sum = md5Hex
+	}
+	objMeta.Checksum = checksumInfo{Algorithm: algorithm, Hash: checksum}
+	if e = fs.writeObjectMetadata(bucket, object, objMeta); e != nil {
+		return false, false, e
+	}
+	return false, true, nil
+}
+
+// isMultipartMD5 - md5sums of multipart objects carry a part count suffix.
+func isMultipartMD5(md5Sum string) bool {
+	return strings.Contains(md5Sum, "-")
+}
@@ -0,0 +1,101 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScrubBucket(t *testing.T) {
+	directory, e := ioutil.TempDir("", "minio-scrub-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.MakeBucket("test-scrub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesystem := fs.(*Filesystem)
+
+	for _, object := range []string{"intact", "photos/tampered", "dir/"} {
+		_, err = fs.PutObject("test-scrub", object, int64(len("abcd")), bytes.NewBufferString("abcd"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Tamper with object data behind the checksum.
+	if e = ioutil.WriteFile(filepath.Join(directory, "test-scrub", "photos", "tampered"), []byte("abce"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	// Object written before checksums were persisted.
+	if e = ioutil.WriteFile(filepath.Join(directory, "test-scrub", "legacy"), []byte("abcd"), 0600); e != nil {
+		t.Fatal(e)
+	}
+	if _, e = filesystem.readObjectMetadata("test-scrub", "legacy"); !os.IsNotExist(e) {
+		t.Fatalf("Expected no metadata for the legacy object, got %v", e)
+	}
+
+	report, err := filesystem.ScrubBucket("test-scrub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ScrubReport{OK: 2, Repaired: 1, Corrupt: 1, CorruptObjects: []string{"photos/tampered"}}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("Expected report %+v, got %+v", expected, report)
+	}
+
+	// Checksums were created for the legacy object.
+	objMeta, e := filesystem.readObjectMetadata("test-scrub", "legacy")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if objMeta.MD5Sum != "e2fc714c4727ee9395f324cd2e7f331f" || objMeta.Checksum.Algorithm == "" {
+		t.Fatalf("Unexpected metadata %+v for the legacy object", objMeta)
+	}
+	reader, err := filesystem.GetObjectVerified("test-scrub", "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.Close()
+
+	// Scrubbing again finds only the corrupt object.
+	report, err = filesystem.ScrubBucket("test-scrub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = ScrubReport{OK: 3, Corrupt: 1, CorruptObjects: []string{"photos/tampered"}}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("Expected report %+v, got %+v", expected, report)
+	}
+
+	// Missing bucket.
+	if _, err = filesystem.ScrubBucket("missing-bucket"); err == nil {
+		t.Fatal("Expected ScrubBucket of a missing bucket to fail")
+	}
+}
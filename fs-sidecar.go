@@ -0,0 +1,25 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "path/filepath"
+
+// sidecarPath - path of a per object sidecar file, all sidecars live
+// under the metadata path so the data path only holds objects.
+func (fs Filesystem) sidecarPath(bucket, object, suffix string) string {
+	return filepath.Join(fs.metaPath, bucket, objectStorageName(object)+suffix)
+}
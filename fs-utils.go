@@ -17,7 +17,10 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -40,7 +43,8 @@ func IsValidBucketName(bucket string) bool {
 
 // IsValidObjectName verifies an object name in accordance with Amazon's
 // requirements. It cannot exceed 1024 characters and must be a valid UTF8
-// string.
+// string. Names with a path component of the directory object marker
+// are reserved, they would address the data of directory objects.
 // See: http://docs.aws.amazon.com/AmazonS3/latest/dev/UsingMetadata.html
 func IsValidObjectName(object string) bool {
 	if len(object) > 1024 || len(object) == 0 {
@@ -49,9 +53,50 @@ func IsValidObjectName(object string) bool {
 	if !utf8.ValidString(object) {
 		return false
 	}
+	for _, component := range strings.Split(object, "/") {
+		if component == dirObjectMarker {
+			return false
+		}
+	}
 	return true
 }
 
+// isInternalName - returns true for multipart directories and temporary
+// files of the server in the data tree, which are never listed and can
+// not be written as objects. They are matched by path component so that
+// keys merely containing their names are still listed. Sidecar files
+// live under the metadata path, their suffixes are valid object names.
+func isInternalName(name string) bool {
+	name = filepath.ToSlash(name)
+	for _, component := range strings.Split(name, "/") {
+		if component == "$multiparts" || strings.HasPrefix(component, "$tmpobject") {
+			return true
+		}
+	}
+	return false
+}
+
+// isObjectFileMode - returns true for files of the data tree which are
+// objects, only regular files are. Symlinks, fifos, sockets and devices
+// are skipped by listings and not found by reads.
+func isObjectFileMode(mode os.FileMode) bool {
+	return mode.IsRegular()
+}
+
+// lstatObject - Lstat of a path in the data tree without following
+// symlinks, files which are not objects are reported as not existing.
+// Directories are returned as is.
+func lstatObject(path string) (os.FileInfo, error) {
+	st, e := os.Lstat(path)
+	if e != nil {
+		return nil, e
+	}
+	if !st.IsDir() && !isObjectFileMode(st.Mode()) {
+		return nil, &os.PathError{Op: "lstat", Path: path, Err: os.ErrNotExist}
+	}
+	return st, nil
+}
+
 // IsValidObjectPrefix verifies whether the prefix is a valid object name.
 // Its valid to have a empty prefix.
 func IsValidObjectPrefix(object string) bool {
@@ -106,6 +106,9 @@ func TestIsValidObjectName(t *testing.T) {
 		//passing invalid object names
 		{"", false},
 		{string([]byte{0xff, 0xfe, 0xfd}), false},
+		{"$dirobject", false},
+		{"folder/$dirobject", false},
+		{"folder/$dirobject/object", false},
 	}
 
 	for i, testCase := range testCases {
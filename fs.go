@@ -17,7 +17,12 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/minio/minio/pkg/probe"
 )
@@ -42,36 +47,256 @@ type listMultipartObjectParams struct {
 // Filesystem - local variables
 type Filesystem struct {
 	path                        string
+	metaPath                    string
 	minFreeDisk                 int64
+	checksumAlgo                string
+	fsync                       bool
+	verifyWrites                bool
+	dedup                       bool
+	mirrorPath                  string
+	tempPath                    string
+	plainSinglePartETag         bool
+	maxObjectSize               int64
+	retryAttempts               int
+	listWalkers                 int
+	rateLimiter                 *rateLimiter
+	requestRateLimit            int64
+	partWrites                  chan struct{}
+	dirMode                     os.FileMode
+	fileMode                    os.FileMode
 	rwLock                      *sync.RWMutex
+	nsLock                      *nsLockMap
+	metrics                     *opMetrics
+	events                      *eventNotifier
+	closed                      chan struct{}
+	closeOnce                   *sync.Once
+	workers                     *sync.WaitGroup
 	listObjectMap               map[listObjectParams][]*treeWalker
+	listCache                   *listCache
+	usage                       *bucketUsageCache
+	completedUploads            *completedUploadCache
 	listObjectMapMutex          *sync.Mutex
 	listMultipartObjectMap      map[listMultipartObjectParams][]multipartObjectInfoChannel
 	listMultipartObjectMapMutex *sync.Mutex
 }
 
 // newFS instantiate a new filesystem.
-func newFS(rootPath string) (ObjectAPI, *probe.Error) {
+func newFS(rootPath string) (ObjectLayer, *probe.Error) {
 	fs := &Filesystem{
-		rwLock: &sync.RWMutex{},
+		rwLock:  &sync.RWMutex{},
+		nsLock:  newNSLock(),
+		metrics: &opMetrics{},
+		events:  newEventNotifier(),
+		usage:   newBucketUsageCache(),
+		// Results of recently completed multipart uploads.
+		completedUploads: newCompletedUploadCache(),
+		// Closed by Close, stops background workers.
+		closed:    make(chan struct{}),
+		closeOnce: &sync.Once{},
+		workers:   &sync.WaitGroup{},
 	}
 	fs.path = rootPath
 
 	/// Defaults
 
+	// Metadata path for multipart uploads and object metadata.
+	fs.metaPath = filepath.Join(rootPath, configDir)
+
 	// Minium free disk required for i/o operations to succeed.
 	fs.minFreeDisk = 5
 
+	// Checksum algorithm for internal integrity verification.
+	fs.checksumAlgo = checksumMD5
+
+	// Attempts of renames failing with transient errors.
+	fs.retryAttempts = 3
+
+	// Concurrent writes of multipart parts.
+	maxPartWrites := 64
+
+	// Background workers started once initialized.
+	var cleanupInterval, multipartExpiry time.Duration
+	var webhook *webhookTarget
+
+	// Apply storage configuration if available.
+	if serverConfig != nil {
+		storage := serverConfig.GetStorageConfig()
+		if storage.Checksum != "" {
+			if !isValidChecksumAlgorithm(storage.Checksum) {
+				return nil, probe.NewError(fmt.Errorf("Unsupported checksum algorithm %s", storage.Checksum))
+			}
+			fs.checksumAlgo = storage.Checksum
+		}
+		fs.fsync = storage.Fsync
+		fs.verifyWrites = storage.VerifyWrites
+		fs.dedup = storage.Dedup
+		fs.mirrorPath = storage.Mirror
+		fs.tempPath = storage.TempDir
+		fs.plainSinglePartETag = storage.PlainSinglePartETag
+		if storage.MaxObjectSize < 0 {
+			return nil, probe.NewError(fmt.Errorf("Invalid maximum object size %d", storage.MaxObjectSize))
+		}
+		fs.maxObjectSize = storage.MaxObjectSize
+		if storage.RetryAttempts < 0 {
+			return nil, probe.NewError(fmt.Errorf("Invalid retry attempts %d", storage.RetryAttempts))
+		}
+		if storage.RetryAttempts > 0 {
+			fs.retryAttempts = storage.RetryAttempts
+		}
+		if storage.MetaDir != "" {
+			fs.metaPath = storage.MetaDir
+		}
+		if storage.ListCacheSize < 0 {
+			return nil, probe.NewError(fmt.Errorf("Invalid list cache size %d", storage.ListCacheSize))
+		}
+		if storage.ListCacheSize > 0 {
+			fs.listCache = newListCache(storage.ListCacheSize)
+		}
+		if storage.ListWalkers < 0 {
+			return nil, probe.NewError(fmt.Errorf("Invalid list walkers %d", storage.ListWalkers))
+		}
+		fs.listWalkers = storage.ListWalkers
+		if storage.RateLimit < 0 {
+			return nil, probe.NewError(fmt.Errorf("Invalid rate limit %d", storage.RateLimit))
+		}
+		fs.rateLimiter = newRateLimiter(storage.RateLimit)
+		if storage.RequestRateLimit < 0 {
+			return nil, probe.NewError(fmt.Errorf("Invalid request rate limit %d", storage.RequestRateLimit))
+		}
+		fs.requestRateLimit = storage.RequestRateLimit
+		if storage.MaxPartWrites < 0 {
+			return nil, probe.NewError(fmt.Errorf("Invalid maximum part writes %d", storage.MaxPartWrites))
+		}
+		if storage.MaxPartWrites > 0 {
+			maxPartWrites = storage.MaxPartWrites
+		}
+		var e error
+		if fs.dirMode, e = parseFileMode(storage.DirMode); e != nil {
+			return nil, probe.NewError(e)
+		}
+		if fs.fileMode, e = parseFileMode(storage.FileMode); e != nil {
+			return nil, probe.NewError(e)
+		}
+
+		if storage.MultipartCleanupInterval != "" {
+			cleanupInterval, e = time.ParseDuration(storage.MultipartCleanupInterval)
+			if e != nil || cleanupInterval <= 0 {
+				return nil, probe.NewError(fmt.Errorf("Invalid multipart cleanup interval %s", storage.MultipartCleanupInterval))
+			}
+			multipartExpiry = defaultMultipartExpiry
+			if storage.MultipartExpiry != "" {
+				if multipartExpiry, e = time.ParseDuration(storage.MultipartExpiry); e != nil || multipartExpiry < 0 {
+					return nil, probe.NewError(fmt.Errorf("Invalid multipart expiry %s", storage.MultipartExpiry))
+				}
+			}
+		}
+
+		// Configured event targets.
+		if config := serverConfig.GetNotifyConfig().Webhook; config.Enable {
+			if webhook, e = newWebhookTarget(config); e != nil {
+				return nil, probe.NewError(e)
+			}
+			fs.RegisterEventHandler(EventFilter{}, webhook.send)
+		}
+	}
+
+	fs.partWrites = make(chan struct{}, maxPartWrites)
+
 	fs.listObjectMap = make(map[listObjectParams][]*treeWalker)
 	fs.listObjectMapMutex = &sync.Mutex{}
 
 	fs.listMultipartObjectMap = make(map[listMultipartObjectParams][]multipartObjectInfoChannel)
 	fs.listMultipartObjectMapMutex = &sync.Mutex{}
 
+	// Verify temporary files can be renamed into the data path.
+	if fs.tempPath != "" {
+		if e := fs.checkTempDir(); e != nil {
+			return nil, probe.NewError(e)
+		}
+	}
+
+	// Start the background workers.
+	if cleanupInterval > 0 {
+		fs.goBackground(fs.runUploadCleanup(cleanupInterval, multipartExpiry))
+	}
+	if webhook != nil {
+		fs.goBackground(webhook.run)
+	}
+
 	// Return here.
 	return fs, nil
 }
 
+// goBackground - runs worker in the background until the filesystem is
+// closed, worker must return once stop is closed.
+func (fs *Filesystem) goBackground(worker func(stop <-chan struct{})) {
+	fs.workers.Add(1)
+	go func() {
+		defer fs.workers.Done()
+		worker(fs.closed)
+	}()
+}
+
+// Close - stops saved listings and background workers, waiting for the
+// workers to deliver pending events. Operations on a closed filesystem
+// fail with FilesystemClosed, closing again does nothing.
+func (fs *Filesystem) Close() error {
+	fs.closeOnce.Do(func() {
+		close(fs.closed)
+		fs.workers.Wait()
+
+		// Release the saved listings, their walks stopped.
+		fs.listObjectMapMutex.Lock()
+		for params := range fs.listObjectMap {
+			delete(fs.listObjectMap, params)
+		}
+		fs.listObjectMapMutex.Unlock()
+		fs.listMultipartObjectMapMutex.Lock()
+		for params := range fs.listMultipartObjectMap {
+			delete(fs.listMultipartObjectMap, params)
+		}
+		fs.listMultipartObjectMapMutex.Unlock()
+	})
+	return nil
+}
+
+// parseFileMode - parses octal permissions, empty mode returns 0.
+func parseFileMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	perm, e := strconv.ParseUint(mode, 8, 32)
+	if e != nil || perm == 0 || perm > 0777 {
+		return 0, fmt.Errorf("Invalid file mode %s", mode)
+	}
+	return os.FileMode(perm), nil
+}
+
+// getOwner - returns the canonical owner of all buckets and objects,
+// the access key of the configured credential.
+func getOwner() string {
+	if serverConfig == nil {
+		return ""
+	}
+	return serverConfig.GetCredential().AccessKeyID
+}
+
+// dirPerm - returns the configured directory mode, defaultMode if unset.
+func (fs Filesystem) dirPerm(defaultMode os.FileMode) os.FileMode {
+	if fs.dirMode != 0 {
+		return fs.dirMode
+	}
+	return defaultMode
+}
+
+// filePerm - returns the configured file mode, defaultMode if unset.
+func (fs Filesystem) filePerm(defaultMode os.FileMode) os.FileMode {
+	if fs.fileMode != 0 {
+		return fs.fileMode
+	}
+	return defaultMode
+}
+
 // GetRootPath - get root path.
 func (fs Filesystem) GetRootPath() string {
 	return fs.path
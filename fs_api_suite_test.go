@@ -27,8 +27,13 @@ import (
 	"gopkg.in/check.v1"
 )
 
+// suiteRand - random source of the suite, the expected md5sums rely on
+// its sequence which restarts for every object layer under test.
+var suiteRand *rand.Rand
+
 // APITestSuite - collection of API tests
-func APITestSuite(c *check.C, create func() ObjectAPI) {
+func APITestSuite(c *check.C, create func() ObjectLayer) {
+	suiteRand = rand.New(rand.NewSource(1))
 	testMakeBucket(c, create)
 	testMultipleObjectCreation(c, create)
 	testPaging(c, create)
@@ -46,13 +51,13 @@ func APITestSuite(c *check.C, create func() ObjectAPI) {
 	testMultipartObjectAbort(c, create)
 }
 
-func testMakeBucket(c *check.C, create func() ObjectAPI) {
+func testMakeBucket(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	err := fs.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
 }
 
-func testMultipartObjectCreation(c *check.C, create func() ObjectAPI) {
+func testMultipartObjectCreation(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	err := fs.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
@@ -61,7 +66,7 @@ func testMultipartObjectCreation(c *check.C, create func() ObjectAPI) {
 
 	completedParts := completeMultipartUpload{}
 	for i := 1; i <= 10; i++ {
-		randomPerm := rand.Perm(10)
+		randomPerm := suiteRand.Perm(10)
 		randomString := ""
 		for _, num := range randomPerm {
 			randomString = randomString + strconv.Itoa(num)
@@ -82,7 +87,7 @@ func testMultipartObjectCreation(c *check.C, create func() ObjectAPI) {
 	c.Assert(objInfo.MD5Sum, check.Equals, "3605d84b1c43b1a664aa7c0d5082d271-10")
 }
 
-func testMultipartObjectAbort(c *check.C, create func() ObjectAPI) {
+func testMultipartObjectAbort(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	err := fs.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
@@ -92,7 +97,7 @@ func testMultipartObjectAbort(c *check.C, create func() ObjectAPI) {
 	parts := make(map[int]string)
 	metadata := make(map[string]string)
 	for i := 1; i <= 10; i++ {
-		randomPerm := rand.Perm(10)
+		randomPerm := suiteRand.Perm(10)
 		randomString := ""
 		for _, num := range randomPerm {
 			randomString = randomString + strconv.Itoa(num)
@@ -113,13 +118,13 @@ func testMultipartObjectAbort(c *check.C, create func() ObjectAPI) {
 	c.Assert(err, check.IsNil)
 }
 
-func testMultipleObjectCreation(c *check.C, create func() ObjectAPI) {
+func testMultipleObjectCreation(c *check.C, create func() ObjectLayer) {
 	objects := make(map[string][]byte)
 	fs := create()
 	err := fs.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
 	for i := 0; i < 10; i++ {
-		randomPerm := rand.Perm(10)
+		randomPerm := suiteRand.Perm(10)
 		randomString := ""
 		for _, num := range randomPerm {
 			randomString = randomString + strconv.Itoa(num)
@@ -154,7 +159,7 @@ func testMultipleObjectCreation(c *check.C, create func() ObjectAPI) {
 	}
 }
 
-func testPaging(c *check.C, create func() ObjectAPI) {
+func testPaging(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	fs.MakeBucket("bucket")
 	result, err := fs.ListObjects("bucket", "", "", "", 0)
@@ -254,7 +259,7 @@ func testPaging(c *check.C, create func() ObjectAPI) {
 	}
 }
 
-func testObjectOverwriteWorks(c *check.C, create func() ObjectAPI) {
+func testObjectOverwriteWorks(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	err := fs.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
@@ -275,13 +280,13 @@ func testObjectOverwriteWorks(c *check.C, create func() ObjectAPI) {
 	c.Assert(r.Close(), check.IsNil)
 }
 
-func testNonExistantBucketOperations(c *check.C, create func() ObjectAPI) {
+func testNonExistantBucketOperations(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	_, err := fs.PutObject("bucket", "object", int64(len("one")), bytes.NewBufferString("one"), nil)
 	c.Assert(err, check.Not(check.IsNil))
 }
 
-func testBucketRecreateFails(c *check.C, create func() ObjectAPI) {
+func testBucketRecreateFails(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	err := fs.MakeBucket("string")
 	c.Assert(err, check.IsNil)
@@ -289,7 +294,7 @@ func testBucketRecreateFails(c *check.C, create func() ObjectAPI) {
 	c.Assert(err, check.Not(check.IsNil))
 }
 
-func testPutObjectInSubdir(c *check.C, create func() ObjectAPI) {
+func testPutObjectInSubdir(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	err := fs.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
@@ -307,7 +312,7 @@ func testPutObjectInSubdir(c *check.C, create func() ObjectAPI) {
 	c.Assert(r.Close(), check.IsNil)
 }
 
-func testListBuckets(c *check.C, create func() ObjectAPI) {
+func testListBuckets(c *check.C, create func() ObjectLayer) {
 	fs := create()
 
 	// test empty list
@@ -339,7 +344,7 @@ func testListBuckets(c *check.C, create func() ObjectAPI) {
 	c.Assert(err, check.IsNil)
 }
 
-func testListBucketsOrder(c *check.C, create func() ObjectAPI) {
+func testListBucketsOrder(c *check.C, create func() ObjectLayer) {
 	// if implementation contains a map, order of map keys will vary.
 	// this ensures they return in the same order each time
 	for i := 0; i < 10; i++ {
@@ -357,7 +362,7 @@ func testListBucketsOrder(c *check.C, create func() ObjectAPI) {
 	}
 }
 
-func testListObjectsTestsForNonExistantBucket(c *check.C, create func() ObjectAPI) {
+func testListObjectsTestsForNonExistantBucket(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	result, err := fs.ListObjects("bucket", "", "", "", 1000)
 	c.Assert(err, check.Not(check.IsNil))
@@ -365,7 +370,7 @@ func testListObjectsTestsForNonExistantBucket(c *check.C, create func() ObjectAP
 	c.Assert(len(result.Objects), check.Equals, 0)
 }
 
-func testNonExistantObjectInBucket(c *check.C, create func() ObjectAPI) {
+func testNonExistantObjectInBucket(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	err := fs.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
@@ -380,7 +385,7 @@ func testNonExistantObjectInBucket(c *check.C, create func() ObjectAPI) {
 	}
 }
 
-func testGetDirectoryReturnsObjectNotFound(c *check.C, create func() ObjectAPI) {
+func testGetDirectoryReturnsObjectNotFound(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	err := fs.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
@@ -409,7 +414,7 @@ func testGetDirectoryReturnsObjectNotFound(c *check.C, create func() ObjectAPI)
 	}
 }
 
-func testDefaultContentType(c *check.C, create func() ObjectAPI) {
+func testDefaultContentType(c *check.C, create func() ObjectLayer) {
 	fs := create()
 	err := fs.MakeBucket("bucket")
 	c.Assert(err, check.IsNil)
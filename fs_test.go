@@ -25,7 +25,7 @@ import (
 
 func (s *MyAPISuite) TestAPISuite(c *C) {
 	var storageList []string
-	create := func() ObjectAPI {
+	create := func() ObjectLayer {
 		path, e := ioutil.TempDir(os.TempDir(), "minio-")
 		c.Check(e, IsNil)
 		storageList = append(storageList, path)
@@ -37,6 +37,10 @@ func (s *MyAPISuite) TestAPISuite(c *C) {
 	defer removeRoots(c, storageList)
 }
 
+func (s *MyAPISuite) TestAPISuiteMemory(c *C) {
+	APITestSuite(c, newMemory)
+}
+
 func removeRoots(c *C, roots []string) {
 	for _, root := range roots {
 		os.RemoveAll(root)
@@ -29,6 +29,10 @@ const (
 	b = "bytes="
 )
 
+// errMalformedRange - Range header which is no valid single byte range,
+// such a header is ignored as per RFC 7233.
+var errMalformedRange = errors.New("malformed range")
+
 // HttpRange specifies the byte range to be sent to the client.
 type httpRange struct {
 	start, length, size int64
@@ -56,68 +60,90 @@ func getRequestedRange(hrange string, size int64) (*httpRange, *probe.Error) {
 	return r, nil
 }
 
+// parseRangePos - parses a byte position of a range spec, which has
+// digits only.
+func parseRangePos(s string) (int64, error) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, errMalformedRange
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// parse a single byte range spec as per RFC 7233, one of
+// "start-end", "start-" or "-suffixLength". Returns errMalformedRange
+// for invalid specs and InvalidRange for unsatisfiable ones.
 func (r *httpRange) parse(ra string) *probe.Error {
 	i := strings.Index(ra, "-")
 	if i < 0 {
-		return probe.NewError(InvalidRange{})
+		return probe.NewError(errMalformedRange)
 	}
 	start, end := strings.TrimSpace(ra[:i]), strings.TrimSpace(ra[i+1:])
 	if start == "" {
 		// If no start is specified, end specifies the
 		// range start relative to the end of the file.
-		i, err := strconv.ParseInt(end, 10, 64)
+		i, err := parseRangePos(end)
 		if err != nil {
+			return probe.NewError(errMalformedRange)
+		}
+		// Zero length suffix and suffix of an empty file cannot be
+		// satisfied.
+		if i == 0 || r.size == 0 {
 			return probe.NewError(InvalidRange{})
 		}
+		// Suffix longer than the file selects the whole file.
 		if i > r.size {
 			i = r.size
 		}
 		r.start = r.size - i
-		r.length = r.size - r.start
+		r.length = i
 	} else {
-		i, err := strconv.ParseInt(start, 10, 64)
-		if err != nil || i > r.size || i < 0 {
-			return probe.NewError(InvalidRange{})
+		i, err := parseRangePos(start)
+		if err != nil {
+			return probe.NewError(errMalformedRange)
+		}
+		last := int64(-1)
+		if end != "" {
+			// End before start is invalid.
+			if last, err = parseRangePos(end); err != nil || last < i {
+				return probe.NewError(errMalformedRange)
+			}
+		}
+		// Range starting at or past the end of file cannot be satisfied.
+		if i >= r.size {
+			return probe.NewError(InvalidRange{Start: i, Length: r.size})
 		}
 		r.start = i
-		if end == "" {
+		if last < 0 {
 			// If no end is specified, range extends to end of the file.
 			r.length = r.size - r.start
 		} else {
-			i, err := strconv.ParseInt(end, 10, 64)
-			if err != nil || r.start > i {
-				return probe.NewError(InvalidRange{})
+			// End past the end of file is clamped to the last byte.
+			if last >= r.size {
+				last = r.size - 1
 			}
-			if i >= r.size {
-				i = r.size - 1
-			}
-			r.length = i - r.start + 1
+			r.length = last - r.start + 1
 		}
 	}
 	return nil
 }
 
-// parseRange parses a Range header string as per RFC 2616.
+// parseRange parses a Range header string as per RFC 7233. Other units
+// than bytes and multiple ranges are not supported and reported as
+// errMalformedRange like invalid ranges.
 func (r *httpRange) parseRange(s string) *probe.Error {
-	if s == "" {
-		return probe.NewError(errors.New("header not present"))
-	}
 	if !strings.HasPrefix(s, b) {
-		return probe.NewError(InvalidRange{})
+		return probe.NewError(errMalformedRange)
 	}
 
+	// We only support one range per object.
 	ras := strings.Split(s[len(b):], ",")
-	if len(ras) == 0 {
-		return probe.NewError(errors.New("invalid request"))
-	}
-	// Just pick the first one and ignore the rest, we only support one range per object
 	if len(ras) > 1 {
-		return probe.NewError(errors.New("multiple ranges specified"))
+		return probe.NewError(errMalformedRange)
 	}
 
 	ra := strings.TrimSpace(ras[0])
 	if ra == "" {
-		return probe.NewError(InvalidRange{})
+		return probe.NewError(errMalformedRange)
 	}
 	return r.parse(ra)
 }
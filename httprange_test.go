@@ -0,0 +1,92 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "testing"
+
+// Testing getRequestedRange().
+func TestGetRequestedRange(t *testing.T) {
+	testCases := []struct {
+		hrange     string
+		size       int64
+		start      int64
+		length     int64
+		shouldPass bool
+		malformed  bool
+	}{
+		// No range requested.
+		{"", 10, 0, 0, true, false},
+		// Fully specified ranges.
+		{"bytes=0-0", 10, 0, 1, true, false},
+		{"bytes=2-5", 10, 2, 4, true, false},
+		{"bytes=0-9", 10, 0, 10, true, false},
+		// End past the end of file is clamped.
+		{"bytes=5-100", 10, 5, 5, true, false},
+		// Open ended ranges.
+		{"bytes=0-", 10, 0, 10, true, false},
+		{"bytes=9-", 10, 9, 1, true, false},
+		// Suffix ranges.
+		{"bytes=-1", 10, 9, 1, true, false},
+		{"bytes=-4", 10, 6, 4, true, false},
+		// Suffix longer than the file selects the whole file.
+		{"bytes=-100", 10, 0, 10, true, false},
+		// Whitespace is allowed around range specs.
+		{"bytes= 2 - 5 ", 10, 2, 4, true, false},
+		// Start at or past the end of file.
+		{"bytes=10-", 10, 0, 0, false, false},
+		{"bytes=10-20", 10, 0, 0, false, false},
+		// Zero length suffix.
+		{"bytes=-0", 10, 0, 0, false, false},
+		// Ranges of an empty file.
+		{"bytes=0-", 0, 0, 0, false, false},
+		{"bytes=-1", 0, 0, 0, false, false},
+		// End before start.
+		{"bytes=5-2", 10, 0, 0, false, true},
+		// Malformed ranges.
+		{"bytes=", 10, 0, 0, false, true},
+		{"bytes=5", 10, 0, 0, false, true},
+		{"bytes=a-b", 10, 0, 0, false, true},
+		{"bytes=--5", 10, 0, 0, false, true},
+		{"bytes=-5-", 10, 0, 0, false, true},
+		{"bytes=5--3", 10, 0, 0, false, true},
+		{"bytes=+5-", 10, 0, 0, false, true},
+		{"items=0-5", 10, 0, 0, false, true},
+		// Multiple ranges are not supported.
+		{"bytes=0-1,5-6", 10, 0, 0, false, true},
+	}
+	for i, testCase := range testCases {
+		hrange, err := getRequestedRange(testCase.hrange, testCase.size)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected \"%s\" to pass, but failed with \"%s\"", i+1, testCase.hrange, err.Cause.Error())
+			continue
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected \"%s\" to fail, but passed with %s", i+1, testCase.hrange, hrange)
+			continue
+		}
+		if err != nil {
+			if malformed := err.ToGoError() == errMalformedRange; malformed != testCase.malformed {
+				t.Errorf("Test %d: Expected \"%s\" malformed to be %v, but failed with \"%s\"", i+1, testCase.hrange, testCase.malformed, err.Cause.Error())
+			}
+			continue
+		}
+		if hrange.start != testCase.start || hrange.length != testCase.length {
+			t.Errorf("Test %d: Expected start %d length %d, but instead found start %d length %d", i+1,
+				testCase.start, testCase.length, hrange.start, hrange.length)
+		}
+	}
+}
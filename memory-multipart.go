@@ -0,0 +1,418 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio/pkg/mimedb"
+	"github.com/minio/minio/pkg/probe"
+	"github.com/skyrings/skyring-common/tools/uuid"
+)
+
+// memUpload - multipart upload in progress.
+type memUpload struct {
+	object       string
+	storageClass string
+	headers      contentHeaders
+	userDefined  map[string]string
+	initiated    time.Time
+	parts        map[int]memPart
+}
+
+// memPart - uploaded part of a multipart upload.
+type memPart struct {
+	info partInfo
+	data []byte
+}
+
+// getUpload - returns an upload of an object, caller must hold the lock.
+func (m Memory) getUpload(bucket, object, uploadID string) (*memUpload, error) {
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return nil, e
+	}
+	if !IsValidObjectName(object) {
+		return nil, ObjectNameInvalid{Object: object}
+	}
+	upload, ok := b.uploads[uploadID]
+	if !ok || upload.object != object {
+		return nil, InvalidUploadID{UploadID: uploadID}
+	}
+	return upload, nil
+}
+
+// NewMultipartUpload - initiate a new multipart session
+func (m Memory) NewMultipartUpload(bucket, object string) (string, *probe.Error) {
+	return m.NewMultipartUploadWithMetadata(bucket, object, nil)
+}
+
+// NewMultipartUploadWithMetadata - initiate a new multipart session
+// with metadata of the object, supports "storageClass", the content
+// headers and user defined metadata.
+func (m Memory) NewMultipartUploadWithMetadata(bucket, object string, metadata map[string]string) (string, *probe.Error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object)
+	}
+	if !IsValidObjectName(object) {
+		return "", probe.NewError(ObjectNameInvalid{Object: object}).Trace(bucket, object)
+	}
+	storageClass, e := checkStorageClass(metadata["storageClass"])
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object)
+	}
+	headers, e := parseContentHeaders(metadata)
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object)
+	}
+	userDefined, e := parseUserMetadata(metadata)
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object)
+	}
+	for i := 0; i < maxUploadIDAttempts; i++ {
+		uploadID, e := newUUID()
+		if e != nil {
+			return "", probe.NewError(e).Trace(bucket, object)
+		}
+		if _, ok := b.uploads[uploadID]; ok {
+			continue
+		}
+		b.uploads[uploadID] = &memUpload{
+			object:       object,
+			storageClass: storageClass,
+			headers:      headers,
+			userDefined:  userDefined,
+			initiated:    time.Now().UTC(),
+			parts:        make(map[int]memPart),
+		}
+		return uploadID, nil
+	}
+	return "", probe.NewError(errUploadIDAttemptsExhausted).Trace(bucket, object)
+}
+
+// PutObjectPart - create a part in a multipart session
+func (m Memory) PutObjectPart(bucket, object, uploadID string, partNumber int, size int64, data io.Reader, md5Hex string) (string, *probe.Error) {
+	return m.PutObjectPartWithMetadata(bucket, object, uploadID, partNumber, size, data, map[string]string{"md5Sum": md5Hex})
+}
+
+// PutObjectPartWithMetadata - create a part in a multipart session
+// verified against the checksums declared in metadata, the hex encoded
+// "md5Sum", "crc32c" and "sha256".
+func (m Memory) PutObjectPartWithMetadata(bucket, object, uploadID string, partNumber int, size int64, data io.Reader, metadata map[string]string) (string, *probe.Error) {
+	m.mutex.RLock()
+	_, e := m.getUpload(bucket, object, uploadID)
+	m.mutex.RUnlock()
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+
+	// Part id must be within 1 and maxPartID inclusive.
+	if partNumber <= 0 || partNumber > maxPartID {
+		return "", probe.NewError(InvalidPartNumber{PartNumber: partNumber}).Trace(bucket, object, uploadID)
+	}
+
+	// Read the data before taking the lock.
+	buf := &bytes.Buffer{}
+	if size > 0 {
+		if _, e = io.CopyN(buf, data, size); e != nil {
+			if e == io.EOF {
+				return "", probe.NewError(IncompleteBody{Bucket: bucket, Object: object}).Trace(bucket, object, uploadID)
+			}
+			return "", probe.NewError(e).Trace(bucket, object, uploadID)
+		}
+	} else if _, e = io.Copy(buf, data); e != nil {
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	verifier, e := newDataVerifier(transferChecks(metadata))
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	verifier.Write(buf.Bytes())
+	if e = verifier.verify(); e != nil {
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	newMD5Hex := verifier.md5Hex()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	// The upload may have been completed or aborted meanwhile.
+	upload, e := m.getUpload(bucket, object, uploadID)
+	if e != nil {
+		return "", probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	upload.parts[partNumber] = memPart{
+		info: partInfo{
+			PartNumber:   partNumber,
+			LastModified: time.Now().UTC().Truncate(time.Second),
+			ETag:         newMD5Hex,
+			Size:         int64(buf.Len()),
+		},
+		data: buf.Bytes(),
+	}
+	return newMD5Hex, nil
+}
+
+// AbortMultipartUpload - abort an incomplete multipart session
+func (m Memory) AbortMultipartUpload(bucket, object, uploadID string) *probe.Error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, e := m.getUpload(bucket, object, uploadID); e != nil {
+		return probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	delete(m.buckets[bucket].uploads, uploadID)
+	return nil
+}
+
+// CompleteMultipartUpload - concatenate the parts of a multipart
+// session into an object.
+func (m Memory) CompleteMultipartUpload(bucket, object, uploadID string, parts []completePart) (ObjectInfo, *probe.Error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	if !isPartOrderValid(parts) {
+		return ObjectInfo{}, probe.NewError(InvalidPartOrder{UploadID: uploadID}).Trace(bucket, object, uploadID)
+	}
+	upload, e := m.getUpload(bucket, object, uploadID)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+
+	// Verify object name does not collide with existing prefixes.
+	if e = b.checkObjectCollision(bucket, object); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+
+	var md5Sums []string
+	buf := &bytes.Buffer{}
+	for i, part := range parts {
+		md5sum := strings.Trim(part.ETag, "\"")
+		uploaded, ok := upload.parts[part.PartNumber]
+		if !ok {
+			return ObjectInfo{}, probe.NewError(InvalidPart{PartNumber: part.PartNumber, ETag: md5sum}).Trace(bucket, object, uploadID)
+		}
+		if uploaded.info.ETag != md5sum {
+			return ObjectInfo{}, probe.NewError(InvalidPart{PartNumber: part.PartNumber, ETag: md5sum, Uploaded: true, UploadedETag: uploaded.info.ETag}).Trace(bucket, object, uploadID)
+		}
+		// Only the last part may be empty.
+		if uploaded.info.Size == 0 && i < len(parts)-1 {
+			return ObjectInfo{}, probe.NewError(EntityTooSmall{PartNumber: part.PartNumber}).Trace(bucket, object, uploadID)
+		}
+		buf.Write(uploaded.data)
+		md5Sums = append(md5Sums, md5sum)
+	}
+
+	s3MD5, err := makeS3MD5(md5Sums...)
+	if err != nil {
+		return ObjectInfo{}, err.Trace(md5Sums...)
+	}
+
+	contentType := "application/octet-stream"
+	if objectExt := path.Ext(object); objectExt != "" {
+		if content, ok := mimedb.DB[strings.ToLower(strings.TrimPrefix(objectExt, "."))]; ok {
+			contentType = content.ContentType
+		}
+	}
+	storageClass, e := checkStorageClass(upload.storageClass)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+	objInfo := ObjectInfo{
+		Bucket:         bucket,
+		Name:           object,
+		ModifiedTime:   time.Now().UTC().Truncate(time.Second),
+		Size:           int64(buf.Len()),
+		MD5Sum:         s3MD5,
+		ContentType:    contentType,
+		Owner:          getOwner(),
+		StorageClass:   storageClass,
+		contentHeaders: upload.headers,
+		UserDefined:    upload.userDefined,
+	}
+	b.objects[object] = memObject{info: objInfo, data: buf.Bytes()}
+	delete(b.uploads, uploadID)
+	return objInfo, nil
+}
+
+// ListMultipartUploads - list incomplete multipart sessions for a given bucket
+func (m Memory) ListMultipartUploads(bucket, objectPrefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, *probe.Error) {
+	result := ListMultipartsInfo{}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return result, probe.NewError(e).Trace(bucket, objectPrefix)
+	}
+
+	if !IsValidObjectPrefix(objectPrefix) {
+		return result, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: objectPrefix}).Trace(bucket, objectPrefix)
+	}
+
+	// Verify if delimiter is anything other than '/', which we do not support.
+	if delimiter != "" && delimiter != "/" {
+		return result, probe.NewError(fmt.Errorf("delimiter '%s' is not supported", delimiter)).Trace(bucket, objectPrefix)
+	}
+
+	if keyMarker != "" && !strings.HasPrefix(keyMarker, objectPrefix) {
+		return result, probe.NewError(fmt.Errorf("Invalid combination of marker '%s' and prefix '%s'", keyMarker, objectPrefix)).Trace(bucket, objectPrefix)
+	}
+
+	if uploadIDMarker != "" {
+		if strings.HasSuffix(keyMarker, "/") {
+			return result, probe.NewError(fmt.Errorf("Invalid combination of uploadID marker '%s' and marker '%s'", uploadIDMarker, keyMarker)).Trace(bucket, objectPrefix)
+		}
+		id, e := uuid.Parse(uploadIDMarker)
+		if e != nil {
+			return result, probe.NewError(e).Trace(bucket, objectPrefix)
+		}
+		if id.IsZero() {
+			return result, probe.NewError(fmt.Errorf("Invalid upload ID marker %s", uploadIDMarker)).Trace(bucket, objectPrefix)
+		}
+	}
+
+	// Return empty response if maxUploads is zero
+	if maxUploads == 0 {
+		return result, nil
+	}
+
+	// set listObjectsLimit to maxUploads for out-of-range limit
+	if maxUploads < 0 || maxUploads > listObjectsLimit {
+		maxUploads = listObjectsLimit
+	}
+
+	// Uploads sort by object name and then by upload id.
+	var uploads []uploadMetadata
+	for uploadID, upload := range b.uploads {
+		if !strings.HasPrefix(upload.object, objectPrefix) {
+			continue
+		}
+		if upload.object < keyMarker || (upload.object == keyMarker && uploadID <= uploadIDMarker) {
+			continue
+		}
+		uploads = append(uploads, uploadMetadata{
+			Object:    upload.object,
+			UploadID:  uploadID,
+			Initiated: upload.initiated,
+		})
+	}
+	sort.Sort(byObjectUploadID(uploads))
+
+	commonPrefixes := make(map[string]struct{})
+	for _, upload := range uploads {
+		name := upload.Object
+		isPrefix := false
+		if delimiter == "/" {
+			if i := strings.Index(name[len(objectPrefix):], delimiter); i >= 0 {
+				name = name[:len(objectPrefix)+i+1]
+				isPrefix = true
+			}
+		}
+		if isPrefix {
+			if _, ok := commonPrefixes[name]; ok || name == keyMarker {
+				continue
+			}
+		}
+		if len(result.Uploads)+len(result.CommonPrefixes) == maxUploads {
+			result.IsTruncated = true
+			break
+		}
+		if isPrefix {
+			commonPrefixes[name] = struct{}{}
+			result.CommonPrefixes = append(result.CommonPrefixes, name)
+			result.NextUploadIDMarker = ""
+		} else {
+			result.Uploads = append(result.Uploads, upload)
+			result.NextUploadIDMarker = upload.UploadID
+		}
+		result.NextKeyMarker = name
+	}
+	if !result.IsTruncated {
+		result.NextKeyMarker = ""
+		result.NextUploadIDMarker = ""
+	}
+	return result, nil
+}
+
+// byObjectUploadID is a collection satisfying sort.Interface.
+type byObjectUploadID []uploadMetadata
+
+func (u byObjectUploadID) Len() int      { return len(u) }
+func (u byObjectUploadID) Swap(i, j int) { u[i], u[j] = u[j], u[i] }
+func (u byObjectUploadID) Less(i, j int) bool {
+	if u[i].Object != u[j].Object {
+		return u[i].Object < u[j].Object
+	}
+	return u[i].UploadID < u[j].UploadID
+}
+
+// ListObjectParts - list parts from incomplete multipart session
+func (m Memory) ListObjectParts(bucket, object, uploadID string, partNumberMarker, maxParts int) (ListPartsInfo, *probe.Error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	upload, e := m.getUpload(bucket, object, uploadID)
+	if e != nil {
+		return ListPartsInfo{}, probe.NewError(e).Trace(bucket, object, uploadID)
+	}
+
+	// Parts are listed after the marker, which is a part number.
+	if partNumberMarker < 0 || partNumberMarker > maxPartID {
+		return ListPartsInfo{}, probe.NewError(InvalidPartNumberMarker{PartNumberMarker: partNumberMarker}).Trace(bucket, object, uploadID)
+	}
+
+	if maxParts <= 0 || maxParts > 1000 {
+		maxParts = 1000
+	}
+
+	parts := []partInfo{}
+	for partNumber, part := range upload.parts {
+		if partNumber > partNumberMarker {
+			parts = append(parts, part.info)
+		}
+	}
+	sort.Sort(byPartNumber(parts))
+
+	isTruncated := false
+	nextPartNumberMarker := 0
+	if len(parts) > maxParts {
+		parts = parts[:maxParts]
+		isTruncated = true
+		nextPartNumberMarker = parts[len(parts)-1].PartNumber
+	}
+
+	return ListPartsInfo{
+		Bucket:               bucket,
+		Object:               object,
+		UploadID:             uploadID,
+		PartNumberMarker:     partNumberMarker,
+		NextPartNumberMarker: nextPartNumberMarker,
+		MaxParts:             maxParts,
+		IsTruncated:          isTruncated,
+		Parts:                parts,
+	}, nil
+}
@@ -0,0 +1,396 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// Memory - object layer keeping buckets and objects in memory with
+// the semantics of Filesystem, without touching the disk.
+type Memory struct {
+	mutex   *sync.RWMutex
+	buckets map[string]*memBucket
+}
+
+// memBucket - bucket with its objects and multipart uploads.
+type memBucket struct {
+	created time.Time
+	objects map[string]memObject
+	uploads map[string]*memUpload
+}
+
+// memObject - object data along with its info.
+type memObject struct {
+	info ObjectInfo
+	data []byte
+}
+
+// newMemory instantiate a new in memory object layer.
+func newMemory() ObjectLayer {
+	return Memory{
+		mutex:   &sync.RWMutex{},
+		buckets: make(map[string]*memBucket),
+	}
+}
+
+// getBucket - returns a bucket, caller must hold the lock.
+func (m Memory) getBucket(bucket string) (*memBucket, error) {
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	b, ok := m.buckets[bucket]
+	if !ok {
+		return nil, BucketNotFound{Bucket: bucket}
+	}
+	return b, nil
+}
+
+/// Bucket Operations
+
+// MakeBucket - PUT Bucket
+func (m Memory) MakeBucket(bucket string) *probe.Error {
+	if !IsValidBucketName(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket)
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.buckets[bucket]; ok {
+		return probe.NewError(BucketExists{Bucket: bucket}).Trace(bucket)
+	}
+	m.buckets[bucket] = &memBucket{
+		created: time.Now().UTC(),
+		objects: make(map[string]memObject),
+		uploads: make(map[string]*memUpload),
+	}
+	return nil
+}
+
+// DeleteBucket - delete a bucket, which must not have any objects.
+func (m Memory) DeleteBucket(bucket string) *probe.Error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return probe.NewError(e).Trace(bucket)
+	}
+	if len(b.objects) != 0 {
+		return probe.NewError(BucketNotEmpty{Bucket: bucket}).Trace(bucket)
+	}
+	delete(m.buckets, bucket)
+	return nil
+}
+
+// ListBuckets - lists all buckets sorted by name.
+func (m Memory) ListBuckets() ([]BucketInfo, *probe.Error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	var names []string
+	for name := range m.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buckets []BucketInfo
+	owner := getOwner()
+	for _, name := range names {
+		buckets = append(buckets, BucketInfo{Name: name, Created: m.buckets[name].created, Owner: owner})
+	}
+	return buckets, nil
+}
+
+// GetBucketInfo - get bucket metadata.
+func (m Memory) GetBucketInfo(bucket string) (BucketInfo, *probe.Error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return BucketInfo{}, probe.NewError(e).Trace(bucket)
+	}
+	return BucketInfo{Name: bucket, Created: b.created, Owner: getOwner()}, nil
+}
+
+// ListObjects - lists all objects for a given prefix in the order of
+// Filesystem, returns up to maxKeys number of objects per call.
+func (m Memory) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, *probe.Error) {
+	result := ListObjectsInfo{}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return result, probe.NewError(e).Trace(bucket, prefix)
+	}
+	if !IsValidObjectPrefix(prefix) {
+		return result, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: prefix}).Trace(bucket, prefix)
+	}
+
+	// Verify if delimiter is anything other than '/', which we do not support.
+	if delimiter != "" && delimiter != "/" {
+		return result, probe.NewError(fmt.Errorf("delimiter '%s' is not supported. Only '/' is supported", delimiter)).Trace(bucket, prefix)
+	}
+
+	// Verify if marker has prefix.
+	if marker != "" && !strings.HasPrefix(marker, prefix) {
+		return result, probe.NewError(fmt.Errorf("Invalid combination of marker '%s' and prefix '%s'", marker, prefix)).Trace(bucket, prefix)
+	}
+
+	// Return empty response for a valid request when maxKeys is 0.
+	if maxKeys == 0 {
+		return result, nil
+	}
+
+	// Over flowing maxkeys - reset to listObjectsLimit.
+	if maxKeys < 0 || maxKeys > listObjectsLimit {
+		maxKeys = listObjectsLimit
+	}
+
+	// Object names sort in the order of the tree walk.
+	var names []string
+	for name := range b.objects {
+		if strings.HasPrefix(name, prefix) && name > marker {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		entry := name
+		isPrefix := false
+		if delimiter == "/" {
+			// Collapse objects below the next delimiter into a common prefix.
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 && i < len(name[len(prefix):])-1 {
+				entry = name[:len(prefix)+i+1]
+				isPrefix = true
+			}
+		}
+		if isPrefix {
+			// The marker itself was already returned as a prefix.
+			if entry == marker {
+				continue
+			}
+			if n := len(result.Prefixes); n > 0 && result.Prefixes[n-1] == entry {
+				continue
+			}
+		}
+		if len(result.Objects)+len(result.Prefixes) == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		if isPrefix {
+			result.Prefixes = append(result.Prefixes, entry)
+		} else {
+			result.Objects = append(result.Objects, b.objects[name].info)
+		}
+		result.NextMarker = entry
+	}
+	if !result.IsTruncated {
+		result.NextMarker = ""
+	}
+	return result, nil
+}
+
+/// Object Operations
+
+// GetObject - GET object
+func (m Memory) GetObject(bucket, object string, startOffset int64) (io.ReadCloser, *probe.Error) {
+	readCloser, _, err := m.GetObjectReader(bucket, object, startOffset, 0)
+	if err != nil {
+		return nil, err.Trace(bucket, object)
+	}
+	return readCloser, nil
+}
+
+// GetObjectReader - returns a reader of length bytes of an object from
+// startOffset along with the number of bytes it returns, a length of 0
+// or less reads till the end of the object.
+func (m Memory) GetObjectReader(bucket, object string, startOffset, length int64) (io.ReadCloser, int64, *probe.Error) {
+	objInfo, data, err := m.getObject(bucket, object)
+	if err != nil {
+		return nil, 0, err.Trace(bucket, object)
+	}
+	if startOffset < 0 || startOffset > objInfo.Size {
+		return nil, 0, probe.NewError(InvalidRange{Start: startOffset, Length: length}).Trace(bucket, object)
+	}
+	data = data[startOffset:]
+	if length > 0 && length < int64(len(data)) {
+		data = data[:length]
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
+
+// GetObjectRequest - GET object following conditions and the range of
+// the request.
+func (m Memory) GetObjectRequest(bucket, object string, req GetObjectRequest) (GetObjectResponse, *probe.Error) {
+	return getObjectRequest(m, bucket, object, req)
+}
+
+// getObject - returns the info and data of an object.
+func (m Memory) getObject(bucket, object string) (ObjectInfo, []byte, *probe.Error) {
+	if !IsValidBucketName(bucket) {
+		return ObjectInfo{}, nil, probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket, object)
+	}
+	if !IsValidObjectName(object) {
+		return ObjectInfo{}, nil, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return ObjectInfo{}, nil, probe.NewError(e).Trace(bucket, object)
+	}
+	obj, ok := b.objects[object]
+	if !ok {
+		return ObjectInfo{}, nil, probe.NewError(ObjectNotFound{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+	return obj.info, obj.data, nil
+}
+
+// GetObjectInfo - get object info.
+func (m Memory) GetObjectInfo(bucket, object string) (ObjectInfo, *probe.Error) {
+	objInfo, _, err := m.getObject(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err.Trace(bucket, object)
+	}
+	return objInfo, nil
+}
+
+// checkObjectCollision - none of the parent prefixes of an object may be
+// an existing object and the object name must not be an existing
+// prefix, caller must hold the lock.
+func (b *memBucket) checkObjectCollision(bucket, object string) error {
+	elements := strings.Split(object, "/")
+	for i := 1; i < len(elements); i++ {
+		if _, ok := b.objects[strings.Join(elements[:i], "/")]; ok {
+			return ObjectExistsAsPrefix{Bucket: bucket, Prefix: object}
+		}
+	}
+	for name := range b.objects {
+		if strings.HasPrefix(name, object+"/") {
+			return PrefixExistsAsObject{Bucket: bucket, Object: object}
+		}
+	}
+	return nil
+}
+
+// PutObject - create an object.
+func (m Memory) PutObject(bucket string, object string, size int64, data io.Reader, metadata map[string]string) (ObjectInfo, *probe.Error) {
+	if !IsValidBucketName(bucket) {
+		return ObjectInfo{}, probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket, object)
+	}
+	if !IsValidObjectName(object) {
+		return ObjectInfo{}, probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+	storageClass, e := checkStorageClass(metadata["storageClass"])
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	headers, e := parseContentHeaders(metadata)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	userDefined, e := parseUserMetadata(metadata)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+
+	// Read the data before taking the lock.
+	buf := &bytes.Buffer{}
+	if size > 0 {
+		if _, e = io.CopyN(buf, data, size); e != nil {
+			if e == io.EOF {
+				return ObjectInfo{}, probe.NewError(IncompleteBody{Bucket: bucket, Object: object}).Trace(bucket, object)
+			}
+			return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+		}
+	} else if _, e = io.Copy(buf, data); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	verifier, e := newDataVerifier(transferChecks(metadata))
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	verifier.Write(buf.Bytes())
+	if e = verifier.verify(); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	newMD5Hex := verifier.md5Hex()
+
+	contentType := metadata["contentType"]
+	if contentType == "" {
+		contentType = extensionContentType(object)
+	}
+	if contentType == "" {
+		contentType = sniffContentType(buf.Bytes())
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	if e = b.checkObjectCollision(bucket, object); e != nil {
+		return ObjectInfo{}, probe.NewError(e).Trace(bucket, object)
+	}
+	objInfo := ObjectInfo{
+		Bucket:         bucket,
+		Name:           object,
+		ModifiedTime:   time.Now().UTC().Truncate(time.Second),
+		Size:           int64(buf.Len()),
+		MD5Sum:         newMD5Hex,
+		ContentType:    contentType,
+		Owner:          getOwner(),
+		StorageClass:   storageClass,
+		contentHeaders: headers,
+		UserDefined:    userDefined,
+	}
+	b.objects[object] = memObject{info: objInfo, data: buf.Bytes()}
+	return objInfo, nil
+}
+
+// DeleteObject - delete an object.
+func (m Memory) DeleteObject(bucket, object string) *probe.Error {
+	if !IsValidBucketName(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket}).Trace(bucket, object)
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	b, e := m.getBucket(bucket)
+	if e != nil {
+		return probe.NewError(e).Trace(bucket, object)
+	}
+	if !IsValidObjectName(object) {
+		return probe.NewError(ObjectNameInvalid{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+	if _, ok := b.objects[object]; !ok {
+		return probe.NewError(ObjectNotFound{Bucket: bucket, Object: object}).Trace(bucket, object)
+	}
+	delete(b.objects, object)
+	return nil
+}
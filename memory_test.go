@@ -0,0 +1,47 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// objectLayerTest - test function run against an object layer.
+type objectLayerTest func(t *testing.T, obj ObjectLayer)
+
+// execObjectLayerTest - runs a test against both the fs and the in
+// memory object layer, which are expected to behave the same.
+func execObjectLayerTest(t *testing.T, test objectLayerTest) {
+	directory, e := ioutil.TempDir("", "minio-object-layer-test")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(directory)
+
+	// Create the fs.
+	fs, err := newFS(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("Running against the fs object layer")
+	test(t, fs)
+
+	t.Log("Running against the in memory object layer")
+	test(t, newMemory())
+}
@@ -6,8 +6,8 @@ import (
 	"github.com/minio/minio/pkg/probe"
 )
 
-// ObjectAPI interface.
-type ObjectAPI interface {
+// ObjectLayer interface.
+type ObjectLayer interface {
 	// Bucket resource API.
 	DeleteBucket(bucket string) *probe.Error
 	ListBuckets() ([]BucketInfo, *probe.Error)
@@ -20,13 +20,17 @@ type ObjectAPI interface {
 
 	// Object resource API.
 	GetObject(bucket, object string, startOffset int64) (io.ReadCloser, *probe.Error)
+	GetObjectReader(bucket, object string, startOffset, length int64) (io.ReadCloser, int64, *probe.Error)
 	GetObjectInfo(bucket, object string) (ObjectInfo, *probe.Error)
+	GetObjectRequest(bucket, object string, req GetObjectRequest) (GetObjectResponse, *probe.Error)
 	PutObject(bucket string, object string, size int64, data io.Reader, metadata map[string]string) (ObjectInfo, *probe.Error)
 	DeleteObject(bucket, object string) *probe.Error
 
 	// Object query API.
 	NewMultipartUpload(bucket, object string) (string, *probe.Error)
+	NewMultipartUploadWithMetadata(bucket, object string, metadata map[string]string) (string, *probe.Error)
 	PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, *probe.Error)
+	PutObjectPartWithMetadata(bucket, object, uploadID string, partID int, size int64, data io.Reader, metadata map[string]string) (string, *probe.Error)
 	ListObjectParts(bucket, object, uploadID string, partNumberMarker, maxParts int) (ListPartsInfo, *probe.Error)
 	CompleteMultipartUpload(bucket string, object string, uploadID string, parts []completePart) (ObjectInfo, *probe.Error)
 	AbortMultipartUpload(bucket, object, uploadID string) *probe.Error
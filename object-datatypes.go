@@ -22,6 +22,18 @@ import "time"
 type BucketInfo struct {
 	Name    string
 	Created time.Time
+	Owner   string
+}
+
+// contentHeaders - headers of an object set by the client, returned as
+// is on GET and HEAD.
+type contentHeaders struct {
+	// Content encoding such as "gzip", the data is not decoded.
+	ContentEncoding    string `json:"contentEncoding,omitempty"`
+	CacheControl       string `json:"cacheControl,omitempty"`
+	ContentDisposition string `json:"contentDisposition,omitempty"`
+	ContentLanguage    string `json:"contentLanguage,omitempty"`
+	Expires            string `json:"expires,omitempty"`
 }
 
 // ObjectInfo - object info.
@@ -33,7 +45,12 @@ type ObjectInfo struct {
 	MD5Sum       string
 	Size         int64
 	IsDir        bool
-	Err          error
+	Owner        string
+	StorageClass string
+	contentHeaders
+	// User defined metadata by lowercased "x-amz-meta-" header name.
+	UserDefined map[string]string
+	Err         error
 }
 
 // ListPartsInfo - various types of object resources.
@@ -71,7 +88,28 @@ type ListObjectsInfo struct {
 	IsTruncated bool
 	NextMarker  string
 	Objects     []ObjectInfo
-	Prefixes    []string
+	// Common prefixes of a delimited listing, collapsed directories
+	// are listed here and never among Objects.
+	Prefixes []string
+}
+
+// nullVersionID - version id of objects of unversioned buckets.
+const nullVersionID = "null"
+
+// ObjectVersionInfo - version of an object.
+type ObjectVersionInfo struct {
+	ObjectInfo
+	VersionID string
+	IsLatest  bool
+}
+
+// ListObjectVersionsInfo - container for list object versions.
+type ListObjectVersionsInfo struct {
+	IsTruncated         bool
+	NextKeyMarker       string
+	NextVersionIDMarker string
+	Versions            []ObjectVersionInfo
+	Prefixes            []string
 }
 
 // partInfo - various types of individual part resources.
@@ -80,6 +118,9 @@ type partInfo struct {
 	LastModified time.Time
 	ETag         string
 	Size         int64
+	// Part data does not match its ETag, only set by verified
+	// listings.
+	Corrupt bool
 }
 
 // uploadMetadata container capturing metadata on in progress multipart upload in a given bucket
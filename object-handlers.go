@@ -87,8 +87,11 @@ func (api objectStorageAPI) GetObjectHandler(w http.ResponseWriter, r *http.Requ
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:GetObject", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		// Policies may compare tags of the object.
+		conditions := bucketPolicyConditions(r)
+		addObjectTagConditions(conditions, api.ObjectAPI, bucket, object)
+		if !bucketPolicyAllowsConditions(bucket, "s3:GetObject", AWSResourcePrefix+bucket+"/"+object, conditions) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
 	case authTypePresigned, authTypeSigned:
@@ -98,7 +101,8 @@ func (api objectStorageAPI) GetObjectHandler(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
-	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	// Verify conditions and the requested range.
+	resp, err := api.ObjectAPI.GetObjectRequest(bucket, object, newGetObjectRequest(r))
 	if err != nil {
 		switch err.ToGoError().(type) {
 		case BucketNameInvalid:
@@ -110,148 +114,59 @@ func (api objectStorageAPI) GetObjectHandler(w http.ResponseWriter, r *http.Requ
 		case ObjectNameInvalid:
 			writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
 		default:
-			errorIf(err.Trace(), "GetObjectInfo failed.", nil)
+			errorIf(err.Trace(), "GetObjectRequest failed.", nil)
 			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		}
 		return
 	}
-
-	// Verify 'If-Modified-Since' and 'If-Unmodified-Since'.
-	lastModified := objInfo.ModifiedTime
-	if checkLastModified(w, r, lastModified) {
-		return
-	}
-	// Verify 'If-Match' and 'If-None-Match'.
-	if checkETag(w, r) {
+	switch resp.StatusCode {
+	case http.StatusNotModified, http.StatusPreconditionFailed:
+		w.WriteHeader(resp.StatusCode)
 		return
-	}
-
-	var hrange *httpRange
-	hrange, err = getRequestedRange(r.Header.Get("Range"), objInfo.Size)
-	if err != nil {
+	case http.StatusRequestedRangeNotSatisfiable:
+		w.Header().Set("Content-Range", resp.ContentRange)
 		writeErrorResponse(w, r, ErrInvalidRange, r.URL.Path)
 		return
 	}
-
-	// Get the object.
-	startOffset := hrange.start
-	readCloser, err := api.ObjectAPI.GetObject(bucket, object, startOffset)
-	if err != nil {
-		errorIf(err.Trace(), "GetObject failed.", nil)
-		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
-		return
-	}
-	defer readCloser.Close() // Close after this handler returns.
+	defer resp.Reader.Close() // Close after this handler returns.
 
 	// Set standard object headers.
-	setObjectHeaders(w, objInfo, hrange)
+	setObjectHeaders(w, resp.ObjectInfo, nil)
+	w.Header().Set("Content-Length", strconv.FormatInt(resp.ContentLength, 10))
+	if resp.ContentRange != "" {
+		w.Header().Set("Content-Range", resp.ContentRange)
+	}
 
 	// Set any additional requested response headers.
 	setGetRespHeaders(w, r.URL.Query())
+	w.WriteHeader(resp.StatusCode)
 
-	if hrange.length > 0 {
-		if _, e := io.CopyN(w, readCloser, hrange.length); e != nil {
-			errorIf(probe.NewError(e), "Writing to client failed", nil)
-			// Do not send error response here, since client could have died.
-			return
-		}
-	} else {
-		if _, e := io.Copy(w, readCloser); e != nil {
-			errorIf(probe.NewError(e), "Writing to client failed", nil)
-			// Do not send error response here, since client could have died.
-			return
-		}
+	if _, e := io.CopyN(w, resp.Reader, resp.ContentLength); e != nil {
+		errorIf(probe.NewError(e), "Writing to client failed", nil)
+		// Do not send error response here, since client could have died.
+		return
 	}
 }
 
 var unixEpochTime = time.Unix(0, 0)
 
-// checkLastModified implements If-Modified-Since and
-// If-Unmodified-Since checks.
-//
-// modtime is the modification time of the resource to be served, or
-// IsZero(). return value is whether this request is now complete.
-func checkLastModified(w http.ResponseWriter, r *http.Request, modtime time.Time) bool {
-	if modtime.IsZero() || modtime.Equal(unixEpochTime) {
-		// If the object doesn't have a modtime (IsZero), or the modtime
-		// is obviously garbage (Unix time == 0), then ignore modtimes
-		// and don't process the If-Modified-Since header.
-		return false
-	}
-
-	// The Date-Modified header truncates sub-second precision, so
-	// use mtime < t+1s instead of mtime <= t to check for unmodified.
-	if _, ok := r.Header["If-Modified-Since"]; ok {
-		// Return the object only if it has been modified since the
-		// specified time, otherwise return a 304 (not modified).
-		t, err := time.Parse(http.TimeFormat, r.Header.Get("If-Modified-Since"))
-		if err == nil && modtime.Before(t.Add(1*time.Second)) {
-			h := w.Header()
-			// Remove following headers if already set.
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusNotModified)
-			return true
-		}
-	} else if _, ok := r.Header["If-Unmodified-Since"]; ok {
-		// Return the object only if it has not been modified since
-		// the specified time, otherwise return a 412 (precondition failed).
-		t, err := time.Parse(http.TimeFormat, r.Header.Get("If-Unmodified-Since"))
-		if err == nil && modtime.After(t.Add(1*time.Second)) {
-			h := w.Header()
-			// Remove following headers if already set.
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusPreconditionFailed)
-			return true
-		}
-	}
-	w.Header().Set("Last-Modified", modtime.UTC().Format(http.TimeFormat))
-	return false
-}
-
-// checkETag implements If-None-Match and If-Match checks.
-//
-// The ETag must have been previously set in the ResponseWriter's
-// headers. The return value is whether this request is now considered
-// done.
-func checkETag(w http.ResponseWriter, r *http.Request) bool {
-	etag := w.Header().Get("ETag")
-	// Must know ETag.
-	if etag == "" {
-		return false
-	}
-	if inm := r.Header.Get("If-None-Match"); inm != "" {
-		// Return the object only if its entity tag (ETag) is
-		// different from the one specified; otherwise, return a 304
-		// (not modified).
-		if r.Method != "GET" && r.Method != "HEAD" {
-			return false
-		}
-		if inm == etag || inm == "*" {
-			h := w.Header()
-			// Remove following headers if already set.
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusNotModified)
+// etagMatches reports whether the comma separated list of entity
+// tags in header matches etag, "*" matches any entity tag. Weak entity
+// tags (prefixed with W/) only match with weak comparison.
+func etagMatches(header, etag string, weak bool) bool {
+	etag = strings.Trim(etag, "\"")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
 			return true
 		}
-	} else if im := r.Header.Get("If-Match"); im != "" {
-		// Return the object only if its entity tag (ETag) is the same
-		// as the one specified; otherwise, return a 412 (precondition failed).
-		if r.Method != "GET" && r.Method != "HEAD" {
-			return false
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
 		}
-		if im != etag {
-			h := w.Header()
-			// Remove following headers if already set.
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusPreconditionFailed)
+		if strings.Trim(tag, "\"") == etag {
 			return true
 		}
 	}
@@ -274,8 +189,11 @@ func (api objectStorageAPI) HeadObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:GetObject", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		// Policies may compare tags of the object.
+		conditions := bucketPolicyConditions(r)
+		addObjectTagConditions(conditions, api.ObjectAPI, bucket, object)
+		if !bucketPolicyAllowsConditions(bucket, "s3:GetObject", AWSResourcePrefix+bucket+"/"+object, conditions) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
 	case authTypePresigned, authTypeSigned:
@@ -303,14 +221,9 @@ func (api objectStorageAPI) HeadObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Verify 'If-Modified-Since' and 'If-Unmodified-Since'.
-	lastModified := objInfo.ModifiedTime
-	if checkLastModified(w, r, lastModified) {
-		return
-	}
-
-	// Verify 'If-Match' and 'If-None-Match'.
-	if checkETag(w, r) {
+	// Verify conditions like GET does.
+	if status := newGetObjectRequest(r).conditionStatus(objInfo); status != http.StatusOK {
+		w.WriteHeader(status)
 		return
 	}
 
@@ -337,8 +250,8 @@ func (api objectStorageAPI) CopyObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		if !bucketPolicyAllows(bucket, "s3:PutObject", AWSResourcePrefix+bucket+"/"+object, r) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
 	case authTypePresigned, authTypeSigned:
@@ -448,6 +361,15 @@ func (api objectStorageAPI) CopyObjectHandler(w http.ResponseWriter, r *http.Req
 	// Save metadata.
 	metadata := make(map[string]string)
 	metadata["md5Sum"] = hex.EncodeToString(md5Bytes)
+	metadata["storageClass"] = r.Header.Get("X-Amz-Storage-Class")
+	metadata["contentEncoding"] = objInfo.ContentEncoding
+	metadata["cacheControl"] = objInfo.CacheControl
+	metadata["contentDisposition"] = objInfo.ContentDisposition
+	metadata["contentLanguage"] = objInfo.ContentLanguage
+	metadata["expires"] = objInfo.Expires
+	for name, value := range objInfo.UserDefined {
+		metadata[name] = value
+	}
 
 	// Create the object.
 	objInfo, err = api.ObjectAPI.PutObject(bucket, object, size, readCloser, metadata)
@@ -456,6 +378,8 @@ func (api objectStorageAPI) CopyObjectHandler(w http.ResponseWriter, r *http.Req
 		switch err.ToGoError().(type) {
 		case RootPathFull:
 			writeErrorResponse(w, r, ErrRootPathFull, r.URL.Path)
+		case RootPathNotFound, RootPathNotDir:
+			writeErrorResponse(w, r, ErrRootPathNotFound, r.URL.Path)
 		case BucketNotFound:
 			writeErrorResponse(w, r, ErrNoSuchBucket, r.URL.Path)
 		case BucketNameInvalid:
@@ -466,6 +390,20 @@ func (api objectStorageAPI) CopyObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, r, ErrIncompleteBody, r.URL.Path)
 		case ObjectExistsAsPrefix:
 			writeErrorResponse(w, r, ErrObjectExistsAsPrefix, r.URL.Path)
+		case ObjectLocked:
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		case InvalidStorageClass:
+			writeErrorResponse(w, r, ErrInvalidStorageClass, r.URL.Path)
+		case InvalidHeaderValue:
+			writeErrorResponse(w, r, ErrInvalidHeaderValue, r.URL.Path)
+		case MetadataTooLarge:
+			writeErrorResponse(w, r, ErrMetadataTooLarge, r.URL.Path)
+		case EntityTooLarge:
+			writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		case QuotaExceeded:
+			writeErrorResponse(w, r, ErrQuotaExceeded, r.URL.Path)
+		case PrefixExistsAsObject:
+			writeErrorResponse(w, r, ErrPrefixExistsAsObject, r.URL.Path)
 		default:
 			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		}
@@ -573,6 +511,17 @@ func checkCopySourceETag(w http.ResponseWriter, r *http.Request) bool {
 
 }
 
+// precheckPut - verifies a put can succeed before reading the request
+// body, the server only answers "Expect: 100-continue" once the body is
+// read so rejected clients do not send it.
+func (api objectStorageAPI) precheckPut(bucket, object string, size int64) *probe.Error {
+	fs, ok := api.ObjectAPI.(*Filesystem)
+	if !ok {
+		return nil
+	}
+	return fs.PrecheckPut(bucket, object, size)
+}
+
 // PutObjectHandler - PUT Object
 // ----------
 // This implementation of the PUT operation adds an object to a bucket.
@@ -606,6 +555,17 @@ func (api objectStorageAPI) PutObjectHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// Save metadata, along with the checksums declared by the client.
+	metadata, err := checkValidChecksums(r.Header)
+	if err != nil {
+		errorIf(err.Trace(), "Decoding checksums failed.", nil)
+		writeErrorResponse(w, r, ErrInvalidDigest, r.URL.Path)
+		return
+	}
+	metadata["storageClass"] = r.Header.Get("X-Amz-Storage-Class")
+	setContentHeadersMetadata(metadata, r.Header)
+	setUserMetadata(metadata, r.Header)
+
 	var objInfo ObjectInfo
 	switch getRequestAuthType(r) {
 	default:
@@ -614,13 +574,19 @@ func (api objectStorageAPI) PutObjectHandler(w http.ResponseWriter, r *http.Requ
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		if !bucketPolicyAllows(bucket, "s3:PutObject", AWSResourcePrefix+bucket+"/"+object, r) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
+		if err = api.precheckPut(bucket, object, size); err != nil {
+			break
+		}
 		// Create anonymous object.
-		objInfo, err = api.ObjectAPI.PutObject(bucket, object, size, r.Body, nil)
+		objInfo, err = api.ObjectAPI.PutObject(bucket, object, size, r.Body, metadata)
 	case authTypePresigned, authTypeSigned:
+		if err = api.precheckPut(bucket, object, size); err != nil {
+			break
+		}
 		// Initialize a pipe for data pipe line.
 		reader, writer := io.Pipe()
 
@@ -653,8 +619,6 @@ func (api objectStorageAPI) PutObjectHandler(w http.ResponseWriter, r *http.Requ
 			writer.Close()
 		}()
 
-		// Save metadata.
-		metadata := make(map[string]string)
 		// Make sure we hex encode here.
 		metadata["md5"] = hex.EncodeToString(md5Bytes)
 		// Create object.
@@ -671,16 +635,34 @@ func (api objectStorageAPI) PutObjectHandler(w http.ResponseWriter, r *http.Requ
 		switch e.(type) {
 		case RootPathFull:
 			writeErrorResponse(w, r, ErrRootPathFull, r.URL.Path)
+		case RootPathNotFound, RootPathNotDir:
+			writeErrorResponse(w, r, ErrRootPathNotFound, r.URL.Path)
 		case BucketNotFound:
 			writeErrorResponse(w, r, ErrNoSuchBucket, r.URL.Path)
 		case BucketNameInvalid:
 			writeErrorResponse(w, r, ErrInvalidBucketName, r.URL.Path)
+		case ObjectNameInvalid:
+			writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
 		case BadDigest:
 			writeErrorResponse(w, r, ErrBadDigest, r.URL.Path)
 		case IncompleteBody:
 			writeErrorResponse(w, r, ErrIncompleteBody, r.URL.Path)
 		case ObjectExistsAsPrefix:
 			writeErrorResponse(w, r, ErrObjectExistsAsPrefix, r.URL.Path)
+		case ObjectLocked:
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		case InvalidStorageClass:
+			writeErrorResponse(w, r, ErrInvalidStorageClass, r.URL.Path)
+		case InvalidHeaderValue:
+			writeErrorResponse(w, r, ErrInvalidHeaderValue, r.URL.Path)
+		case MetadataTooLarge:
+			writeErrorResponse(w, r, ErrMetadataTooLarge, r.URL.Path)
+		case EntityTooLarge:
+			writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		case QuotaExceeded:
+			writeErrorResponse(w, r, ErrQuotaExceeded, r.URL.Path)
+		case PrefixExistsAsObject:
+			writeErrorResponse(w, r, ErrPrefixExistsAsObject, r.URL.Path)
 		default:
 			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		}
@@ -708,8 +690,8 @@ func (api objectStorageAPI) NewMultipartUploadHandler(w http.ResponseWriter, r *
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/mpuAndPermissions.html
-		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		if !bucketPolicyAllows(bucket, "s3:PutObject", AWSResourcePrefix+bucket+"/"+object, r) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
 	case authTypePresigned, authTypeSigned:
@@ -719,12 +701,20 @@ func (api objectStorageAPI) NewMultipartUploadHandler(w http.ResponseWriter, r *
 		}
 	}
 
-	uploadID, err := api.ObjectAPI.NewMultipartUpload(bucket, object)
+	// Save metadata.
+	metadata := make(map[string]string)
+	metadata["storageClass"] = r.Header.Get("X-Amz-Storage-Class")
+	setContentHeadersMetadata(metadata, r.Header)
+	setUserMetadata(metadata, r.Header)
+
+	uploadID, err := api.ObjectAPI.NewMultipartUploadWithMetadata(bucket, object, metadata)
 	if err != nil {
 		errorIf(err.Trace(), "NewMultipartUpload failed.", nil)
 		switch err.ToGoError().(type) {
 		case RootPathFull:
 			writeErrorResponse(w, r, ErrRootPathFull, r.URL.Path)
+		case RootPathNotFound, RootPathNotDir:
+			writeErrorResponse(w, r, ErrRootPathNotFound, r.URL.Path)
 		case BucketNameInvalid:
 			writeErrorResponse(w, r, ErrInvalidBucketName, r.URL.Path)
 		case BucketNotFound:
@@ -733,6 +723,12 @@ func (api objectStorageAPI) NewMultipartUploadHandler(w http.ResponseWriter, r *
 			writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
 		case ObjectNameInvalid:
 			writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
+		case InvalidStorageClass:
+			writeErrorResponse(w, r, ErrInvalidStorageClass, r.URL.Path)
+		case InvalidHeaderValue:
+			writeErrorResponse(w, r, ErrInvalidHeaderValue, r.URL.Path)
+		case MetadataTooLarge:
+			writeErrorResponse(w, r, ErrMetadataTooLarge, r.URL.Path)
 		default:
 			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		}
@@ -783,6 +779,15 @@ func (api objectStorageAPI) PutObjectPartHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	// Checksums of the part declared by the client.
+	metadata, err := checkValidChecksums(r.Header)
+	if err != nil {
+		errorIf(err.Trace(), "Decoding checksums failed.", nil)
+		writeErrorResponse(w, r, ErrInvalidDigest, r.URL.Path)
+		return
+	}
+	metadata["md5Sum"] = hex.EncodeToString(md5Bytes)
+
 	var partMD5 string
 	switch getRequestAuthType(r) {
 	default:
@@ -791,13 +796,13 @@ func (api objectStorageAPI) PutObjectPartHandler(w http.ResponseWriter, r *http.
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/mpuAndPermissions.html
-		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		if !bucketPolicyAllows(bucket, "s3:PutObject", AWSResourcePrefix+bucket+"/"+object, r) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
 		// No need to verify signature, anonymous request access is
 		// already allowed.
-		partMD5, err = api.ObjectAPI.PutObjectPart(bucket, object, uploadID, partID, size, r.Body, hex.EncodeToString(md5Bytes))
+		partMD5, err = api.ObjectAPI.PutObjectPartWithMetadata(bucket, object, uploadID, partID, size, r.Body, metadata)
 	case authTypePresigned, authTypeSigned:
 		validateRegion := true // Validate region.
 		// Initialize a pipe for data pipe line.
@@ -830,7 +835,7 @@ func (api objectStorageAPI) PutObjectPartHandler(w http.ResponseWriter, r *http.
 			// Close the writer.
 			writer.Close()
 		}()
-		partMD5, err = api.ObjectAPI.PutObjectPart(bucket, object, uploadID, partID, size, reader, hex.EncodeToString(md5Bytes))
+		partMD5, err = api.ObjectAPI.PutObjectPartWithMetadata(bucket, object, uploadID, partID, size, reader, metadata)
 	}
 	if err != nil {
 		errorIf(err.Trace(), "PutObjectPart failed.", nil)
@@ -843,12 +848,16 @@ func (api objectStorageAPI) PutObjectPartHandler(w http.ResponseWriter, r *http.
 		switch e.(type) {
 		case RootPathFull:
 			writeErrorResponse(w, r, ErrRootPathFull, r.URL.Path)
+		case RootPathNotFound, RootPathNotDir:
+			writeErrorResponse(w, r, ErrRootPathNotFound, r.URL.Path)
 		case InvalidUploadID:
 			writeErrorResponse(w, r, ErrNoSuchUpload, r.URL.Path)
 		case BadDigest:
 			writeErrorResponse(w, r, ErrBadDigest, r.URL.Path)
 		case IncompleteBody:
 			writeErrorResponse(w, r, ErrIncompleteBody, r.URL.Path)
+		case InvalidPartNumber:
+			writeErrorResponse(w, r, ErrInvalidPartNumber, r.URL.Path)
 		default:
 			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		}
@@ -873,8 +882,8 @@ func (api objectStorageAPI) AbortMultipartUploadHandler(w http.ResponseWriter, r
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/mpuAndPermissions.html
-		if s3Error := enforceBucketPolicy("s3:AbortMultipartUpload", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		if !bucketPolicyAllows(bucket, "s3:AbortMultipartUpload", AWSResourcePrefix+bucket+"/"+object, r) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
 	case authTypePresigned, authTypeSigned:
@@ -920,8 +929,8 @@ func (api objectStorageAPI) ListObjectPartsHandler(w http.ResponseWriter, r *htt
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/mpuAndPermissions.html
-		if s3Error := enforceBucketPolicy("s3:ListMultipartUploadParts", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		if !bucketPolicyAllows(bucket, "s3:ListMultipartUploadParts", AWSResourcePrefix+bucket+"/"+object, r) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
 	case authTypePresigned, authTypeSigned:
@@ -958,6 +967,8 @@ func (api objectStorageAPI) ListObjectPartsHandler(w http.ResponseWriter, r *htt
 			writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
 		case InvalidUploadID:
 			writeErrorResponse(w, r, ErrNoSuchUpload, r.URL.Path)
+		case InvalidPartNumberMarker:
+			writeErrorResponse(w, r, ErrInvalidPartNumberMarker, r.URL.Path)
 		default:
 			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		}
@@ -989,8 +1000,8 @@ func (api objectStorageAPI) CompleteMultipartUploadHandler(w http.ResponseWriter
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/mpuAndPermissions.html
-		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		if !bucketPolicyAllows(bucket, "s3:PutObject", AWSResourcePrefix+bucket+"/"+object, r) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
 	case authTypePresigned, authTypeSigned:
@@ -1036,8 +1047,22 @@ func (api objectStorageAPI) CompleteMultipartUploadHandler(w http.ResponseWriter
 			writeErrorResponse(w, r, ErrInvalidPart, r.URL.Path)
 		case InvalidPartOrder:
 			writeErrorResponse(w, r, ErrInvalidPartOrder, r.URL.Path)
+		case EntityTooSmall:
+			writeErrorResponse(w, r, ErrEntityTooSmall, r.URL.Path)
 		case IncompleteBody:
 			writeErrorResponse(w, r, ErrIncompleteBody, r.URL.Path)
+		case ObjectExistsAsPrefix:
+			writeErrorResponse(w, r, ErrObjectExistsAsPrefix, r.URL.Path)
+		case ObjectLocked:
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		case InvalidStorageClass:
+			writeErrorResponse(w, r, ErrInvalidStorageClass, r.URL.Path)
+		case EntityTooLarge:
+			writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		case QuotaExceeded:
+			writeErrorResponse(w, r, ErrQuotaExceeded, r.URL.Path)
+		case PrefixExistsAsObject:
+			writeErrorResponse(w, r, ErrPrefixExistsAsObject, r.URL.Path)
 		default:
 			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		}
@@ -1069,8 +1094,8 @@ func (api objectStorageAPI) DeleteObjectHandler(w http.ResponseWriter, r *http.R
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:DeleteObject", bucket, r.URL); s3Error != ErrNone {
-			writeErrorResponse(w, r, s3Error, r.URL.Path)
+		if !bucketPolicyAllows(bucket, "s3:DeleteObject", AWSResourcePrefix+bucket+"/"+object, r) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 			return
 		}
 	case authTypeSigned, authTypePresigned:
@@ -1091,6 +1116,8 @@ func (api objectStorageAPI) DeleteObjectHandler(w http.ResponseWriter, r *http.R
 			writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
 		case ObjectNameInvalid:
 			writeErrorResponse(w, r, ErrNoSuchKey, r.URL.Path)
+		case ObjectLocked:
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 		default:
 			writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
 		}
@@ -0,0 +1,89 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Tests validate evaluation of the conditional request headers.
+func TestConditionStatus(t *testing.T) {
+	modtime := time.Date(2016, time.April, 1, 10, 20, 30, 0, time.UTC)
+	before := modtime.Add(-time.Second).Format(http.TimeFormat)
+	equal := modtime.Format(http.TimeFormat)
+	after := modtime.Add(time.Second).Format(http.TimeFormat)
+	etag := "5eb63bbbe01eeed093cb22bb8f5acdc3"
+
+	testCases := []struct {
+		headers        map[string]string
+		modtime        time.Time
+		etag           string
+		expectedStatus int
+	}{
+		// If-Modified-Since.
+		{map[string]string{"If-Modified-Since": before}, modtime, etag, http.StatusOK},
+		{map[string]string{"If-Modified-Since": equal}, modtime, etag, http.StatusNotModified},
+		{map[string]string{"If-Modified-Since": after}, modtime, etag, http.StatusNotModified},
+		// Sub-second modification time within the same second.
+		{map[string]string{"If-Modified-Since": equal}, modtime.Add(500 * time.Millisecond), etag, http.StatusNotModified},
+		// If-Unmodified-Since.
+		{map[string]string{"If-Unmodified-Since": before}, modtime, etag, http.StatusPreconditionFailed},
+		{map[string]string{"If-Unmodified-Since": equal}, modtime, etag, http.StatusOK},
+		{map[string]string{"If-Unmodified-Since": after}, modtime, etag, http.StatusOK},
+		// Unparsable dates are ignored.
+		{map[string]string{"If-Modified-Since": "yesterday"}, modtime, etag, http.StatusOK},
+		{map[string]string{"If-Unmodified-Since": "yesterday"}, modtime, etag, http.StatusOK},
+		// Objects without a modification time skip date conditions.
+		{map[string]string{"If-Modified-Since": after}, time.Time{}, etag, http.StatusOK},
+		{map[string]string{"If-Unmodified-Since": before}, unixEpochTime, etag, http.StatusOK},
+		// If-None-Match.
+		{map[string]string{"If-None-Match": "\"" + etag + "\""}, modtime, etag, http.StatusNotModified},
+		{map[string]string{"If-None-Match": etag}, modtime, "\"" + etag + "\"", http.StatusNotModified},
+		{map[string]string{"If-None-Match": "\"abc\""}, modtime, etag, http.StatusOK},
+		{map[string]string{"If-None-Match": "*"}, modtime, etag, http.StatusNotModified},
+		{map[string]string{"If-None-Match": "\"abc\", \"" + etag + "\""}, modtime, etag, http.StatusNotModified},
+		{map[string]string{"If-None-Match": "W/\"" + etag + "\""}, modtime, etag, http.StatusNotModified},
+		// If-Match.
+		{map[string]string{"If-Match": "\"" + etag + "\""}, modtime, etag, http.StatusOK},
+		{map[string]string{"If-Match": "\"abc\""}, modtime, etag, http.StatusPreconditionFailed},
+		{map[string]string{"If-Match": "*"}, modtime, etag, http.StatusOK},
+		{map[string]string{"If-Match": "\"abc\",\"" + etag + "\""}, modtime, etag, http.StatusOK},
+		// Weak entity tags never match with strong comparison.
+		{map[string]string{"If-Match": "W/\"" + etag + "\""}, modtime, etag, http.StatusPreconditionFailed},
+		// Entity tags take precedence over dates.
+		{map[string]string{"If-None-Match": "\"abc\"", "If-Modified-Since": after}, modtime, etag, http.StatusOK},
+		{map[string]string{"If-Match": etag, "If-Unmodified-Since": before}, modtime, etag, http.StatusOK},
+		// Failed preconditions take precedence over not modified.
+		{map[string]string{"If-Match": "\"abc\"", "If-None-Match": etag}, modtime, etag, http.StatusPreconditionFailed},
+	}
+	for i, testCase := range testCases {
+		r, e := http.NewRequest("HEAD", "http://localhost/bucket/object", nil)
+		if e != nil {
+			t.Fatal(e)
+		}
+		for header, value := range testCase.headers {
+			r.Header.Set(header, value)
+		}
+		objInfo := ObjectInfo{ModifiedTime: testCase.modtime, MD5Sum: testCase.etag}
+		status := newGetObjectRequest(r).conditionStatus(objInfo)
+		if status != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, but instead found %d", i+1, testCase.expectedStatus, status)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio/pkg/probe"
+)
+
+// GetObjectRequest - conditions and range of a GET object request.
+type GetObjectRequest struct {
+	// Value of the Range header, the whole object is read when empty.
+	Range string
+	// Comma separated entity tags like in the If-Match and
+	// If-None-Match headers, ignored when empty.
+	IfMatch     string
+	IfNoneMatch string
+	// Dates of the If-Modified-Since and If-Unmodified-Since headers,
+	// ignored when zero.
+	IfModifiedSince   time.Time
+	IfUnmodifiedSince time.Time
+}
+
+// GetObjectResponse - result of a GET object request.
+type GetObjectResponse struct {
+	// Object data for 200 and 206 responses, closed by the caller.
+	Reader io.ReadCloser
+	// One of 200, 206, 304, 412 or 416.
+	StatusCode    int
+	ContentLength int64
+	// Content-Range header of 206 and 416 responses.
+	ContentRange string
+	ETag         string
+	ObjectInfo   ObjectInfo
+}
+
+// newGetObjectRequest - returns the conditions and range of a request,
+// malformed dates are ignored.
+func newGetObjectRequest(r *http.Request) GetObjectRequest {
+	req := GetObjectRequest{
+		Range:       r.Header.Get("Range"),
+		IfMatch:     r.Header.Get("If-Match"),
+		IfNoneMatch: r.Header.Get("If-None-Match"),
+	}
+	if t, e := time.Parse(http.TimeFormat, r.Header.Get("If-Modified-Since")); e == nil {
+		req.IfModifiedSince = t
+	}
+	if t, e := time.Parse(http.TimeFormat, r.Header.Get("If-Unmodified-Since")); e == nil {
+		req.IfUnmodifiedSince = t
+	}
+	return req
+}
+
+// conditionStatus - evaluates the conditions of a request in the order
+// of RFC 7232, returns http.StatusOK if the object should be served,
+// http.StatusNotModified or http.StatusPreconditionFailed otherwise.
+func (req GetObjectRequest) conditionStatus(objInfo ObjectInfo) int {
+	// Modification times of the object and of header dates have second
+	// precision, objects without one skip date conditions.
+	modtime := objInfo.ModifiedTime.Truncate(time.Second)
+	hasModtime := !objInfo.ModifiedTime.IsZero() && !objInfo.ModifiedTime.Equal(unixEpochTime)
+
+	if req.IfMatch != "" {
+		if !etagMatches(req.IfMatch, objInfo.MD5Sum, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if hasModtime && !req.IfUnmodifiedSince.IsZero() && modtime.After(req.IfUnmodifiedSince) {
+		return http.StatusPreconditionFailed
+	}
+	if req.IfNoneMatch != "" {
+		if etagMatches(req.IfNoneMatch, objInfo.MD5Sum, true) {
+			return http.StatusNotModified
+		}
+	} else if hasModtime && !req.IfModifiedSince.IsZero() && !modtime.After(req.IfModifiedSince) {
+		return http.StatusNotModified
+	}
+	return http.StatusOK
+}
+
+// getObjectRequest - serves a GET object request from an object layer,
+// following the S3 semantics of conditions and ranges.
+func getObjectRequest(objAPI ObjectLayer, bucket, object string, req GetObjectRequest) (GetObjectResponse, *probe.Error) {
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return GetObjectResponse{}, err.Trace(bucket, object)
+	}
+	resp := GetObjectResponse{
+		StatusCode: req.conditionStatus(objInfo),
+		ETag:       objInfo.MD5Sum,
+		ObjectInfo: objInfo,
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	hrange, err := getRequestedRange(req.Range, objInfo.Size)
+	if err != nil && err.ToGoError() == errMalformedRange {
+		// Malformed ranges are ignored, the whole object is served.
+		req.Range = ""
+		hrange, err = getRequestedRange(req.Range, objInfo.Size)
+	}
+	if err != nil {
+		// Unsatisfiable ranges carry the current size of the object.
+		resp.StatusCode = http.StatusRequestedRangeNotSatisfiable
+		resp.ContentRange = "bytes */" + strconv.FormatInt(objInfo.Size, 10)
+		return resp, nil
+	}
+	resp.Reader, resp.ContentLength, err = objAPI.GetObjectReader(bucket, object, hrange.start, hrange.length)
+	if err != nil {
+		return GetObjectResponse{}, err.Trace(bucket, object)
+	}
+	if req.Range != "" {
+		resp.StatusCode = http.StatusPartialContent
+		resp.ContentRange = hrange.String()
+	}
+	return resp, nil
+}
@@ -0,0 +1,117 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Tests GetObjectRequest with combinations of conditions and ranges.
+func TestGetObjectRequest(t *testing.T) {
+	execObjectLayerTest(t, testGetObjectRequest)
+}
+
+func testGetObjectRequest(t *testing.T, obj ObjectLayer) {
+	if err := obj.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	data := "0123456789"
+	objInfo, err := obj.PutObject("bucket", "object", int64(len(data)), bytes.NewBufferString(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := "\"" + objInfo.MD5Sum + "\""
+	modtime := objInfo.ModifiedTime.Truncate(time.Second)
+	before, after := modtime.Add(-time.Hour), modtime.Add(time.Hour)
+
+	testCases := []struct {
+		req          GetObjectRequest
+		status       int
+		data         string
+		contentRange string
+	}{
+		// Whole object and ranges.
+		{GetObjectRequest{}, http.StatusOK, data, ""},
+		{GetObjectRequest{Range: "bytes=2-5"}, http.StatusPartialContent, "2345", "bytes 2-5/10"},
+		{GetObjectRequest{Range: "bytes=-3"}, http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{GetObjectRequest{Range: "bytes=8-"}, http.StatusPartialContent, "89", "bytes 8-9/10"},
+		{GetObjectRequest{Range: "bytes=10-"}, http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+		// Malformed ranges are ignored.
+		{GetObjectRequest{Range: "bytes=a-b"}, http.StatusOK, data, ""},
+		{GetObjectRequest{Range: "bytes=5-2"}, http.StatusOK, data, ""},
+		{GetObjectRequest{Range: "items=0-5"}, http.StatusOK, data, ""},
+		// Entity tag conditions.
+		{GetObjectRequest{IfMatch: etag}, http.StatusOK, data, ""},
+		{GetObjectRequest{IfMatch: "\"other\""}, http.StatusPreconditionFailed, "", ""},
+		{GetObjectRequest{IfNoneMatch: etag}, http.StatusNotModified, "", ""},
+		{GetObjectRequest{IfNoneMatch: "*"}, http.StatusNotModified, "", ""},
+		{GetObjectRequest{IfNoneMatch: "\"other\""}, http.StatusOK, data, ""},
+		// Date conditions.
+		{GetObjectRequest{IfModifiedSince: before}, http.StatusOK, data, ""},
+		{GetObjectRequest{IfModifiedSince: after}, http.StatusNotModified, "", ""},
+		{GetObjectRequest{IfUnmodifiedSince: after}, http.StatusOK, data, ""},
+		{GetObjectRequest{IfUnmodifiedSince: before}, http.StatusPreconditionFailed, "", ""},
+		// Matching entity tags take precedence over dates.
+		{GetObjectRequest{IfMatch: etag, IfUnmodifiedSince: before}, http.StatusOK, data, ""},
+		{GetObjectRequest{IfNoneMatch: "\"other\"", IfModifiedSince: after}, http.StatusOK, data, ""},
+		// Failed preconditions take precedence over not modified.
+		{GetObjectRequest{IfMatch: "\"other\"", IfNoneMatch: etag}, http.StatusPreconditionFailed, "", ""},
+		// Conditions are evaluated before ranges.
+		{GetObjectRequest{Range: "bytes=2-5", IfMatch: etag}, http.StatusPartialContent, "2345", "bytes 2-5/10"},
+		{GetObjectRequest{Range: "bytes=2-5", IfNoneMatch: etag}, http.StatusNotModified, "", ""},
+		{GetObjectRequest{Range: "bytes=10-", IfMatch: "\"other\""}, http.StatusPreconditionFailed, "", ""},
+		{GetObjectRequest{Range: "bytes=10-", IfModifiedSince: before}, http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+	}
+	for i, testCase := range testCases {
+		resp, err := obj.GetObjectRequest("bucket", "object", testCase.req)
+		if err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with: <ERROR> %s", i+1, err.Cause.Error())
+		}
+		if resp.StatusCode != testCase.status {
+			t.Errorf("Test %d: Expected status %d, but instead found %d", i+1, testCase.status, resp.StatusCode)
+		}
+		if resp.ContentRange != testCase.contentRange {
+			t.Errorf("Test %d: Expected Content-Range \"%s\", but instead found \"%s\"", i+1, testCase.contentRange, resp.ContentRange)
+		}
+		if resp.ETag != objInfo.MD5Sum {
+			t.Errorf("Test %d: Expected ETag %s, but instead found %s", i+1, objInfo.MD5Sum, resp.ETag)
+		}
+		if resp.Reader == nil {
+			if testCase.data != "" {
+				t.Errorf("Test %d: Expected data \"%s\", but instead found none", i+1, testCase.data)
+			}
+			continue
+		}
+		content, e := ioutil.ReadAll(resp.Reader)
+		resp.Reader.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if string(content) != testCase.data || resp.ContentLength != int64(len(testCase.data)) {
+			t.Errorf("Test %d: Expected data \"%s\", but instead found \"%s\" of length %d", i+1, testCase.data, content, resp.ContentLength)
+		}
+	}
+
+	// Missing objects fail.
+	if _, err = obj.GetObjectRequest("bucket", "missing", GetObjectRequest{}); err == nil {
+		t.Fatal("Expected GetObjectRequest of a missing object to fail")
+	}
+}
@@ -78,22 +78,13 @@ func CreateFile(filePath string) (*File, error) {
 // CreateFileWithSuffix is similar to CreateFileWithPrefix, but the
 // second argument is treated as suffix for the temporary files.
 func CreateFileWithSuffix(filePath string, suffix string) (*File, error) {
-	// If parent directories do not exist, ioutil.TempFile doesn't create them
-	// handle such a case with os.MkdirAll()
-	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
-		return nil, err
-	}
-	f, err := ioutil.TempFile(filepath.Dir(filePath), filepath.Base(filePath)+suffix)
-	if err != nil {
-		return nil, err
-	}
-	if err = os.Chmod(f.Name(), 0600); err != nil {
-		if err = os.Remove(f.Name()); err != nil {
-			return nil, err
-		}
-		return nil, err
-	}
-	return &File{File: f, file: filePath}, nil
+	return CreateFileWithSuffixMode(filePath, suffix, 0700, 0600)
+}
+
+// CreateFileWithSuffixMode is similar to CreateFileWithSuffix, parent
+// directories are created with dirMode and the file with fileMode.
+func CreateFileWithSuffixMode(filePath string, suffix string, dirMode, fileMode os.FileMode) (*File, error) {
+	return createFile(filePath, filepath.Dir(filePath), filepath.Base(filePath)+suffix, dirMode, fileMode)
 }
 
 // CreateFileWithPrefix creates a new file at filePath for safe
@@ -101,16 +92,39 @@ func CreateFileWithSuffix(filePath string, suffix string) (*File, error) {
 // prefix specifies the prefix of the temporary files so that cleaning
 // stale temp files is easy.
 func CreateFileWithPrefix(filePath string, prefix string) (*File, error) {
+	return CreateFileWithPrefixMode(filePath, prefix, 0700, 0600)
+}
+
+// CreateFileWithPrefixMode is similar to CreateFileWithPrefix, parent
+// directories are created with dirMode and the file with fileMode.
+func CreateFileWithPrefixMode(filePath string, prefix string, dirMode, fileMode os.FileMode) (*File, error) {
+	return createFile(filePath, filepath.Dir(filePath), prefix+filepath.Base(filePath), dirMode, fileMode)
+}
+
+// CreateFileInDirMode is similar to CreateFileWithPrefixMode, but the
+// temporary file is created in tempDir instead of next to filePath.
+// tempDir must be on the same filesystem as filePath for Close to
+// rename the file into place.
+func CreateFileInDirMode(filePath string, tempDir string, prefix string, dirMode, fileMode os.FileMode) (*File, error) {
+	return createFile(filePath, tempDir, prefix+filepath.Base(filePath), dirMode, fileMode)
+}
+
+// createFile creates a temporary file named after pattern in tempDir
+// for safe writes to filePath.
+func createFile(filePath string, tempDir string, pattern string, dirMode, fileMode os.FileMode) (*File, error) {
 	// If parent directories do not exist, ioutil.TempFile doesn't create them
 	// handle such a case with os.MkdirAll()
-	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+	if err := os.MkdirAll(filepath.Dir(filePath), dirMode); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(tempDir, dirMode); err != nil {
 		return nil, err
 	}
-	f, err := ioutil.TempFile(filepath.Dir(filePath), prefix+filepath.Base(filePath))
+	f, err := ioutil.TempFile(tempDir, pattern)
 	if err != nil {
 		return nil, err
 	}
-	if err = os.Chmod(f.Name(), 0600); err != nil {
+	if err = os.Chmod(f.Name(), fileMode); err != nil {
 		if err = os.Remove(f.Name()); err != nil {
 			return nil, err
 		}
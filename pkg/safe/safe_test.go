@@ -64,3 +64,33 @@ func (s *MySuite) TestSafePurge(c *C) {
 	err = f.Close()
 	c.Assert(err, Not(IsNil))
 }
+
+func (s *MySuite) TestSafeMode(c *C) {
+	f, err := CreateFileWithPrefixMode(filepath.Join(s.root, "modedir", "modefile"), "$tmp", 0750, 0640)
+	c.Assert(err, IsNil)
+	err = f.Close()
+	c.Assert(err, IsNil)
+	st, err := os.Stat(filepath.Join(s.root, "modedir"))
+	c.Assert(err, IsNil)
+	c.Assert(st.Mode().Perm()&0750, Equals, os.FileMode(0750))
+	st, err = os.Stat(filepath.Join(s.root, "modedir", "modefile"))
+	c.Assert(err, IsNil)
+	c.Assert(st.Mode().Perm(), Equals, os.FileMode(0640))
+}
+
+func (s *MySuite) TestSafeInDir(c *C) {
+	tempDir := filepath.Join(s.root, "tmp")
+	f, err := CreateFileInDirMode(filepath.Join(s.root, "indir", "indirfile"), tempDir, "$tmp", 0700, 0600)
+	c.Assert(err, IsNil)
+	c.Assert(filepath.Dir(f.Name()), Equals, tempDir)
+	_, err = f.Write([]byte("data"))
+	c.Assert(err, IsNil)
+	err = f.Close()
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadFile(filepath.Join(s.root, "indir", "indirfile"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "data")
+	entries, err := ioutil.ReadDir(tempDir)
+	c.Assert(err, IsNil)
+	c.Assert(len(entries), Equals, 0)
+}
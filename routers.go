@@ -23,7 +23,7 @@ import (
 )
 
 // configureServer handler returns final handler for the http server.
-func configureServerHandler(objectAPI ObjectAPI) http.Handler {
+func configureServerHandler(objectAPI ObjectLayer) http.Handler {
 	// Initialize API.
 	api := objectStorageAPI{
 		ObjectAPI: objectAPI,
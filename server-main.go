@@ -70,7 +70,7 @@ EXAMPLES:
 }
 
 // configureServer configure a new server instance
-func configureServer(serverAddr string, objectAPI ObjectAPI) *http.Server {
+func configureServer(serverAddr string, objectAPI ObjectLayer) *http.Server {
 	// Minio server config
 	apiServer := &http.Server{
 		Addr:           serverAddr,
@@ -133,16 +133,11 @@ func initServerConfig(c *cli.Context) {
 
 	// Validate if both keys are specified and they are valid save them.
 	if accessKey != "" && secretKey != "" {
-		if !isValidAccessKey.MatchString(accessKey) {
-			fatalIf(probe.NewError(errInvalidArgument), "Access key does not have required length", nil)
-		}
-		if !isValidSecretKey.MatchString(secretKey) {
-			fatalIf(probe.NewError(errInvalidArgument), "Secret key does not have required length", nil)
-		}
-		serverConfig.SetCredential(credential{
+		err = serverConfig.SetCredential(credential{
 			AccessKeyID:     accessKey,
 			SecretAccessKey: secretKey,
 		})
+		fatalIf(err.Trace(), "Invalid credentials in MINIO_ACCESS_KEY and MINIO_SECRET_KEY.", nil)
 	}
 }
 
@@ -258,7 +253,7 @@ func serverMain(c *cli.Context) {
 	// Check configured ports.
 	checkPortAvailability(getPort(net.JoinHostPort(host, port)))
 
-	var objectAPI ObjectAPI
+	var objectAPI ObjectLayer
 	var err *probe.Error
 
 	// Set backend FS type.
@@ -306,4 +301,9 @@ func serverMain(c *cli.Context) {
 	// Start server.
 	err = minhttp.ListenAndServe(apiServer)
 	errorIf(err.Trace(), "Failed to start the minio server.", nil)
+
+	// Stop background workers once the server stopped.
+	if fs, ok := objectAPI.(*Filesystem); ok {
+		errorIf(probe.NewError(fs.Close()), "Unable to close the filesystem.", nil)
+	}
 }
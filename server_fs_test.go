@@ -31,6 +31,7 @@ import (
 
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
 	"net/http"
 	"net/http/httptest"
@@ -349,6 +350,143 @@ func (s *MyAPISuite) TestBucketPolicy(c *C) {
 	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
 }
 
+func (s *MyAPISuite) TestGetBucketPolicyNotFound(c *C) {
+	bucketPolicyBuf := `{
+    "Version": "2012-10-17",
+    "Statement": [
+        {
+            "Action": ["s3:GetObject"],
+            "Effect": "Allow",
+            "Principal": {"AWS": ["*"]},
+            "Resource": ["arn:aws:s3:::nopolicybucket/*"]
+        }
+    ]
+}`
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/nopolicybucket?policy", int64(len(bucketPolicyBuf)), bytes.NewReader([]byte(bucketPolicyBuf)))
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/nopolicybucket?policy", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	// The deleted policy is not found.
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/nopolicybucket?policy", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	data, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, xml.Header+"<Error><Code>NoSuchBucketPolicy</Code><Message>The specified bucket does not have a bucket policy.</Message><Key></Key><BucketName></BucketName><Resource>/nopolicybucket</Resource><RequestId>3L137</RequestId><HostId>3L137</HostId></Error>")
+
+	// Deleting it again fails the same way.
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/nopolicybucket?policy", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchBucketPolicy", "The specified bucket does not have a bucket policy.", http.StatusNotFound)
+
+	// Unless deletes are requested to be idempotent.
+	request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/nopolicybucket?policy", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Minio-Idempotent-Delete", "true")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+}
+
+func (s *MyAPISuite) TestDeleteBucketPolicyIdempotent(c *C) {
+	bucketPolicyBuf := `{
+    "Version": "2012-10-17",
+    "Statement": [
+        {
+            "Action": ["s3:GetObject"],
+            "Effect": "Allow",
+            "Principal": {"AWS": ["*"]},
+            "Resource": ["arn:aws:s3:::idempotentpolicybucket/*"]
+        }
+    ]
+}`
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/idempotentpolicybucket?policy", int64(len(bucketPolicyBuf)), bytes.NewReader([]byte(bucketPolicyBuf)))
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	// Deleting an existing policy, then again the missing one.
+	for i := 0; i < 2; i++ {
+		request, err = s.newRequest("DELETE", testAPIFSCacheServer.URL+"/idempotentpolicybucket?policy", 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Set("X-Minio-Idempotent-Delete", "true")
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	}
+
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/idempotentpolicybucket?policy", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchBucketPolicy", "The specified bucket does not have a bucket policy.", http.StatusNotFound)
+}
+
+func (s *MyAPISuite) TestRotateCredentials(c *C) {
+	oldCreds := s.credential
+	defer func() {
+		c.Assert(rotateCredentials(oldCreds.AccessKeyID, oldCreds.SecretAccessKey), IsNil)
+		s.credential = oldCreds
+	}()
+
+	// Invalid keys are rejected.
+	c.Assert(rotateCredentials("abc", "abcdefghijkl"), Not(IsNil))
+	c.Assert(rotateCredentials("ROTATEDACCESSKEY", "short"), Not(IsNil))
+	c.Assert(serverConfig.GetCredential(), Equals, oldCreds)
+
+	c.Assert(rotateCredentials("ROTATEDACCESSKEY", "rotated/secret+key"), IsNil)
+
+	// Requests signed with the old credential are rejected.
+	request, err := s.newRequest("GET", testAPIFSCacheServer.URL+"/", 0, nil)
+	c.Assert(err, IsNil)
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	defer response.Body.Close()
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+
+	// The new credential authenticates.
+	s.credential = credential{AccessKeyID: "ROTATEDACCESSKEY", SecretAccessKey: "rotated/secret+key"}
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	defer response.Body.Close()
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// The config file holds the new credential.
+	configFile, perr := getConfigFile()
+	c.Assert(perr, IsNil)
+	data, err := ioutil.ReadFile(configFile)
+	c.Assert(err, IsNil)
+	savedConfig := serverConfigV4{}
+	c.Assert(json.Unmarshal(data, &savedConfig), IsNil)
+	c.Assert(savedConfig.Credential, Equals, s.credential)
+}
+
 func (s *MyAPISuite) TestDeleteBucket(c *C) {
 	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/deletebucket", 0, nil)
 	c.Assert(err, IsNil)
@@ -483,6 +621,55 @@ func (s *MyAPISuite) TestObject(c *C) {
 
 }
 
+func (s *MyAPISuite) TestAnonymousGetObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/anonymousbucket", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/anonymousbucket/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// Denied without a policy.
+	response, err = client.Get(testAPIFSCacheServer.URL + "/anonymousbucket/object")
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+
+	// Allowed by a public read policy.
+	bucketPolicyBuf := `{"Version": "2012-10-17", "Statement": [{"Action": ["s3:GetObject"], "Effect": "Allow", "Principal": {"AWS": ["*"]}, "Resource": ["arn:aws:s3:::anonymousbucket/*"]}]}`
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/anonymousbucket?policy", int64(len(bucketPolicyBuf)), bytes.NewReader([]byte(bucketPolicyBuf)))
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	response, err = client.Get(testAPIFSCacheServer.URL + "/anonymousbucket/object")
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
+
+	// Writes are still denied.
+	buffer = bytes.NewReader([]byte("hello world"))
+	request, err = http.NewRequest("PUT", testAPIFSCacheServer.URL+"/anonymousbucket/object", buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+}
+
 func (s *MyAPISuite) TestMultipleObjects(c *C) {
 	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/multipleobjects", 0, nil)
 	c.Assert(err, IsNil)
@@ -745,6 +932,15 @@ func (s *MyAPISuite) TestHeadOnObject(c *C) {
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusPreconditionFailed)
+
+	// Mismatching entity tags take precedence over dates like on GET.
+	request, err = s.newRequest("HEAD", testAPIFSCacheServer.URL+"/headonobject/object1", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-None-Match", "\"abc\"")
+	request.Header.Set("If-Modified-Since", t.Add(1*time.Minute).UTC().Format(http.TimeFormat))
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 }
 
 func (s *MyAPISuite) TestHeadOnBucket(c *C) {
@@ -1013,9 +1209,28 @@ func (s *MyAPISuite) TestGetObjectRangeErrors(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
+	// Invalid and malformed ranges are ignored, the whole object is
+	// served.
+	for _, hrange := range []string{"bytes=7-6", "bytes=a-b", "bytes=0-1,5-6", "items=0-5"} {
+		request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/getobjectrangeerrors/bar", 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Add("Range", hrange)
+
+		client = http.Client{}
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		c.Assert(response.Header.Get("Content-Range"), Equals, "")
+		object, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		response.Body.Close()
+		c.Assert(string(object), Equals, "Hello World")
+	}
+
+	// Unsatisfiable range.
 	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/getobjectrangeerrors/bar", 0, nil)
-	request.Header.Add("Range", "bytes=7-6")
 	c.Assert(err, IsNil)
+	request.Header.Add("Range", "bytes=11-12")
 
 	client = http.Client{}
 	response, err = client.Do(request)
@@ -1023,6 +1238,101 @@ func (s *MyAPISuite) TestGetObjectRangeErrors(c *C) {
 	verifyError(c, response, "InvalidRange", "The requested range cannot be satisfied.", http.StatusRequestedRangeNotSatisfiable)
 }
 
+func (s *MyAPISuite) TestGetObjectRanges(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/getobjectranges", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer1 := bytes.NewReader([]byte("Hello World"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/getobjectranges/bar", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	testCases := []struct {
+		hrange       string
+		content      string
+		contentRange string
+	}{
+		// Suffix range.
+		{"bytes=-5", "World", "bytes 6-10/11"},
+		// Open ended range.
+		{"bytes=6-", "World", "bytes 6-10/11"},
+		// End past the end of object is clamped.
+		{"bytes=4-100", "o World", "bytes 4-10/11"},
+	}
+	for _, testCase := range testCases {
+		request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/getobjectranges/bar", 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Add("Range", testCase.hrange)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
+		c.Assert(response.Header.Get("Content-Range"), Equals, testCase.contentRange)
+		partialObject, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		c.Assert(string(partialObject), Equals, testCase.content)
+	}
+
+	// Range starting past the end of object.
+	request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/getobjectranges/bar", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("Range", "bytes=11-")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.Header.Get("Content-Range"), Equals, "bytes */11")
+	verifyError(c, response, "InvalidRange", "The requested range cannot be satisfied.", http.StatusRequestedRangeNotSatisfiable)
+}
+
+func (s *MyAPISuite) TestGetObjectETagConditions(c *C) {
+	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/getobjectetagconditions", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIFSCacheServer.URL+"/getobjectetagconditions/bar", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	etag := response.Header.Get("ETag")
+
+	testCases := []struct {
+		header         string
+		value          string
+		expectedStatus int
+	}{
+		{"If-None-Match", etag, http.StatusNotModified},
+		{"If-None-Match", "*", http.StatusNotModified},
+		{"If-None-Match", "\"abc\"", http.StatusOK},
+		{"If-Match", etag, http.StatusOK},
+		{"If-Match", "\"abc\", " + etag, http.StatusOK},
+		{"If-Match", "\"abc\"", http.StatusPreconditionFailed},
+	}
+	for _, testCase := range testCases {
+		request, err = s.newRequest("GET", testAPIFSCacheServer.URL+"/getobjectetagconditions/bar", 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Add(testCase.header, testCase.value)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, testCase.expectedStatus)
+	}
+}
+
 func (s *MyAPISuite) TestObjectMultipartAbort(c *C) {
 	request, err := s.newRequest("PUT", testAPIFSCacheServer.URL+"/objectmultipartabort", 0, nil)
 	c.Assert(err, IsNil)
@@ -24,8 +24,21 @@ var errSyslogNotSupported = errors.New("Syslog logger not supported on windows")
 // errInvalidArgument means that input argument is invalid.
 var errInvalidArgument = errors.New("Invalid arguments specified")
 
+// errInvalidAccessKey means an access key is not 5 to 20 letters,
+// digits, '-', '.', '_' or '~'.
+var errInvalidAccessKey = errors.New("Access key must be 5 to 20 letters, digits, '-', '.', '_' or '~'")
+
+// errInvalidSecretKey means a secret key is not 8 to 40 characters.
+var errInvalidSecretKey = errors.New("Secret key must be 8 to 40 characters")
+
 // errSignatureMismatch means signature did not match.
 var errSignatureMismatch = errors.New("Signature does not match")
 
 // used when token used for authentication by the MinioBrowser has expired
 var errInvalidToken = errors.New("Invalid token")
+
+// errUploadIDAttemptsExhausted means no unused upload id could be generated.
+var errUploadIDAttemptsExhausted = errors.New("Unable to generate an unused upload id")
+
+// errWriteNotVisible means a written file is not visible after its rename.
+var errWriteNotVisible = errors.New("Written file is not visible after rename")
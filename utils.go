@@ -18,6 +18,8 @@ package main
 
 import (
 	"encoding/base64"
+	"encoding/hex"
+	"net/http"
 	"strings"
 
 	"github.com/minio/minio/pkg/probe"
@@ -32,6 +34,28 @@ func checkValidMD5(md5 string) ([]byte, *probe.Error) {
 	return md5Bytes, nil
 }
 
+// checkValidChecksums - verify the base64 encoded checksums declared in
+// the X-Amz-Checksum-Crc32c and X-Amz-Checksum-Sha256 headers, returns
+// them hex encoded as "crc32c" and "sha256" metadata.
+func checkValidChecksums(header http.Header) (map[string]string, *probe.Error) {
+	metadata := make(map[string]string)
+	for algorithm, name := range map[string]string{
+		checksumCRC32C: "X-Amz-Checksum-Crc32c",
+		checksumSHA256: "X-Amz-Checksum-Sha256",
+	} {
+		value := strings.TrimSpace(header.Get(name))
+		if value == "" {
+			continue
+		}
+		checksum, e := base64.StdEncoding.DecodeString(value)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		metadata[algorithm] = hex.EncodeToString(checksum)
+	}
+	return metadata, nil
+}
+
 /// http://docs.aws.amazon.com/AmazonS3/latest/dev/UploadingObjects.html
 const (
 	// maximum object size per PUT request is 5GiB
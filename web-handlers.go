@@ -311,14 +311,10 @@ func (web *webAPI) SetAuth(r *http.Request, args *SetAuthArgs, reply *SetAuthRep
 	if !isJWTReqAuthenticated(r) {
 		return &json2.Error{Message: "Unauthorized request"}
 	}
-	if !isValidAccessKey.MatchString(args.AccessKey) {
-		return &json2.Error{Message: "Invalid Access Key"}
-	}
-	if !isValidSecretKey.MatchString(args.SecretKey) {
-		return &json2.Error{Message: "Invalid Secret Key"}
-	}
 	cred := credential{args.AccessKey, args.SecretKey}
-	serverConfig.SetCredential(cred)
+	if err := serverConfig.SetCredential(cred); err != nil {
+		return &json2.Error{Message: err.Cause.Error()}
+	}
 	if err := serverConfig.Save(); err != nil {
 		return &json2.Error{Message: err.Cause.Error()}
 	}
@@ -414,6 +410,8 @@ func writeWebErrorResponse(w http.ResponseWriter, err error) {
 	switch err.(type) {
 	case RootPathFull:
 		apiErrCode = ErrRootPathFull
+	case RootPathNotFound, RootPathNotDir:
+		apiErrCode = ErrRootPathNotFound
 	case BucketNotFound:
 		apiErrCode = ErrNoSuchBucket
 	case BucketNameInvalid:
@@ -424,6 +422,8 @@ func writeWebErrorResponse(w http.ResponseWriter, err error) {
 		apiErrCode = ErrIncompleteBody
 	case ObjectExistsAsPrefix:
 		apiErrCode = ErrObjectExistsAsPrefix
+	case PrefixExistsAsObject:
+		apiErrCode = ErrPrefixExistsAsObject
 	case ObjectNotFound:
 		apiErrCode = ErrNoSuchKey
 	case ObjectNameInvalid:
@@ -30,7 +30,7 @@ import (
 
 // webAPI container for Web API.
 type webAPI struct {
-	ObjectAPI ObjectAPI
+	ObjectAPI ObjectLayer
 }
 
 // indexHandler - Handler to serve index.html